@@ -1,32 +1,643 @@
 package app
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
 	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/domain/chat"
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/domain/documents"
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/domain/models"
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/domain/search"
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/domain/sites"
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/watch"
+	sharedgo "github.com/Joepolymath/DaVinci/libs/shared-go"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/chunking"
 	"github.com/Joepolymath/DaVinci/libs/shared-go/config"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/crawler"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/embedding"
 	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai/cache"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai/limiter"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai/openai/embeddings"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai/window"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/db/graph"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/db/graph/neo4j"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/db/vector/local"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/loaders"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/prompts"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/retrieval"
+	"github.com/Joepolymath/DaVinci/memory"
 	"go.uber.org/zap"
 )
 
 type Services struct {
-	ChatService chat.Service
+	ChatService  chat.Service
+	ModelService models.Service
+
+	// ChatRouter dispatches chat requests to the configured providers by model name.
+	ChatRouter *ai.Router
+
+	// OpenAILimiter is shared by every OpenAI-backed provider (chat and
+	// embeddings) since they draw from the same API key quota. LocalLimiter
+	// is shared by the local providers, which run on the same host. Each is
+	// nil when its provider has no limit configured.
+	OpenAILimiter *limiter.Limiter
+	LocalLimiter  *limiter.Limiter
+
+	// ChatCache and EmbeddingCache hold deterministic completions and
+	// per-text embeddings respectively.
+	ChatCache      *cache.Cache
+	EmbeddingCache *cache.Cache
+
+	Prompts *prompts.Registry
+
+	// Embeddings is nil when no embedding provider is configured.
+	Embeddings embedding.Provider
+	Vectors    local.Service
+	Collection string // searched when a request doesn't name one
+
+	// Graph and GraphIndexer are nil when GRAPH_STORE is none.
+	Graph         graph.Store
+	GraphIndexer  *retrieval.GraphIndexer
+	Retriever     retrieval.Retriever
+	SearchService search.Service
+
+	// Parents keeps the parent sections chunks are cut from when
+	// PARENT_TOKENS is set; Retriever then returns them in place of chunks.
+	Parents retrieval.ParentStore
+
+	// Loaders read uploaded files by format for DocumentService, which
+	// ingests them as background jobs once started.
+	Loaders         *loaders.Registry
+	DocumentService documents.Service
+
+	// Watcher ingests the files of WATCH_DIRS, or of the directories it is
+	// run on.
+	Watcher *watch.Watcher
+
+	// SiteService crawls web pages and sites into the index and refreshes
+	// them once started.
+	SiteService sites.Service
 }
 
 func InitServices(cfg *config.Config, logger *zap.Logger) *Services {
-	chatProviderConfig := &ai.ChatProviderConfig{
-		Provider:     ai.ProviderOpenAI,
-		OpenAIAPIKey: cfg.OpenAIAPIKey,
-		OpenAIModel:  cfg.OpenAIModel,
-		LocalHost:    cfg.LocalHost,
-		LocalModel:   cfg.LocalModel,
-	}
+	openAILimiter := newLimiter(ai.ProviderOpenAI, limiter.Config{
+		RequestsPerMinute:  cfg.OpenAIRequestsPerMinute,
+		TokensPerMinute:    cfg.OpenAITokensPerMinute,
+		InteractiveReserve: cfg.InteractiveReserve,
+	}, logger)
+	localLimiter := newLimiter(ai.ProviderLocal, limiter.Config{
+		RequestsPerMinute:  cfg.LocalRequestsPerMinute,
+		TokensPerMinute:    cfg.LocalTokensPerMinute,
+		InteractiveReserve: cfg.InteractiveReserve,
+	}, logger)
 
-	chatProvider, err := ai.NewChatProvider(chatProviderConfig, logger)
+	router, err := newChatRouter(cfg, logger, map[ai.ProviderType]*limiter.Limiter{
+		ai.ProviderOpenAI: openAILimiter,
+		ai.ProviderLocal:  localLimiter,
+	})
 	if err != nil {
 		logger.Error("Failed to create chat provider", zap.Error(err))
 		return nil
 	}
 
-	return &Services{
-		ChatService: chat.NewService(chatProvider),
+	// The cache sits outside the limiter so hits don't spend rate limit budget.
+	chatCache, err := newCache(cfg, "chat")
+	if err != nil {
+		logger.Error("Failed to create chat cache", zap.Error(err))
+		return nil
+	}
+	promptRegistry, err := prompts.NewRegistry(cfg.PromptsDir, logger)
+	if err != nil {
+		logger.Error("Failed to load prompt templates", zap.Error(err))
+		return nil
+	}
+
+	windowBuilder := newWindowBuilder(cfg, router, promptRegistry)
+	chatProvider := cache.NewChatProvider(newContextWindow(router, windowBuilder, logger), chatCache, logger)
+
+	conversations, err := newMemory(cfg, router, promptRegistry, logger)
+	if err != nil {
+		logger.Error("Failed to create conversation memory", zap.Error(err))
+		return nil
+	}
+
+	embeddingCache, err := newCache(cfg, "embeddings")
+	if err != nil {
+		logger.Error("Failed to create embedding cache", zap.Error(err))
+		return nil
+	}
+	embeddings := newEmbeddingProvider(cfg, openAILimiter, embeddingCache, logger)
+
+	facts, err := newFacts(cfg, router, embeddings, promptRegistry, logger)
+	if err != nil {
+		logger.Error("Failed to create long-term memory", zap.Error(err))
+		return nil
+	}
+	vectors, err := openVectors(cfg, cfg.VectorDir, logger)
+	if err != nil {
+		logger.Error("Failed to create vector store", zap.Error(err))
+		return nil
+	}
+
+	graphStore, err := newGraphStore(cfg, logger)
+	if err != nil {
+		logger.Error("Failed to create knowledge graph", zap.Error(err))
+		return nil
+	}
+	collection := cfg.VectorCollection
+	if collection == "" {
+		collection = sharedgo.ScribeQueryIndex
+	}
+	index := retrieval.LocalIndex{Vectors: vectors}
+	var (
+		retriever    retrieval.Retriever = retrieval.NewVectorRetriever(index, embeddings, collection)
+		graphIndexer *retrieval.GraphIndexer
+	)
+	if graphStore != nil {
+		extractor := retrieval.NewLLMGraphExtractor(router, cfg.GraphModel, promptRegistry)
+		graphIndexer = retrieval.NewGraphIndexer(graphStore, extractor)
+		retriever = retrieval.NewGraphRetriever(retriever, index, collection, graphStore, extractor,
+			retrieval.GraphConfig{Hops: cfg.GraphHops}, logger)
+	}
+	reranker, err := newReranker(cfg, router, promptRegistry, logger)
+	if err != nil {
+		logger.Error("Failed to create reranker", zap.Error(err))
+		return nil
+	}
+	if reranker != nil {
+		retriever = retrieval.NewRerankingRetriever(retriever, reranker, retrieval.RerankConfig{
+			OverFetch: cfg.RerankOverFetch,
+			Depth:     cfg.RerankDepth,
+			MinScore:  float32(cfg.RerankMinScore),
+		}, logger)
+	}
+	queryMode, err := retrieval.ParseMode(cfg.QueryMode)
+	if err != nil {
+		logger.Error("Invalid QUERY_MODE", zap.Error(err))
+		return nil
+	}
+	transformer := retrieval.NewQueryTransformer(router, promptRegistry, retrieval.TransformConfig{
+		Mode:        queryMode,
+		Model:       cfg.QueryModel,
+		Paraphrases: cfg.QueryParaphrases,
+	})
+	parents, err := newParentStore(cfg)
+	if err != nil {
+		logger.Error("Failed to create parent store", zap.Error(err))
+		return nil
+	}
+	if cfg.ParentTokens > 0 {
+		retriever = retrieval.NewParentRetriever(retriever, parents, collection, logger)
+	}
+	loaderRegistry := loaders.NewRegistry()
+	jobs, err := newJobStore(cfg)
+	if err != nil {
+		logger.Error("Failed to create ingestion job store", zap.Error(err))
+		return nil
+	}
+	chunkingCfg, strategies, err := newChunking(cfg)
+	if err != nil {
+		logger.Error("Invalid chunking configuration", zap.Error(err))
+		return nil
+	}
+	siteStore, err := newSiteStore(cfg)
+	if err != nil {
+		logger.Error("Failed to create site store", zap.Error(err))
+		return nil
+	}
+
+	services := &Services{
+		ChatService:    chat.NewService(chatProvider, windowBuilder, promptRegistry, conversations, facts, logger),
+		Prompts:        promptRegistry,
+		ModelService:   models.NewService(router, cfg.ModelCatalogRefresh),
+		ChatRouter:     router,
+		OpenAILimiter:  openAILimiter,
+		LocalLimiter:   localLimiter,
+		ChatCache:      chatCache,
+		EmbeddingCache: embeddingCache,
+		Embeddings:     embeddings,
+		Vectors:        vectors,
+		Collection:     collection,
+		Graph:          graphStore,
+		GraphIndexer:   graphIndexer,
+		Retriever:      retriever,
+		SearchService:  search.NewService(retriever, transformer, chatProvider, windowBuilder, promptRegistry),
+		Parents:        parents,
+		Loaders:        loaderRegistry,
+		DocumentService: documents.NewService(loaderRegistry, embeddings, vectors, graphIndexer, parents, jobs, documents.Config{
+			Collection:   collection,
+			Workers:      cfg.IngestWorkers,
+			QueueSize:    cfg.IngestQueue,
+			Chunking:     chunkingCfg,
+			Strategies:   strategies,
+			ParentTokens: cfg.ParentTokens,
+		}, logger),
+	}
+	services.Watcher = watch.New(services.DocumentService, loaderRegistry, watch.Config{
+		Dirs:       watchDirs(cfg),
+		Collection: cfg.WatchCollection,
+		Debounce:   cfg.WatchDebounce,
+		StatePath:  cfg.WatchState,
+	}, logger)
+	services.SiteService = sites.NewService(services.DocumentService, crawler.NewFetcher(crawler.Config{
+		UserAgent: cfg.WebUserAgent,
+		Delay:     cfg.WebDelay,
+	}), siteStore, logger)
+	return services
+}
+
+// newChatRouter creates every provider listed in PROVIDERS (or just PROVIDER)
+// and routes between them using MODEL_ROUTES. Each provider takes its
+// settings from PROVIDER_<NAME>_API_KEY, _MODEL and _HOST, falling back to
+// those of its type (OPENAI_API_KEY, OPENAI_MODEL, LOCAL_HOST, LOCAL_MODEL).
+func newChatRouter(cfg *config.Config, logger *zap.Logger, limiters map[ai.ProviderType]*limiter.Limiter) (*ai.Router, error) {
+	defaultName := cfg.Provider
+	if defaultName == "" {
+		defaultName = string(ai.ProviderOpenAI)
+	}
+
+	providerList := cfg.Providers
+	if providerList == "" {
+		providerList = defaultName
+	}
+	specs, err := ai.ParseProviderSpecs(providerList)
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := ai.ParseRoutes(cfg.ModelRoutes)
+	if err != nil {
+		return nil, err
+	}
+
+	providers := make(map[string]ai.ChatProvider, len(specs))
+	for _, spec := range specs {
+		provider, err := ai.NewChatProvider(&ai.ChatProviderConfig{
+			Provider:     spec.Type,
+			OpenAIAPIKey: cfg.ProviderEnv(spec.Name, "API_KEY", cfg.OpenAIAPIKey),
+			OpenAIModel:  cfg.ProviderEnv(spec.Name, "MODEL", cfg.OpenAIModel),
+			LocalHost:    cfg.ProviderEnv(spec.Name, "HOST", cfg.LocalHost),
+			LocalModel:   cfg.ProviderEnv(spec.Name, "MODEL", cfg.LocalModel),
+		}, logger)
+		if err != nil {
+			return nil, fmt.Errorf("provider %q: %w", spec.Name, err)
+		}
+		if l := limiters[spec.Type]; l != nil {
+			provider = limiter.NewChatProvider(provider, l)
+		}
+		providers[spec.Name] = provider
+
+		logger.Info("Chat provider configured",
+			zap.String("name", spec.Name),
+			zap.String("type", string(spec.Type)),
+			zap.String("model", provider.GetModel()))
+	}
+
+	return ai.NewRouter(providers, routes, defaultName)
+}
+
+// newWindowBuilder creates the builder that fits prompts into the model's
+// context window, summarizing dropped turns with provider when
+// CONTEXT_SUMMARIZE is set.
+func newWindowBuilder(cfg *config.Config, provider ai.ChatProvider, registry *prompts.Registry) *window.Builder {
+	var summarizer window.Summarizer
+	if cfg.ContextSummarize {
+		summarizer = window.NewSummarizer(provider, cfg.ContextSummaryModel, func() (string, error) {
+			text, _, err := registry.Render(prompts.SummarizeHistory, nil)
+			return text, err
+		})
+	}
+	return window.NewBuilder(window.Config{
+		ContextWindow:  cfg.ContextWindow,
+		ReservedOutput: cfg.ContextReservedOutput,
+	}, summarizer)
+}
+
+// newContextWindow wraps provider so requests are trimmed to the model's
+// context window by builder.
+func newContextWindow(provider ai.ChatProvider, builder *window.Builder, logger *zap.Logger) ai.ChatProvider {
+	return window.NewChatProvider(provider, builder, func(model string, r *window.Report) {
+		logger.Info("Trimmed chat request to fit the context window",
+			zap.String("model", model),
+			zap.Int("context_window", r.ContextWindow),
+			zap.Int("prompt_tokens", r.PromptTokens),
+			zap.Int("dropped_turns", r.DroppedTurns),
+			zap.Bool("summarized", r.Summarized),
+			zap.String("summary_error", r.SummaryError),
+			zap.Bool("system_truncated", r.SystemTruncated))
+	})
+}
+
+// newMemory creates the conversation memory selected by MEMORY_STRATEGY, or
+// nil when it is disabled. Token budgets are counted with the tokenizer of
+// provider's default model.
+func newMemory(cfg *config.Config, provider ai.ChatProvider, registry *prompts.Registry, logger *zap.Logger) (*memory.Memory, error) {
+	maxTurns := cfg.MemoryMaxTurns
+	if maxTurns <= 0 {
+		maxTurns = 20
+	}
+	maxTokens := cfg.MemoryMaxTokens
+	if maxTokens <= 0 {
+		maxTokens = 4000
+	}
+
+	var strategy memory.Strategy
+	switch cfg.MemoryStrategy {
+	case "none":
+		return nil, nil
+	case "window":
+		strategy = &memory.SlidingWindow{MaxTurns: maxTurns}
+	case "", "tokens":
+		strategy = &memory.TokenBuffer{MaxTokens: maxTokens, Model: provider.GetModel()}
+	case "summary":
+		strategy = &memory.RollingSummary{
+			MaxTokens:  maxTokens,
+			Model:      provider.GetModel(),
+			Summarizer: memory.NewLLMSummarizer(provider, cfg.ContextSummaryModel, registry),
+		}
+	default:
+		return nil, fmt.Errorf("unknown memory strategy %q", cfg.MemoryStrategy)
+	}
+
+	var store memory.Store = memory.NewInMemoryStore()
+	if cfg.MemoryDir != "" {
+		fileStore, err := memory.NewFileStore(cfg.MemoryDir)
+		if err != nil {
+			return nil, err
+		}
+		store = fileStore
+	}
+
+	logger.Info("Conversation memory enabled",
+		zap.String("strategy", cfg.MemoryStrategy),
+		zap.String("dir", cfg.MemoryDir))
+	return memory.New(store, strategy), nil
+}
+
+// newEmbeddingProvider returns the OpenAI embedding provider, rate limited
+// alongside chat and cached per text, or nil without an OpenAI API key.
+func newEmbeddingProvider(cfg *config.Config, l *limiter.Limiter, c *cache.Cache, logger *zap.Logger) embedding.Provider {
+	if cfg.OpenAIAPIKey == "" {
+		logger.Warn("No OpenAI API key, embeddings are disabled")
+		return nil
+	}
+	client, err := embeddings.NewClient(&embeddings.Config{
+		APIKey: cfg.OpenAIAPIKey,
+		Model:  cfg.OpenAIEmbedModel,
+	}, logger)
+	if err != nil {
+		logger.Error("Failed to create embedding client", zap.Error(err))
+		return nil
+	}
+
+	provider := embeddings.NewEmbeddingProvider(client)
+	if l != nil {
+		provider = limiter.NewEmbeddingProvider(provider, l)
+	}
+	return cache.NewEmbeddingProvider(provider, c, logger)
+}
+
+// newFacts creates the long-term memory of user facts, or nil when it is
+// disabled or there is no embedding provider.
+func newFacts(cfg *config.Config, provider ai.ChatProvider, embedder embedding.Provider, registry *prompts.Registry, logger *zap.Logger) (*memory.Facts, error) {
+	if !cfg.MemoryFacts {
+		return nil, nil
+	}
+	if embedder == nil {
+		logger.Warn("Long-term memory needs an embedding provider and is disabled")
+		return nil, nil
+	}
+	vectors, err := newFactsStore(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Long-term memory enabled", zap.String("model", cfg.MemoryFactsModel))
+	return memory.NewFacts(vectors, embedder, memory.NewLLMExtractor(provider, cfg.MemoryFactsModel, registry), memory.FactsConfig{
+		DedupeThreshold: float32(cfg.MemoryFactsDedupe),
+		RecallLimit:     cfg.MemoryFactsRecall,
+		RecallThreshold: float32(cfg.MemoryFactsThreshold),
+	}), nil
+}
+
+// newFactsStore opens the vector store of user facts, VECTOR_DIR/facts, kept
+// apart from the document collections so neither can reach the other's.
+// Facts used to live among them as facts_<user> collections; those are moved
+// over first.
+func newFactsStore(cfg *config.Config, logger *zap.Logger) (local.Service, error) {
+	if cfg.VectorDir == "" {
+		return local.NewService("", logger)
+	}
+	dir := filepath.Join(cfg.VectorDir, "facts")
+	if cfg.VectorReadOnly {
+		return openVectors(cfg, dir, logger)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create facts directory %q: %w", dir, err)
+	}
+	files, err := filepath.Glob(filepath.Join(cfg.VectorDir, "facts_*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if err := os.Rename(file, filepath.Join(dir, filepath.Base(file))); err != nil {
+			return nil, fmt.Errorf("move user facts: %w", err)
+		}
+	}
+	if len(files) > 0 {
+		logger.Info("Moved user facts to their own store", zap.String("dir", dir), zap.Int("users", len(files)))
+	}
+	return openVectors(cfg, dir, logger)
+}
+
+// openVectors opens the in-process vector store in dir, read-only when
+// VectorReadOnly is set.
+func openVectors(cfg *config.Config, dir string, logger *zap.Logger) (local.Service, error) {
+	if cfg.VectorReadOnly {
+		return local.NewReadOnlyService(dir, logger)
 	}
+	return local.NewService(dir, logger)
+}
+
+// newGraphStore creates the knowledge graph selected by GRAPH_STORE, or nil
+// when it is disabled.
+func newGraphStore(cfg *config.Config, logger *zap.Logger) (graph.Store, error) {
+	switch cfg.GraphStore {
+	case "", "none":
+		return nil, nil
+	case "memory":
+		logger.Info("Knowledge graph enabled", zap.String("store", "memory"))
+		return graph.NewMemoryStore(), nil
+	case "neo4j":
+		client, err := neo4j.NewClient(&neo4j.Config{
+			URL:      cfg.Neo4jURL,
+			Username: cfg.Neo4jUsername,
+			Password: cfg.Neo4jPassword,
+			Database: cfg.Neo4jDatabase,
+		}, logger)
+		if err != nil {
+			return nil, err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		logger.Info("Knowledge graph enabled", zap.String("store", "neo4j"))
+		return neo4j.NewStore(ctx, client, logger)
+	default:
+		return nil, fmt.Errorf("unknown graph store %q", cfg.GraphStore)
+	}
+}
+
+// newJobStore keeps ingestion jobs under JOBS_DIR, or in memory when it is
+// unset.
+func newJobStore(cfg *config.Config) (documents.JobStore, error) {
+	if cfg.JobsDir == "" {
+		return documents.NewInMemoryJobStore(), nil
+	}
+	return documents.NewFileJobStore(cfg.JobsDir)
+}
+
+// newChunking reads the chunking settings, checking them up front so a
+// mistake doesn't only show when the first document fails to ingest.
+func newChunking(cfg *config.Config) (chunking.Config, map[string]chunking.Strategy, error) {
+	chunkingCfg := chunking.Config{
+		MaxTokens:  cfg.ChunkTokens,
+		Overlap:    cfg.ChunkOverlap,
+		Percentile: cfg.ChunkPercentile,
+	}
+	if cfg.ChunkStrategy != "" {
+		strategy, err := chunking.ParseStrategy(cfg.ChunkStrategy)
+		if err != nil {
+			return chunking.Config{}, nil, err
+		}
+		chunkingCfg.Strategy = strategy
+	}
+	if err := chunkingCfg.Validate(); err != nil {
+		return chunking.Config{}, nil, err
+	}
+	strategies, err := chunking.ParseCollections(cfg.ChunkCollections)
+	if err != nil {
+		return chunking.Config{}, nil, err
+	}
+	return chunkingCfg, strategies, nil
+}
+
+// newReranker creates the reranker selected by RERANKER, or nil when
+// reranking is disabled.
+func newReranker(cfg *config.Config, provider ai.ChatProvider, registry *prompts.Registry, logger *zap.Logger) (retrieval.Reranker, error) {
+	switch cfg.Reranker {
+	case "", "none":
+		return nil, nil
+	case "llm":
+		logger.Info("Reranking enabled", zap.String("reranker", "llm"))
+		return retrieval.NewLLMReranker(provider, cfg.RerankModel, registry), nil
+	case retrieval.FormatTEI, retrieval.FormatCohere:
+		logger.Info("Reranking enabled", zap.String("reranker", cfg.Reranker), zap.String("url", cfg.RerankURL))
+		return retrieval.NewCrossEncoderReranker(retrieval.CrossEncoderConfig{
+			URL:    cfg.RerankURL,
+			Format: cfg.Reranker,
+			Model:  cfg.RerankModel,
+			APIKey: cfg.RerankAPIKey,
+		}, logger)
+	default:
+		return nil, fmt.Errorf("unknown reranker %q", cfg.Reranker)
+	}
+}
+
+// newParentStore keeps parent sections under PARENTS_DIR, or in memory when
+// it is unset.
+func newParentStore(cfg *config.Config) (retrieval.ParentStore, error) {
+	if cfg.ParentsDir == "" {
+		return retrieval.NewInMemoryParentStore(), nil
+	}
+	return retrieval.NewFileParentStore(cfg.ParentsDir)
+}
+
+// newSiteStore keeps crawled sites under SITES_DIR, or in memory when it is
+// unset.
+func newSiteStore(cfg *config.Config) (sites.Store, error) {
+	if cfg.SitesDir == "" {
+		return sites.NewInMemoryStore(), nil
+	}
+	return sites.NewFileStore(cfg.SitesDir)
+}
+
+// watchDirs returns the directories listed in WATCH_DIRS.
+func watchDirs(cfg *config.Config) []string {
+	var dirs []string
+	for _, dir := range strings.Split(cfg.WatchDirs, ",") {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// newLimiter returns a limiter for cfg, or nil when no limit is configured.
+func newLimiter(provider ai.ProviderType, cfg limiter.Config, logger *zap.Logger) *limiter.Limiter {
+	if !cfg.IsEnabled() {
+		return nil
+	}
+	logger.Info("Client-side rate limiting enabled",
+		zap.String("provider", string(provider)),
+		zap.Int("rpm", cfg.RequestsPerMinute),
+		zap.Int("tpm", cfg.TokensPerMinute),
+		zap.Float64("interactive_reserve", cfg.InteractiveReserve))
+	return limiter.New(cfg)
+}
+
+// newCache creates a response cache, persisted under AI_CACHE_DIR/name when a
+// cache directory is configured.
+func newCache(cfg *config.Config, name string) (*cache.Cache, error) {
+	dir := ""
+	if cfg.AICacheDir != "" {
+		dir = filepath.Join(cfg.AICacheDir, name)
+	}
+	return cache.New(cache.Config{
+		MaxEntries: cfg.AICacheMaxEntries,
+		TTL:        cfg.AICacheTTL,
+		Dir:        dir,
+	})
+}
+
+// EnsureModels pulls every model listed in ENSURE_MODELS that its provider
+// doesn't have yet, giving up after ENSURE_MODELS_TIMEOUT (30 minutes when
+// unset). Entries are "model" (routed by MODEL_ROUTES) or "provider=model".
+// It is meant to run in the background while the server is up; progress is
+// served by GET /api/admin/models/ensure. Failures are returned together once
+// every entry has been tried.
+func EnsureModels(ctx context.Context, cfg *config.Config, services *Services, logger *zap.Logger) error {
+	var entries []string
+	for _, item := range strings.Split(cfg.EnsureModels, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			entries = append(entries, item)
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	timeout := cfg.EnsureModelsTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	lastStatus := make(map[string]string, len(entries))
+	return services.ModelService.Prepare(ctx, entries, func(p models.Preparation) {
+		if lastStatus[p.Provider+"="+p.Model] == p.Status {
+			return
+		}
+		lastStatus[p.Provider+"="+p.Model] = p.Status
+		logger.Info("Preparing model",
+			zap.String("provider", p.Provider),
+			zap.String("model", p.Model),
+			zap.String("status", p.Status))
+	})
 }
@@ -10,8 +10,16 @@ import (
 	"time"
 
 	"github.com/Joepolymath/DaVinci/apps/scribequery/app"
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/cli"
 	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/handlers"
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/handlers/admin"
 	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/handlers/chat"
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/handlers/documents"
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/handlers/memories"
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/handlers/models"
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/handlers/prompts"
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/handlers/search"
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/handlers/sites"
 	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/router"
 	sharedgo "github.com/Joepolymath/DaVinci/libs/shared-go"
 	"github.com/Joepolymath/DaVinci/libs/shared-go/config"
@@ -20,6 +28,10 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	log.Println("Starting ScribeQuery backend")
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -69,12 +81,43 @@ func main() {
 		return
 	}
 
+	go func() {
+		if err := app.EnsureModels(context.Background(), cfg, services, logger); err != nil {
+			logger.Warn("Some models could not be prepared", zap.Error(err))
+		}
+	}()
+
+	if err := services.DocumentService.Start(context.Background()); err != nil {
+		logger.Error("Failed to start ingestion workers", zap.Error(err))
+		return
+	}
+
+	if err := services.SiteService.Start(context.Background()); err != nil {
+		logger.Error("Failed to start site crawler", zap.Error(err))
+		return
+	}
+
+	if cfg.WatchDirs != "" {
+		go func() {
+			if err := services.Watcher.Run(context.Background()); err != nil {
+				logger.Error("Stopped watching directories", zap.Error(err))
+			}
+		}()
+	}
+
 	appEnv := router.InitRouterWithConfig(cfg)
 
 	env := handlers.NewEnvironment(cfg, appEnv, logger, services)
 
 	if err := router.InitHandlers(env, []handlers.IHandler{
 		&chat.Handler{},
+		&memories.Handler{},
+		&models.Handler{},
+		&prompts.Handler{},
+		&search.Handler{},
+		&documents.Handler{},
+		&sites.Handler{},
+		&admin.Handler{},
 	}); err != nil {
 		logger.Error("Failed to initialize handlers", zap.Error(err))
 		return
@@ -88,3 +131,34 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 }
+
+// runCommand runs a CLI subcommand on the configured services, without the
+// HTTP server, and returns the exit code. Only warnings and errors are
+// logged, so output stays readable.
+func runCommand(args []string) int {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Printf("Failed to load config: %v", err)
+		return 1
+	}
+	cfg.VectorReadOnly = cli.ReadOnly(args)
+
+	logConfig := zap.NewProductionConfig()
+	logConfig.Level = zap.NewAtomicLevelAt(zap.WarnLevel)
+	logger, err := logConfig.Build()
+	if err != nil {
+		log.Printf("Failed to create logger: %v", err)
+		return 1
+	}
+	defer logger.Sync()
+
+	services := app.InitServices(cfg, logger)
+	if services == nil {
+		logger.Error("Failed to initialize services")
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	return cli.Run(ctx, cfg, services, args, os.Stdout, os.Stderr)
+}
@@ -0,0 +1,189 @@
+// Package cli runs ScribeQuery subcommands against the same services as the
+// HTTP server, for scripting bulk operations and debugging retrieval from a
+// terminal.
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Joepolymath/DaVinci/apps/scribequery/app"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/config"
+)
+
+// Exit codes.
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+const usage = `Usage: scribequery <command> [flags] [args]
+
+Without a command the HTTP server is started.
+
+Commands:
+  ingest <path...>                      index files, walking directories recursively
+  watch [dir...]                        keep indexing directories (default WATCH_DIRS) as files change
+  sites add|list|refresh|rm             crawl web pages and sites into the index
+  query "<question>"                    answer a question, citing the documentation
+  search "<query>"                      show the chunks retrieved for a query
+  collections list|create|delete        manage vector collections
+  docs list|rm                          list or remove indexed documents
+  users token <id>                      print the bearer token identifying a user
+
+Every command takes --json to print machine-readable output. Run
+"scribequery <command> -h" for its flags.
+
+Commands work on the stores configured for the server (VECTOR_DIR,
+GRAPH_STORE...). Only one process at a time may write to VECTOR_DIR, so
+commands that change the index refuse to run while the server is up; stop
+it first. Commands that only read it (query, search, collections list,
+docs list, sites list, users) run alongside the server, on the index as it
+was when they started.
+`
+
+// errUsage reports a command line mistake; the message has been printed.
+var errUsage = errors.New("usage")
+
+// command is a subcommand. It parses its own flags from args.
+type command func(ctx context.Context, env *env, args []string) error
+
+var commands = map[string]command{
+	"ingest":      ingest,
+	"watch":       watch,
+	"sites":       sites,
+	"query":       query,
+	"search":      search,
+	"collections": collections,
+	"docs":        docs,
+	"users":       users,
+}
+
+// readOnly lists the commands, and "command subcommand" pairs, that never
+// change the vector store.
+var readOnly = map[string]bool{
+	"query":            true,
+	"search":           true,
+	"collections list": true,
+	"docs list":        true,
+	"sites list":       true,
+	"users":            true,
+}
+
+// ReadOnly reports whether the command in args only reads the vector store,
+// so it can open VECTOR_DIR while another process writes to it.
+func ReadOnly(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	if readOnly[args[0]] {
+		return true
+	}
+	for _, arg := range args[1:] {
+		if !strings.HasPrefix(arg, "-") {
+			return readOnly[args[0]+" "+arg]
+		}
+	}
+	return false
+}
+
+// env is what commands run with.
+type env struct {
+	cfg      *config.Config
+	services *app.Services
+	stdout   io.Writer
+	stderr   io.Writer
+}
+
+// IsCommand reports whether name is a subcommand, as opposed to the server.
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+	return ok || name == "help" || name == "-h" || name == "--help"
+}
+
+// Run runs the subcommand named by args[0] and returns the process exit code.
+func Run(ctx context.Context, cfg *config.Config, services *app.Services, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+
+	err := cmd(ctx, &env{cfg: cfg, services: services, stdout: stdout, stderr: stderr}, args[1:])
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, errUsage):
+		return exitUsage
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	}
+	fmt.Fprintf(stderr, "error: %v\n", err)
+	return exitError
+}
+
+// newFlags returns the flag set of a command, printing its usage line and
+// flags on -h or a bad flag.
+func (e *env) newFlags(name, synopsis string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "Usage: scribequery %s %s\n", name, synopsis)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses flags wherever they appear among the arguments, so that
+// `query "why?" --json` works as well as `query --json "why?"`, and returns
+// the positional arguments.
+func parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, errUsage
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// usageError prints a mistake and the command's usage.
+func usageError(fs *flag.FlagSet, format string, args ...any) error {
+	fmt.Fprintf(fs.Output(), format+"\n", args...)
+	fs.Usage()
+	return errUsage
+}
+
+// printJSON writes v as indented JSON.
+func (e *env) printJSON(v any) error {
+	enc := json.NewEncoder(e.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// snippet shortens text to one line of at most n runes.
+func snippet(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if r := []rune(text); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return text
+}
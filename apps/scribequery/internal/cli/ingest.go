@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/domain/documents"
+	watcher "github.com/Joepolymath/DaVinci/apps/scribequery/internal/watch"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/loaders"
+)
+
+// ingestResult is the outcome for one file.
+type ingestResult struct {
+	Path     string              `json:"path"`
+	Document *documents.Document `json:"document,omitempty"`
+	Error    string              `json:"error,omitempty"`
+}
+
+// ingest indexes files and the files under directories. Files in directories
+// that no loader reads are skipped; a file named on the command line must be
+// readable.
+func ingest(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlags("ingest", "[--collection name] [--json] <path...>")
+	collection := fs.String("collection", "", "collection to index into (default: VECTOR_COLLECTION)")
+	asJSON := fs.Bool("json", false, "print the results as JSON")
+	paths, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return usageError(fs, "no path to ingest")
+	}
+
+	files, err := ingestFiles(e, paths)
+	if err != nil {
+		return err
+	}
+
+	results := make([]ingestResult, 0, len(files))
+	failed := 0
+	for _, file := range files {
+		result := ingestResult{Path: file.Path}
+		result.Document, err = ingestFile(ctx, e, file, *collection)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			result.Error = err.Error()
+			failed++
+		}
+		results = append(results, result)
+		if !*asJSON {
+			printIngestResult(e, result)
+		}
+	}
+
+	if *asJSON {
+		if err := e.printJSON(results); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed", failed, len(files))
+	}
+	return nil
+}
+
+// ingestPath is a file to ingest and the source it is indexed under: its
+// path relative to the parent of the argument it was found under, as the
+// watcher names files, so same-named files in different directories stay
+// apart.
+type ingestPath struct {
+	Path   string
+	Source string
+}
+
+// ingestFiles expands the paths into the files to ingest.
+func ingestFiles(e *env, paths []string) ([]ingestPath, error) {
+	var files []ingestPath
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		root, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, ingestPath{Path: path, Source: filepath.Base(root)})
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if file != path && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() || strings.HasPrefix(d.Name(), ".") {
+				return nil
+			}
+			if _, _, err := e.services.Loaders.Select(loaders.Source{Name: d.Name()}, nil); err == nil {
+				rel, err := filepath.Rel(path, file)
+				if err != nil {
+					return err
+				}
+				source := filepath.Join(filepath.Base(root), rel)
+				files = append(files, ingestPath{Path: file, Source: filepath.ToSlash(source)})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func ingestFile(ctx context.Context, e *env, file ingestPath, collection string) (*documents.Document, error) {
+	info, err := os.Stat(file.Path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > documents.MaxUploadBytes {
+		return nil, documents.ErrFileTooLarge
+	}
+	data, err := os.ReadFile(file.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := e.services.DocumentService.Ingest(ctx, &documents.Upload{
+		Name:       file.Source,
+		Source:     file.Source,
+		Data:       data,
+		Collection: collection,
+	})
+	if errors.Is(err, loaders.ErrUnsupportedFormat) {
+		return nil, fmt.Errorf("%w (supported: %s)", err, strings.Join(e.services.Loaders.Formats(), ", "))
+	}
+	return doc, err
+}
+
+func printIngestResult(e *env, result ingestResult) {
+	if result.Error != "" {
+		fmt.Fprintf(e.stderr, "%s: %s\n", result.Path, result.Error)
+		return
+	}
+	doc := result.Document
+	if doc.Unchanged {
+		fmt.Fprintf(e.stdout, "%s: unchanged, %d chunks in %s\n", result.Path, doc.Chunks, doc.Collection)
+		return
+	}
+	fmt.Fprintf(e.stdout, "%s: %d chunks in %s", result.Path, doc.Chunks, doc.Collection)
+	if c := doc.Changes; c != nil && (c.Removed > 0 || c.Unchanged > 0) {
+		fmt.Fprintf(e.stdout, " (%d added, %d removed, %d unchanged)", c.Added, c.Removed, c.Unchanged)
+	}
+	fmt.Fprintf(e.stdout, " [%s]\n", doc.ID)
+}
+
+// watch ingests the files of directories as they appear, change and go,
+// until interrupted.
+func watch(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlags("watch", "[dir...]")
+	dirs, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	err = e.services.Watcher.Run(ctx, dirs...)
+	if errors.Is(err, watcher.ErrNoDirs) {
+		return usageError(fs, "no directory given and WATCH_DIRS is empty")
+	}
+	return err
+}
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/db/vector/local"
+)
+
+// collections manages vector collections.
+func collections(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlags("collections", "list | create <name> [--size n] [--distance d] | delete <name...> [--json]")
+	size := fs.Uint64("size", 0, "vector size of a new collection (default: that of the embedding model)")
+	distance := fs.String("distance", "", "Cosine, Euclid or Dot (default Cosine)")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	words, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(words) == 0 {
+		return usageError(fs, "no subcommand")
+	}
+	vectors := e.services.Vectors
+
+	switch sub, names := words[0], words[1:]; sub {
+	case "list":
+		resp, err := vectors.ListCollections(ctx)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return e.printJSON(resp)
+		}
+		tw := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tPOINTS\tSIZE\tDISTANCE")
+		for _, c := range resp.Collections {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%s\n", c.Name, c.Points, c.VectorSize, c.Distance)
+		}
+		return tw.Flush()
+
+	case "create":
+		if len(names) != 1 {
+			return usageError(fs, "create takes one collection name")
+		}
+		if *size == 0 {
+			if *size, err = embeddingSize(ctx, e); err != nil {
+				return err
+			}
+		}
+		req := &local.CreateCollectionRequest{CollectionName: names[0], VectorSize: *size, Distance: *distance}
+		if err := vectors.CreateCollection(ctx, req); err != nil {
+			return err
+		}
+		if *asJSON {
+			return e.printJSON(req)
+		}
+		fmt.Fprintf(e.stdout, "created %s (size %d)\n", req.CollectionName, req.VectorSize)
+		return nil
+
+	case "delete":
+		if len(names) == 0 {
+			return usageError(fs, "delete takes collection names")
+		}
+		for _, name := range names {
+			if err := vectors.DeleteCollection(ctx, name); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			if err := e.services.Parents.DeleteCollection(ctx, name); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			if !*asJSON {
+				fmt.Fprintf(e.stdout, "deleted %s\n", name)
+			}
+		}
+		if *asJSON {
+			return e.printJSON(map[string][]string{"deleted": names})
+		}
+		return nil
+	}
+	return usageError(fs, "unknown subcommand %q", words[0])
+}
+
+// embeddingSize returns the vector size of the configured embedding model.
+func embeddingSize(ctx context.Context, e *env) (uint64, error) {
+	if e.services.Embeddings == nil {
+		return 0, errors.New("--size is required without an embedding provider")
+	}
+	vector, err := e.services.Embeddings.CreateEmbedding(ctx, "size")
+	if err != nil {
+		return 0, fmt.Errorf("failed to find the embedding size: %w", err)
+	}
+	return uint64(len(vector)), nil
+}
+
+// docs lists and removes ingested documents.
+func docs(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlags("docs", "list | rm <id...> [--collection name] [--json]")
+	collection := fs.String("collection", "", "collection of the documents (default: VECTOR_COLLECTION)")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	words, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(words) == 0 {
+		return usageError(fs, "no subcommand")
+	}
+	service := e.services.DocumentService
+
+	switch sub, ids := words[0], words[1:]; sub {
+	case "list":
+		list, err := service.List(ctx, *collection)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return e.printJSON(list)
+		}
+		tw := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tCHUNKS\tFORMAT\tSOURCE\tTITLE")
+		for _, doc := range list {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", doc.ID, doc.Chunks, doc.Format, doc.Source, snippet(doc.Title, 60))
+		}
+		return tw.Flush()
+
+	case "rm":
+		if len(ids) == 0 {
+			return usageError(fs, "rm takes document IDs")
+		}
+		for _, id := range ids {
+			if err := service.Delete(ctx, *collection, id); err != nil {
+				return fmt.Errorf("%s: %w", id, err)
+			}
+			if !*asJSON {
+				fmt.Fprintf(e.stdout, "removed %s\n", id)
+			}
+		}
+		if *asJSON {
+			return e.printJSON(map[string][]string{"removed": ids})
+		}
+		return nil
+	}
+	return usageError(fs, "unknown subcommand %q", words[0])
+}
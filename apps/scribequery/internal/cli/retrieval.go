@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	searchdomain "github.com/Joepolymath/DaVinci/apps/scribequery/internal/domain/search"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/retrieval"
+)
+
+// query answers a question and lists the sources the answer cites.
+func query(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlags("query", `[--collection name] [--top-k n] [--mode m] [--model name] [--cache] [--json] "<question>"`)
+	collection := fs.String("collection", "", "collection to search (default: VECTOR_COLLECTION)")
+	topK := fs.Int("top-k", 0, "chunks given to the model (default 5)")
+	mode := fs.String("mode", "", "query transformation: standard, multi_query, hyde or step_back (default: QUERY_MODE)")
+	model := fs.String("model", "", "model answering (default: the configured default)")
+	cached := fs.Bool("cache", false, "reuse a cached answer to the same prompt, and cache this one")
+	asJSON := fs.Bool("json", false, "print the answer as JSON")
+	words, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(words) == 0 {
+		return usageError(fs, "no question")
+	}
+
+	answer, err := e.services.SearchService.Answer(ctx, &searchdomain.AnswerRequest{
+		Request: retrieval.Request{
+			Query:      strings.Join(words, " "),
+			Collection: *collection,
+			TopK:       *topK,
+			Mode:       *mode,
+		},
+		Model: *model,
+		Cache: *cached,
+	})
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return e.printJSON(answer)
+	}
+	printDebug(e, answer.Debug)
+
+	fmt.Fprintln(e.stdout, strings.TrimSpace(answer.Answer))
+	if len(answer.Citations) > 0 {
+		fmt.Fprintln(e.stdout, "\nSources:")
+		for _, c := range answer.Citations {
+			fmt.Fprintf(e.stdout, "  [%d] %s", c.Number, c.Label())
+			if c.Source != "" && c.Source != c.Title {
+				fmt.Fprintf(e.stdout, " (%s)", c.Source)
+			}
+			fmt.Fprintln(e.stdout)
+		}
+	}
+	return nil
+}
+
+// search prints the chunks retrieved for a query, best first.
+func search(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlags("search", `[--collection name] [--top-k n] [--mode m] [--full] [--json] "<query>"`)
+	collection := fs.String("collection", "", "collection to search (default: VECTOR_COLLECTION)")
+	topK := fs.Int("top-k", 0, "chunks returned by vector search (default 5)")
+	mode := fs.String("mode", "", "query transformation: standard, multi_query, hyde or step_back (default: QUERY_MODE)")
+	full := fs.Bool("full", false, "print whole chunks instead of one line each")
+	asJSON := fs.Bool("json", false, "print the chunks as JSON")
+	words, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(words) == 0 {
+		return usageError(fs, "no query")
+	}
+
+	response, err := e.services.SearchService.Search(ctx, &retrieval.Request{
+		Query:      strings.Join(words, " "),
+		Collection: *collection,
+		TopK:       *topK,
+		Mode:       *mode,
+	})
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return e.printJSON(response)
+	}
+	printDebug(e, response.Debug)
+
+	if len(response.Chunks) == 0 {
+		fmt.Fprintln(e.stdout, "No chunks found.")
+		return nil
+	}
+	for i, chunk := range response.Chunks {
+		via := chunk.Via
+		if chunk.Hops > 0 {
+			via = fmt.Sprintf("%s, %d hops", via, chunk.Hops)
+		}
+		if chunk.RerankScore != nil {
+			via = fmt.Sprintf("%s, reranked %.4f", via, *chunk.RerankScore)
+		}
+		label := searchdomain.NewCitation(i+1, chunk).Label()
+		fmt.Fprintf(e.stdout, "%2d. %.4f  %s  (%s)\n", i+1, chunk.Score, label, via)
+		if *full {
+			fmt.Fprintf(e.stdout, "%s\n\n", strings.TrimSpace(chunk.Text))
+		} else {
+			fmt.Fprintf(e.stdout, "    %s\n", snippet(chunk.Text, 160))
+		}
+	}
+	return nil
+}
+
+// printDebug tells how a query was transformed, unless it was searched as
+// asked.
+func printDebug(e *env, debug *searchdomain.Debug) {
+	if debug.Error != "" {
+		fmt.Fprintf(e.stderr, "query not transformed: %s\n", debug.Error)
+	}
+	if n := len(debug.DroppedSources); n > 0 {
+		fmt.Fprintf(e.stderr, "%d sources left out to fit the context window\n", n)
+	}
+	if debug.Mode == retrieval.ModeStandard {
+		return
+	}
+	fmt.Fprintf(e.stdout, "Mode: %s\n", debug.Mode)
+	for _, q := range debug.Queries[1:] {
+		fmt.Fprintf(e.stdout, "  also searched: %s\n", q)
+	}
+	if debug.Hypothetical != "" {
+		fmt.Fprintf(e.stdout, "  embedded: %s\n", snippet(debug.Hypothetical, 160))
+	}
+	fmt.Fprintln(e.stdout)
+}
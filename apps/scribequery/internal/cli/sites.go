@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	sitesdomain "github.com/Joepolymath/DaVinci/apps/scribequery/internal/domain/sites"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/crawler"
+)
+
+// sites adds, crawls and removes web pages and sites. Crawls run in the
+// foreground.
+func sites(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlags("sites", "add <url> [--mode m] [--depth n] [--max-pages n] [--refresh d] [--collection name] | list | refresh <id...> | rm <id...> [--json]")
+	mode := fs.String("mode", "page", "page, links (same-host links up to --depth) or sitemap")
+	depth := fs.Int("depth", 0, "links followed from the URL in links mode (default 2)")
+	maxPages := fs.Int("max-pages", 0, "pages fetched per crawl (default 100)")
+	refresh := fs.String("refresh", "", "time between crawls by the server, e.g. 24h (default: none)")
+	collection := fs.String("collection", "", "collection to index into (default: VECTOR_COLLECTION)")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	words, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(words) == 0 {
+		return usageError(fs, "no subcommand")
+	}
+	service := e.services.SiteService
+
+	switch sub, rest := words[0], words[1:]; sub {
+	case "add":
+		if len(rest) != 1 {
+			return usageError(fs, "add takes one URL")
+		}
+		site, err := service.Add(ctx, &sitesdomain.AddRequest{
+			URL:        rest[0],
+			Mode:       crawler.Mode(*mode),
+			MaxDepth:   *depth,
+			MaxPages:   *maxPages,
+			Collection: *collection,
+			Refresh:    *refresh,
+		})
+		if err != nil {
+			return err
+		}
+		return crawlSites(ctx, e, []string{site.ID}, *asJSON)
+
+	case "refresh":
+		if len(rest) == 0 {
+			return usageError(fs, "refresh takes site IDs")
+		}
+		return crawlSites(ctx, e, rest, *asJSON)
+
+	case "list":
+		list, err := service.List(ctx)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return e.printJSON(list)
+		}
+		tw := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tMODE\tSTATUS\tREFRESH\tURL")
+		for _, site := range list {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", site.ID, site.Mode, site.Status, site.Refresh, site.URL)
+		}
+		return tw.Flush()
+
+	case "rm":
+		if len(rest) == 0 {
+			return usageError(fs, "rm takes site IDs")
+		}
+		for _, id := range rest {
+			if err := service.Delete(ctx, id); err != nil {
+				return fmt.Errorf("%s: %w", id, err)
+			}
+			if !*asJSON {
+				fmt.Fprintf(e.stdout, "removed %s\n", id)
+			}
+		}
+		if *asJSON {
+			return e.printJSON(map[string][]string{"removed": rest})
+		}
+		return nil
+	}
+	return usageError(fs, "unknown subcommand %q", words[0])
+}
+
+// crawlSites crawls sites one after the other and prints how each went.
+func crawlSites(ctx context.Context, e *env, ids []string, asJSON bool) error {
+	var crawled []*sitesdomain.Site
+	for _, id := range ids {
+		site, err := e.services.SiteService.Crawl(ctx, id)
+		if err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		crawled = append(crawled, site)
+		if asJSON {
+			continue
+		}
+		s := site.LastCrawl
+		fmt.Fprintf(e.stdout, "%s: %d fetched, %d ingested, %d unchanged, %d removed, %d skipped, %d failed [%s]\n",
+			site.URL, s.Fetched, s.Ingested, s.Unchanged, s.Removed, s.Skipped, s.Failed, site.ID)
+		for _, page := range site.Pages {
+			if page.Error != "" {
+				fmt.Fprintf(e.stderr, "  %s: %s\n", page.URL, page.Error)
+			}
+		}
+	}
+
+	if asJSON {
+		return e.printJSON(crawled)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/auth"
+	"github.com/Joepolymath/DaVinci/memory"
+)
+
+// users mints the bearer tokens that identify users to the HTTP API.
+func users(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlags("users", "token <id> [--json]")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	words, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(words) != 2 || words[0] != "token" {
+		return usageError(fs, "expected token <id>")
+	}
+
+	userID := words[1]
+	if err := memory.ValidateUserID(userID); err != nil {
+		return err
+	}
+	if e.cfg.UserTokenSecret == "" {
+		return errors.New("USER_TOKEN_SECRET is not set")
+	}
+	token := auth.Sign(e.cfg.UserTokenSecret, userID)
+	if *asJSON {
+		return e.printJSON(map[string]string{"user_id": userID, "token": token})
+	}
+	fmt.Fprintln(e.stdout, token)
+	return nil
+}
@@ -1 +1,15 @@
 package chat
+
+import "errors"
+
+var (
+	ErrNoMessages   = errors.New("at least one message is required")
+	ErrEmptyMessage = errors.New("message content is required")
+
+	ErrTooManyImages    = errors.New("too many images attached")
+	ErrImageTooLarge    = errors.New("image is too large")
+	ErrUnsupportedImage = errors.New("unsupported image type")
+
+	ErrMemoryDisabled = errors.New("conversation memory is disabled")
+	ErrFactsDisabled  = errors.New("long-term memory is disabled")
+)
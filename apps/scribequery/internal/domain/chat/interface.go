@@ -4,9 +4,28 @@ import (
 	"context"
 
 	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"github.com/Joepolymath/DaVinci/memory"
 )
 
 type Service interface {
-	Chat(ctx context.Context, messages []ai.Message) (ai.ChatResponse, error)
-	ChatStream(ctx context.Context, messages []ai.Message, onDelta func(delta ai.ChatStreamDelta) error) error
+	// Chat answers messages. With a conversation ID the remembered history
+	// is replayed first and the new turn is remembered; with a user ID the
+	// user's relevant facts are recalled and new ones learned from the turn.
+	Chat(ctx context.Context, session Session, messages []ai.Message, opts *ai.ChatOptions) (ai.ChatResponse, error)
+	ChatStream(ctx context.Context, session Session, messages []ai.Message, opts *ai.ChatOptions, onDelta func(delta ai.ChatStreamDelta) error) error
+
+	// History returns what is remembered of the session's conversation. A
+	// conversation started with a user ID is only returned to that user or
+	// the operator; anyone else gets memory.ErrNotOwner.
+	History(ctx context.Context, session Session) (*memory.Conversation, error)
+	// Forget deletes the session's conversation from memory, with the same
+	// ownership check as History.
+	Forget(ctx context.Context, session Session) error
+
+	// Facts lists what is remembered about a user across conversations.
+	Facts(ctx context.Context, userID string) ([]memory.Fact, error)
+	// ForgetFact deletes one remembered fact about a user.
+	ForgetFact(ctx context.Context, userID, factID string) error
+	// ForgetFacts deletes everything remembered about a user.
+	ForgetFacts(ctx context.Context, userID string) error
 }
@@ -1 +1,82 @@
 package chat
+
+import (
+	"encoding/json"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"github.com/Joepolymath/DaVinci/memory"
+)
+
+// Image attachment limits, matching what OpenAI accepts per request.
+const (
+	MaxImages     = 10
+	MaxImageBytes = 20 << 20
+)
+
+// ImageTypes are the image MIME types accepted as attachments.
+var ImageTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// Session identifies the conversation a turn belongs to and the user sending
+// it. Both are optional.
+type Session struct {
+	ConversationID string
+	UserID         string
+	Admin          bool // The operator, who may read and continue any conversation
+}
+
+// owner is who the session acts as in conversation memory: the user, or
+// anyone for the operator.
+func (s Session) owner() string {
+	if s.UserID == "" && s.Admin {
+		return memory.AnyOwner
+	}
+	return s.UserID
+}
+
+// Request is a chat turn sent by a client. It stays wire-compatible with a
+// bare ai.Message body; the extra fields are optional.
+type Request struct {
+	ai.Message
+	Model          string   `json:"model,omitempty"`           // Routed to the matching provider; empty uses the default
+	ConversationID string   `json:"conversation_id,omitempty"` // Continues a remembered conversation
+	UserID         string   `json:"user_id,omitempty"`         // Recalls and learns facts about this user
+	Temperature    *float64 `json:"temperature,omitempty"`     // nil uses the provider default; 0 makes the reply cacheable
+	Cache          bool     `json:"cache,omitempty"`           // Caches the reply even at a non-zero temperature
+}
+
+// UnmarshalJSON decodes the message and the request fields separately, since
+// ai.Message's own UnmarshalJSON would otherwise be promoted and drop them.
+func (r *Request) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &r.Message); err != nil {
+		return err
+	}
+	var fields struct {
+		Model          string   `json:"model"`
+		ConversationID string   `json:"conversation_id"`
+		UserID         string   `json:"user_id"`
+		Temperature    *float64 `json:"temperature"`
+		Cache          bool     `json:"cache"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	r.Model = fields.Model
+	r.ConversationID = fields.ConversationID
+	r.UserID = fields.UserID
+	r.Temperature = fields.Temperature
+	r.Cache = fields.Cache
+	return nil
+}
+
+// Session returns who the request is from and which conversation it continues.
+func (r *Request) Session() Session {
+	return Session{ConversationID: r.ConversationID, UserID: r.UserID}
+}
+
+// Options returns the provider options requested by the client, or nil.
+func (r *Request) Options() *ai.ChatOptions {
+	if r.Model == "" && r.Temperature == nil && !r.Cache {
+		return nil
+	}
+	return &ai.ChatOptions{Model: r.Model, Temperature: r.Temperature, Cache: r.Cache}
+}
@@ -2,28 +2,300 @@ package chat
 
 import (
 	"context"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai/limiter"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai/window"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/prompts"
+	"github.com/Joepolymath/DaVinci/memory"
+	"go.uber.org/zap"
 )
 
+// learnTimeout bounds the background fact extraction after a turn.
+const learnTimeout = 2 * time.Minute
+
 type service struct {
 	aiProvider ai.ChatProvider
+	window     *window.Builder
+	prompts    *prompts.Registry
+	memory     *memory.Memory
+	facts      *memory.Facts
+	logger     *zap.Logger
 }
 
-func NewService(aiProvider ai.ChatProvider) Service {
+// NewService creates the chat service. mem and facts may be nil to disable
+// conversation memory and long-term memory of user facts respectively.
+// Recalled facts are budgeted by builder like retrieved chunks.
+func NewService(aiProvider ai.ChatProvider, builder *window.Builder, prompts *prompts.Registry, mem *memory.Memory, facts *memory.Facts, logger *zap.Logger) Service {
 	return &service{
 		aiProvider: aiProvider,
+		window:     builder,
+		prompts:    prompts,
+		memory:     mem,
+		facts:      facts,
+		logger:     logger,
 	}
 }
 
-func (s *service) Chat(ctx context.Context, messages []ai.Message) (ai.ChatResponse, error) {
-	resp, err := s.aiProvider.Completion(ctx, messages, nil)
+func (s *service) Chat(ctx context.Context, session Session, messages []ai.Message, opts *ai.ChatOptions) (ai.ChatResponse, error) {
+	if err := ValidateMessages(messages); err != nil {
+		return ai.ChatResponse{}, err
+	}
+
+	prompt, ref, err := s.buildPrompt(ctx, session, messages, opts)
 	if err != nil {
 		return ai.ChatResponse{}, err
 	}
+
+	resp, err := s.aiProvider.Completion(ctx, prompt, opts)
+	if err != nil {
+		return ai.ChatResponse{}, err
+	}
+	resp.Prompt = ref
+
+	s.remember(ctx, session, messages, resp.Content)
 	return *resp, nil
 }
 
-func (s *service) ChatStream(ctx context.Context, messages []ai.Message, onDelta func(delta ai.ChatStreamDelta) error) error {
-	return s.aiProvider.CompletionStream(ctx, messages, nil, onDelta)
+func (s *service) ChatStream(ctx context.Context, session Session, messages []ai.Message, opts *ai.ChatOptions, onDelta func(delta ai.ChatStreamDelta) error) error {
+	if err := ValidateMessages(messages); err != nil {
+		return err
+	}
+
+	prompt, ref, err := s.buildPrompt(ctx, session, messages, opts)
+	if err != nil {
+		return err
+	}
+
+	var answer strings.Builder
+	err = s.aiProvider.CompletionStream(ctx, prompt, opts, func(delta ai.ChatStreamDelta) error {
+		answer.WriteString(delta.Content)
+		if delta.Done {
+			delta.Prompt = ref
+		}
+		return onDelta(delta)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.remember(ctx, session, messages, answer.String())
+	return nil
+}
+
+func (s *service) History(ctx context.Context, session Session) (*memory.Conversation, error) {
+	if s.memory == nil {
+		return nil, ErrMemoryDisabled
+	}
+	return s.memory.Get(ctx, session.ConversationID, session.owner())
+}
+
+func (s *service) Forget(ctx context.Context, session Session) error {
+	if s.memory == nil {
+		return ErrMemoryDisabled
+	}
+	return s.memory.Clear(ctx, session.ConversationID, session.owner())
+}
+
+func (s *service) Facts(ctx context.Context, userID string) ([]memory.Fact, error) {
+	if s.facts == nil {
+		return nil, ErrFactsDisabled
+	}
+	return s.facts.List(ctx, userID)
+}
+
+func (s *service) ForgetFact(ctx context.Context, userID, factID string) error {
+	if s.facts == nil {
+		return ErrFactsDisabled
+	}
+	return s.facts.Forget(ctx, userID, factID)
+}
+
+func (s *service) ForgetFacts(ctx context.Context, userID string) error {
+	if s.facts == nil {
+		return ErrFactsDisabled
+	}
+	return s.facts.ForgetAll(ctx, userID)
+}
+
+// buildPrompt prepends the system prompt, with the user's recalled facts, and
+// the remembered history of the conversation, if any, to messages. The
+// history goes after the system prompt so a remembered summary doesn't stand
+// in for it.
+func (s *service) buildPrompt(ctx context.Context, session Session, messages []ai.Message, opts *ai.ChatOptions) ([]ai.Message, *ai.PromptRef, error) {
+	var history []ai.Message
+	if session.ConversationID != "" && s.memory != nil {
+		var err error
+		if history, err = s.memory.History(ctx, session.ConversationID, session.owner()); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	prompt, ref, err := s.withSystemPrompt(messages, s.recall(ctx, session, history, messages, opts))
+	if err != nil || len(history) == 0 {
+		return prompt, ref, err
+	}
+	if prompt[0].Role != ai.RoleSystem {
+		return append(history, prompt...), ref, nil
+	}
+	out := make([]ai.Message, 0, len(history)+len(prompt))
+	out = append(out, prompt[0])
+	out = append(out, history...)
+	return append(out, prompt[1:]...), ref, nil
+}
+
+// recall returns the user's facts relevant to the latest message, the most
+// relevant first, keeping those that fit in the context window beside the
+// system prompt, the history and messages. Recall is best effort: on failure
+// the turn goes ahead without them.
+func (s *service) recall(ctx context.Context, session Session, history, messages []ai.Message, opts *ai.ChatOptions) []string {
+	if session.UserID == "" || s.facts == nil || messages[0].Role == ai.RoleSystem {
+		return nil
+	}
+	query := messages[len(messages)-1].Text()
+	if strings.TrimSpace(query) == "" {
+		return nil
+	}
+
+	facts, err := s.facts.Recall(ctx, session.UserID, query)
+	if err != nil {
+		s.logger.Warn("Failed to recall user facts", zap.String("user_id", session.UserID), zap.Error(err))
+		return nil
+	}
+	if len(facts) == 0 {
+		return nil
+	}
+
+	chunks := make([]window.Chunk, len(facts))
+	for i, f := range facts {
+		chunks[i] = window.Chunk{ID: f.ID, Text: f.Text, Score: float64(f.Score)}
+	}
+	system, _, err := s.renderSystem(nil)
+	if err != nil {
+		return nil
+	}
+	model, maxOutput := s.aiProvider.GetModel(), 0
+	if opts != nil {
+		if opts.Model != "" {
+			model = opts.Model
+		}
+		maxOutput = opts.MaxTokens
+	}
+	kept, report, err := s.window.FitChunks(model, window.Input{
+		System:  system,
+		Chunks:  chunks,
+		History: append(slices.Clone(history), messages[:len(messages)-1]...),
+		Current: messages[len(messages)-1],
+	}, maxOutput)
+	if err != nil {
+		// The request fails the same way once it is sent.
+		return nil
+	}
+	if len(report.DroppedChunks) > 0 {
+		s.logger.Debug("Dropped recalled facts to fit the context window",
+			zap.String("user_id", session.UserID),
+			zap.Int("dropped", len(report.DroppedChunks)))
+	}
+
+	texts := make([]string, len(kept))
+	for i, c := range kept {
+		texts[i] = c.Text
+	}
+	return texts
+}
+
+// remember records the turn in the conversation memory and, in the
+// background, learns facts about the user from it. Failing to remember
+// doesn't fail the request that was already answered.
+func (s *service) remember(ctx context.Context, session Session, messages []ai.Message, answer string) {
+	turn := append(slices.Clone(messages), ai.Message{Role: ai.RoleAssistant, Content: answer})
+
+	if session.ConversationID != "" && s.memory != nil {
+		if err := s.memory.Append(ctx, session.ConversationID, session.owner(), turn...); err != nil {
+			s.logger.Warn("Failed to update conversation memory",
+				zap.String("conversation_id", session.ConversationID),
+				zap.Error(err))
+		}
+	}
+
+	if session.UserID != "" && s.facts != nil {
+		go s.learn(session, turn)
+	}
+}
+
+func (s *service) learn(session Session, turn []ai.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), learnTimeout)
+	defer cancel()
+	ctx = limiter.WithPriority(ctx, limiter.PriorityBackground)
+
+	learned, err := s.facts.Learn(ctx, session.UserID, session.ConversationID, turn)
+	if err != nil {
+		s.logger.Warn("Failed to learn user facts", zap.String("user_id", session.UserID), zap.Error(err))
+		return
+	}
+	if len(learned) > 0 {
+		s.logger.Debug("Learned user facts", zap.String("user_id", session.UserID), zap.Int("count", len(learned)))
+	}
+}
+
+// withSystemPrompt prepends the chat system prompt unless the conversation
+// already starts with a system message.
+func (s *service) withSystemPrompt(messages []ai.Message, facts []string) ([]ai.Message, *ai.PromptRef, error) {
+	if messages[0].Role == ai.RoleSystem {
+		return messages, nil, nil
+	}
+
+	system, ref, err := s.renderSystem(facts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append([]ai.Message{{Role: ai.RoleSystem, Content: system}}, messages...), ref, nil
+}
+
+// renderSystem renders the chat system prompt with the user's facts.
+func (s *service) renderSystem(facts []string) (string, *ai.PromptRef, error) {
+	return s.prompts.Render(prompts.ChatSystem, map[string]any{
+		"today": time.Now().Format("Monday, 2 January 2006"),
+		"facts": facts,
+	})
+}
+
+// ValidateMessages checks that a conversation can be sent to the model.
+func ValidateMessages(messages []ai.Message) error {
+	if len(messages) == 0 {
+		return ErrNoMessages
+	}
+	for _, m := range messages {
+		images := m.Images()
+		if strings.TrimSpace(m.Text()) == "" && len(images) == 0 {
+			return ErrEmptyMessage
+		}
+		if len(images) > MaxImages {
+			return ErrTooManyImages
+		}
+		for _, image := range images {
+			if err := ValidateImage(image); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateImage checks an image part against the formats and size the
+// providers accept.
+func ValidateImage(image ai.ContentPart) error {
+	if image.Type != ai.PartImage {
+		return nil
+	}
+	if len(image.Data) > MaxImageBytes {
+		return ErrImageTooLarge
+	}
+	if !slices.Contains(ImageTypes, image.MIMEType) {
+		return ErrUnsupportedImage
+	}
+	return nil
 }
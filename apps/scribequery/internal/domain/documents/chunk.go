@@ -0,0 +1,66 @@
+package documents
+
+import (
+	"context"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/chunking"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/loaders"
+)
+
+// chunk is a piece of a section small enough to embed. Section indexes the
+// sections of the loaded document; Start and End are byte offsets of the
+// text in the whole document's text, as loaders.Document.Text joins it, and
+// ParentStart and ParentEnd those of the parent section it was cut from, if
+// any.
+type chunk struct {
+	Text        string `json:"text"`
+	Section     int    `json:"section"`
+	Start       int    `json:"start"`
+	End         int    `json:"end"`
+	ParentStart int    `json:"parent_start,omitempty"`
+	ParentEnd   int    `json:"parent_end,omitempty"`
+}
+
+// splitDocument splits every section of doc with chunker, never mixing
+// sections so each chunk keeps its heading and page. With parents, sections
+// are first split into parents, which are then split into chunks.
+func splitDocument(ctx context.Context, doc *loaders.Document, chunker, parents chunking.Chunker) ([]chunk, error) {
+	var chunks []chunk
+	offset := 0
+	for i := range doc.Sections {
+		text := doc.Sections[i].Text
+		if parents == nil {
+			pieces, err := chunker.Split(ctx, text)
+			if err != nil {
+				return nil, err
+			}
+			for _, p := range pieces {
+				chunks = append(chunks, chunk{Text: p.Text, Section: i, Start: offset + p.Start, End: offset + p.End})
+			}
+		} else {
+			outer, err := parents.Split(ctx, text)
+			if err != nil {
+				return nil, err
+			}
+			for _, parent := range outer {
+				pieces, err := chunker.Split(ctx, parent.Text)
+				if err != nil {
+					return nil, err
+				}
+				start := offset + parent.Start
+				for _, p := range pieces {
+					chunks = append(chunks, chunk{
+						Text:        p.Text,
+						Section:     i,
+						Start:       start + p.Start,
+						End:         start + p.End,
+						ParentStart: start,
+						ParentEnd:   offset + parent.End,
+					})
+				}
+			}
+		}
+		offset += len(text) + len("\n\n")
+	}
+	return chunks, nil
+}
@@ -0,0 +1,12 @@
+package documents
+
+import "errors"
+
+var (
+	ErrNoFile           = errors.New("a file is required")
+	ErrFileTooLarge     = errors.New("file is too large")
+	ErrUnreadableFile   = errors.New("file could not be read")
+	ErrJobNotFound      = errors.New("ingestion job not found")
+	ErrDocumentNotFound = errors.New("document not found")
+	ErrQueueFull        = errors.New("too many documents waiting to be ingested")
+)
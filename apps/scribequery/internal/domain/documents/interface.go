@@ -0,0 +1,49 @@
+package documents
+
+import "context"
+
+type Service interface {
+	// Ingest loads an uploaded file with the loader for its format, splits it
+	// into chunks and indexes them for retrieval, returning once it is done.
+	Ingest(ctx context.Context, upload *Upload) (*Document, error)
+
+	// List returns the documents indexed in a collection, as described by
+	// their chunks. An empty collection is the default one.
+	List(ctx context.Context, collection string) ([]*Document, error)
+
+	// Delete removes a document's chunks from a collection.
+	Delete(ctx context.Context, collection, id string) error
+
+	// Submit checks an upload and queues it for ingestion by a worker,
+	// returning the job tracking it.
+	Submit(ctx context.Context, upload *Upload) (*Job, error)
+
+	// Job returns the current state of a job.
+	Job(ctx context.Context, id string) (*Job, error)
+
+	// Watch returns the state of a job now and after every change. The
+	// channel only holds the latest state, so a slow reader skips
+	// intermediate ones; it is closed once the job is done or ctx ends.
+	Watch(ctx context.Context, id string) (<-chan Job, error)
+
+	// Start runs the workers until ctx ends, first queueing the jobs left
+	// unfinished by a previous run.
+	Start(ctx context.Context) error
+}
+
+// JobStore persists jobs along with their upload and the output of each
+// completed stage.
+type JobStore interface {
+	SaveJob(ctx context.Context, job *Job) error
+	// LoadJob returns ErrJobNotFound for an unknown job.
+	LoadJob(ctx context.Context, id string) (*Job, error)
+	ListJobs(ctx context.Context) ([]*Job, error)
+	DeleteJob(ctx context.Context, id string) error
+
+	// SaveArtifact and LoadArtifact keep data attached to a job by name;
+	// LoadArtifact returns ErrJobNotFound when there is none.
+	SaveArtifact(ctx context.Context, id, name string, data []byte) error
+	LoadArtifact(ctx context.Context, id, name string) ([]byte, error)
+	// DeleteArtifacts drops a job's data, keeping the job itself.
+	DeleteArtifacts(ctx context.Context, id string) error
+}
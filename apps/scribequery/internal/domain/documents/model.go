@@ -0,0 +1,164 @@
+package documents
+
+import (
+	"time"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/chunking"
+)
+
+// MaxUploadBytes caps the size of one uploaded file.
+const MaxUploadBytes = 30 << 20
+
+// Payload keys of indexed chunks, next to retrieval.PayloadText.
+const (
+	PayloadDocumentID = "document_id"
+	PayloadTitle      = "title"
+	PayloadSource     = "source"
+	PayloadURL        = "url" // of a page fetched from the web
+	PayloadFormat     = "format"
+	PayloadSection    = "section" // heading path, e.g. "Installation > Linux"
+	PayloadAnchor     = "anchor"
+	PayloadPage       = "page"
+	PayloadChunk      = "chunk"    // position of the chunk in the document
+	PayloadStart      = "start"    // byte offset of the chunk in the document's text
+	PayloadEnd        = "end"      // byte offset just past it
+	PayloadChunking   = "chunking" // strategy the document was chunked with
+	PayloadChecksum   = "checksum" // SHA-256 of the file the chunk came from
+)
+
+// Config tunes ingestion. Zero values use the defaults.
+type Config struct {
+	Collection string // indexed into when an upload doesn't name one
+	Workers    int    // jobs processed at once; default 2
+	QueueSize  int    // jobs waiting for a worker before uploads are refused; default 64
+
+	// Chunking splits documents into chunks; the default is the recursive
+	// strategy with chunks of up to 512 tokens. Strategies overrides its
+	// strategy for the collections it names.
+	Chunking   chunking.Config
+	Strategies map[string]chunking.Strategy
+
+	// ParentTokens, when set, makes chunks small children of parent
+	// sections of up to this many tokens, which retrieval returns in their
+	// place. It should be well above Chunking.MaxTokens.
+	ParentTokens int
+}
+
+func (c Config) withDefaults() Config {
+	if c.Chunking.Strategy == "" {
+		c.Chunking.Strategy = chunking.StrategyRecursive
+	}
+	if c.Workers <= 0 {
+		c.Workers = 2
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = 64
+	}
+	return c
+}
+
+// Upload is a file to ingest.
+type Upload struct {
+	Name       string
+	MIMEType   string
+	Data       []byte
+	Collection string // empty uses the default collection
+	URL        string // where it was fetched from, for a web page
+
+	// Source names the document across versions, e.g. its path or URL: an
+	// upload with the source of a document already in the collection is a
+	// new version of it. Empty makes the upload a new document, listed
+	// under Name.
+	Source string
+}
+
+// Document describes an ingested file.
+type Document struct {
+	ID         string            `json:"id"`
+	Title      string            `json:"title"`
+	Format     string            `json:"format"`
+	Source     string            `json:"source"`
+	URL        string            `json:"url,omitempty"`
+	Collection string            `json:"collection"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Checksum   string            `json:"checksum"`
+	Chunking   chunking.Strategy `json:"chunking"`
+	Sections   int               `json:"sections"`
+	Chunks     int               `json:"chunks"`
+
+	// Changes compares the chunks with those of the version of the file
+	// indexed before, if any. Unchanged is set when the file itself was
+	// already indexed, in which case nothing was done.
+	Changes   *Changes `json:"changes,omitempty"`
+	Unchanged bool     `json:"unchanged,omitempty"`
+}
+
+// Changes counts the chunks added, removed and kept when a file is indexed
+// again. Kept chunks are not embedded again.
+type Changes struct {
+	Added     int `json:"added"`
+	Removed   int `json:"removed"`
+	Unchanged int `json:"unchanged"`
+}
+
+// JobStatus is where a job is in its life.
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+// Stage is a step of ingestion. Each stage saves its output before the job
+// moves on, so a job interrupted by a restart resumes at the stage it was in.
+type Stage string
+
+const (
+	StageExtract Stage = "extract" // load the file into sections
+	StageChunk   Stage = "chunk"   // split sections into chunks
+	StageEmbed   Stage = "embed"   // embed the chunks
+	StageUpsert  Stage = "upsert"  // write the chunks to the vector store
+	StageGraph   Stage = "graph"   // extract the chunks' entities into the knowledge graph
+)
+
+// stages lists the stages in the order they run.
+var stages = []Stage{StageExtract, StageChunk, StageEmbed, StageUpsert, StageGraph}
+
+// Job is an asynchronous ingestion of one upload.
+type Job struct {
+	ID         string    `json:"id"`
+	Status     JobStatus `json:"status"`
+	Stage      Stage     `json:"stage"`              // the stage running, or next to run
+	Progress   *Progress `json:"progress,omitempty"` // within the stage, when it is measurable
+	Name       string    `json:"name"`
+	MIMEType   string    `json:"mime_type,omitempty"`
+	Collection string    `json:"collection"`
+	Versioned  bool      `json:"versioned,omitempty"` // the upload named its source, see Upload.Source
+	Document   *Document `json:"document,omitempty"`  // filled in as stages complete
+	Error      string    `json:"error,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// chunkKey is what the IDs of the job's chunks are derived from: the source
+// of a versioned document, so a new version keeps its unchanged chunks, or
+// else the document's own ID, so uploads of the same name share nothing.
+func (j *Job) chunkKey() string {
+	if j.Versioned {
+		return j.Document.Source
+	}
+	return j.Document.ID
+}
+
+// Done reports whether the job has finished, successfully or not.
+func (j *Job) Done() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed
+}
+
+// Progress counts the work done in a stage, e.g. chunks embedded.
+type Progress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
@@ -0,0 +1,1012 @@
+package documents
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/chunking"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/embedding"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai/limiter"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/db/vector/local"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/loaders"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/retrieval"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/tokenizer"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// embedBatchSize is how many chunks are embedded per request.
+	embedBatchSize = 64
+
+	// jobRetention is how long finished jobs can still be looked up.
+	jobRetention = 24 * time.Hour
+)
+
+type service struct {
+	loaders  *loaders.Registry
+	embedder embedding.Provider
+	vectors  local.Service
+	indexer  *retrieval.GraphIndexer
+	parents  retrieval.ParentStore
+	store    JobStore
+	cfg      Config
+	logger   *zap.Logger
+
+	queue chan string
+
+	// mu orders job updates with Watch, so a watcher misses none, and
+	// guards running, the jobs a worker has taken.
+	mu       sync.Mutex
+	watchers map[string][]chan Job
+	running  map[string]bool
+}
+
+// NewService returns the ingestion service. embedder may be nil, in which
+// case ingestion fails with retrieval.ErrNoEmbedder; indexer may be nil when
+// there is no knowledge graph. Parent sections are kept in parents when
+// cfg.ParentTokens is set. Jobs are kept in store and only processed once
+// Start is called.
+func NewService(registry *loaders.Registry, embedder embedding.Provider, vectors local.Service, indexer *retrieval.GraphIndexer, parents retrieval.ParentStore, store JobStore, cfg Config, logger *zap.Logger) Service {
+	cfg = cfg.withDefaults()
+	return &service{
+		loaders:  registry,
+		embedder: embedder,
+		vectors:  vectors,
+		indexer:  indexer,
+		parents:  parents,
+		store:    store,
+		cfg:      cfg,
+		logger:   logger,
+		queue:    make(chan string, cfg.QueueSize),
+		watchers: make(map[string][]chan Job),
+		running:  make(map[string]bool),
+	}
+}
+
+func (s *service) Ingest(ctx context.Context, upload *Upload) (*Document, error) {
+	job, err := s.newJob(upload)
+	if err != nil {
+		return nil, err
+	}
+	r := &run{job: job, data: upload.Data}
+	if err := s.process(ctx, r); err != nil {
+		return nil, err
+	}
+	return job.Document, nil
+}
+
+func (s *service) List(ctx context.Context, collection string) ([]*Document, error) {
+	if collection == "" {
+		collection = s.cfg.Collection
+	}
+	resp, err := s.vectors.ListPoints(ctx, &local.ListPointsRequest{CollectionName: collection})
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*Document)
+	var docs []*Document
+	for _, p := range resp.Points {
+		id, _ := p.Payload[PayloadDocumentID].(string)
+		if id == "" {
+			continue // not a chunk of an ingested file
+		}
+		doc, ok := byID[id]
+		if !ok {
+			doc = &Document{ID: id, Collection: collection}
+			doc.Title, _ = p.Payload[PayloadTitle].(string)
+			doc.Format, _ = p.Payload[PayloadFormat].(string)
+			doc.Source, _ = p.Payload[PayloadSource].(string)
+			doc.URL, _ = p.Payload[PayloadURL].(string)
+			doc.Checksum, _ = p.Payload[PayloadChecksum].(string)
+			doc.Chunking = indexedStrategy(p)
+			byID[id] = doc
+			docs = append(docs, doc)
+		}
+		doc.Chunks++
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		if docs[i].Source != docs[j].Source {
+			return docs[i].Source < docs[j].Source
+		}
+		return docs[i].ID < docs[j].ID
+	})
+	return docs, nil
+}
+
+func (s *service) Delete(ctx context.Context, collection, id string) error {
+	if collection == "" {
+		collection = s.cfg.Collection
+	}
+	resp, err := s.vectors.ListPoints(ctx, &local.ListPointsRequest{
+		CollectionName: collection,
+		Filter:         &local.Payload{PayloadDocumentID: id},
+	})
+	if err != nil {
+		return err
+	}
+	if len(resp.Points) == 0 {
+		return ErrDocumentNotFound
+	}
+
+	ids := make([]string, len(resp.Points))
+	for i, p := range resp.Points {
+		ids[i] = p.ID
+	}
+	if err := s.vectors.DeletePoints(ctx, &local.DeletePointsRequest{CollectionName: collection, PointIDs: ids}); err != nil {
+		return err
+	}
+	if parentIDs := parentIDs(resp.Points); len(parentIDs) > 0 && s.parents != nil {
+		if err := s.parents.DeleteParents(ctx, collection, parentIDs); err != nil {
+			return err
+		}
+	}
+	if s.indexer != nil {
+		for _, chunkID := range ids {
+			if err := s.indexer.Remove(ctx, chunkID); err != nil {
+				s.logger.Warn("Failed to remove chunk from the graph", zap.String("chunk_id", chunkID), zap.Error(err))
+			}
+		}
+	}
+
+	s.logger.Info("Deleted document",
+		zap.String("id", id),
+		zap.String("collection", collection),
+		zap.Int("chunks", len(ids)))
+	return nil
+}
+
+func (s *service) Submit(ctx context.Context, upload *Upload) (*Job, error) {
+	job, err := s.newJob(upload)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.SaveArtifact(ctx, job.ID, artifactUpload, upload.Data); err != nil {
+		return nil, err
+	}
+	if err := s.store.SaveJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	select {
+	case s.queue <- job.ID:
+	default:
+		if err := s.store.DeleteJob(ctx, job.ID); err != nil {
+			s.logger.Warn("Failed to delete refused job", zap.String("job_id", job.ID), zap.Error(err))
+		}
+		return nil, ErrQueueFull
+	}
+
+	s.logger.Info("Queued document for ingestion",
+		zap.String("job_id", job.ID),
+		zap.String("name", job.Name),
+		zap.String("collection", job.Collection))
+	return job, nil
+}
+
+func (s *service) Job(ctx context.Context, id string) (*Job, error) {
+	return s.store.LoadJob(ctx, id)
+}
+
+func (s *service) Watch(ctx context.Context, id string) (<-chan Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, err := s.store.LoadJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	updates := make(chan Job, 1)
+	updates <- *job
+	if job.Done() {
+		close(updates)
+		return updates, nil
+	}
+
+	s.watchers[id] = append(s.watchers[id], updates)
+	go func() {
+		<-ctx.Done()
+		s.unwatch(id, updates)
+	}()
+	return updates, nil
+}
+
+func (s *service) Start(ctx context.Context) error {
+	jobs, err := s.store.ListJobs(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pending []*Job
+	for _, job := range jobs {
+		switch {
+		case !job.Done():
+			pending = append(pending, job)
+		case time.Since(job.UpdatedAt) > jobRetention:
+			if err := s.store.DeleteJob(ctx, job.ID); err != nil {
+				s.logger.Warn("Failed to delete expired job", zap.String("job_id", job.ID), zap.Error(err))
+			}
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].CreatedAt.Before(pending[j].CreatedAt) })
+
+	for range s.cfg.Workers {
+		go s.work(ctx)
+	}
+	if len(pending) > 0 {
+		s.logger.Info("Resuming unfinished ingestion jobs", zap.Int("jobs", len(pending)))
+		go func() {
+			for _, job := range pending {
+				select {
+				case s.queue <- job.ID:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+// newJob checks an upload and returns a job for it, ready for the first
+// stage. Mistakes the caller can fix are caught here rather than in a worker.
+func (s *service) newJob(upload *Upload) (*Job, error) {
+	if len(upload.Data) == 0 {
+		return nil, ErrNoFile
+	}
+	if len(upload.Data) > MaxUploadBytes {
+		return nil, ErrFileTooLarge
+	}
+	if s.embedder == nil {
+		return nil, retrieval.ErrNoEmbedder
+	}
+	collection := upload.Collection
+	if collection == "" {
+		collection = s.cfg.Collection
+	}
+	if err := local.ValidateCollectionName(collection); err != nil {
+		return nil, err
+	}
+	_, format, err := s.loaders.Select(loaders.Source{Name: upload.Name, MIMEType: upload.MIMEType}, upload.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	source := upload.Source
+	if source == "" {
+		source = upload.Name
+	}
+
+	now := time.Now().UTC()
+	return &Job{
+		ID:         uuid.NewString(),
+		Status:     JobQueued,
+		Stage:      StageExtract,
+		Name:       upload.Name,
+		MIMEType:   upload.MIMEType,
+		Collection: collection,
+		Versioned:  upload.Source != "",
+		Document: &Document{
+			ID:         uuid.NewString(),
+			Format:     format,
+			Source:     source,
+			URL:        upload.URL,
+			Collection: collection,
+			Checksum:   fmt.Sprintf("%x", sha256.Sum256(upload.Data)),
+			Chunking:   s.chunking(collection).Strategy,
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+func (s *service) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-s.queue:
+			s.runJob(ctx, id)
+		}
+	}
+}
+
+// runJob processes a queued job. A job cut short by shutdown is left as it
+// is, to resume at its current stage on the next Start. A job queued twice,
+// by Submit and by Start, is only run once.
+func (s *service) runJob(ctx context.Context, id string) {
+	s.mu.Lock()
+	if s.running[id] {
+		s.mu.Unlock()
+		return
+	}
+	s.running[id] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, id)
+		s.mu.Unlock()
+	}()
+
+	job, err := s.store.LoadJob(ctx, id)
+	if err != nil {
+		s.logger.Error("Failed to load ingestion job", zap.String("job_id", id), zap.Error(err))
+		return
+	}
+	if job.Done() {
+		return
+	}
+
+	job.Status = JobRunning
+	r := &run{job: job, persist: true}
+	err = s.process(limiter.WithPriority(ctx, limiter.PriorityBackground), r)
+	if err == nil {
+		return
+	}
+	if ctx.Err() != nil {
+		s.logger.Info("Ingestion job interrupted", zap.String("job_id", id), zap.String("stage", string(job.Stage)))
+		return
+	}
+
+	s.logger.Error("Ingestion job failed",
+		zap.String("job_id", id),
+		zap.String("stage", string(job.Stage)),
+		zap.Error(err))
+	job.Status = JobFailed
+	job.Error = err.Error()
+	if err := s.update(ctx, r); err != nil {
+		s.logger.Error("Failed to save ingestion job", zap.String("job_id", id), zap.Error(err))
+	}
+	if err := s.store.DeleteArtifacts(ctx, id); err != nil {
+		s.logger.Warn("Failed to delete job data", zap.String("job_id", id), zap.Error(err))
+	}
+}
+
+// update saves and broadcasts the state of a job. It does nothing for
+// synchronous ingestion, which has no job to track.
+func (s *service) update(ctx context.Context, r *run) error {
+	if !r.persist {
+		return nil
+	}
+	r.job.UpdatedAt = time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.store.SaveJob(ctx, r.job); err != nil {
+		return err
+	}
+
+	job := *copyJob(r.job)
+	for _, updates := range s.watchers[job.ID] {
+		// Replace an update the watcher hasn't read yet with this one.
+		select {
+		case <-updates:
+		default:
+		}
+		updates <- job
+		if job.Done() {
+			close(updates)
+		}
+	}
+	if job.Done() {
+		delete(s.watchers, job.ID)
+	}
+	return nil
+}
+
+func (s *service) unwatch(id string, updates chan Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	watchers := s.watchers[id]
+	for i, w := range watchers {
+		if w == updates {
+			s.watchers[id] = append(watchers[:i], watchers[i+1:]...)
+			close(updates)
+			break
+		}
+	}
+	if len(s.watchers[id]) == 0 {
+		delete(s.watchers, id)
+	}
+}
+
+// Names of the artifacts a job keeps in the store: its upload and the
+// output of each completed stage.
+const (
+	artifactUpload   = "upload"
+	artifactDocument = "document.json"
+	artifactChunks   = "chunks.json"
+	artifactPoints   = "points.json"
+	artifactDiff     = "diff.json"
+)
+
+// run is one ingestion going through the stages. Stage outputs are kept on
+// it and, when persist is set, saved to the store as each stage completes so
+// the job can resume after a restart.
+type run struct {
+	job     *Job
+	persist bool
+
+	data   []byte
+	loaded *loaders.Document
+	chunks []chunk
+	points []local.Point
+	diff   *pointDiff
+
+	// unchanged is set when the file is already indexed as is, which skips
+	// the stages up to the graph one.
+	unchanged bool
+}
+
+// pointDiff lists the points of a document added and removed since the
+// version indexed before. Parents are the parent sections of the points,
+// saved with them, and RemovedParents those only the points of the version
+// before had.
+type pointDiff struct {
+	Added          []string           `json:"added"`
+	Removed        []string           `json:"removed"`
+	Parents        []retrieval.Parent `json:"parents,omitempty"`
+	RemovedParents []string           `json:"removed_parents,omitempty"`
+}
+
+// process runs the stages from the job's current one to the end.
+func (s *service) process(ctx context.Context, r *run) error {
+	start := 0
+	for i, stage := range stages {
+		if stage == r.job.Stage {
+			start = i
+		}
+	}
+
+	r.unchanged = r.job.Document.Unchanged
+	for _, stage := range stages[start:] {
+		// An unchanged file only has its chunks checked against the graph.
+		if (r.unchanged && stage != StageGraph) || (stage == StageGraph && s.indexer == nil) {
+			continue
+		}
+		r.job.Stage = stage
+		r.job.Progress = nil
+		if err := s.update(ctx, r); err != nil {
+			return err
+		}
+
+		var err error
+		switch stage {
+		case StageExtract:
+			err = s.extract(ctx, r)
+		case StageChunk:
+			err = s.chunk(ctx, r)
+		case StageEmbed:
+			err = s.embed(ctx, r)
+		case StageUpsert:
+			err = s.upsert(ctx, r)
+		case StageGraph:
+			err = s.graph(ctx, r)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	doc := r.job.Document
+	r.job.Status = JobSucceeded
+	r.job.Progress = nil
+	if err := s.update(ctx, r); err != nil {
+		return err
+	}
+	if r.persist {
+		if err := s.store.DeleteArtifacts(ctx, r.job.ID); err != nil {
+			s.logger.Warn("Failed to delete job data", zap.String("job_id", r.job.ID), zap.Error(err))
+		}
+	}
+
+	s.logger.Info("Ingested document",
+		zap.String("id", doc.ID),
+		zap.String("source", doc.Source),
+		zap.String("format", doc.Format),
+		zap.String("collection", doc.Collection),
+		zap.Int("sections", doc.Sections),
+		zap.Int("chunks", doc.Chunks),
+		zap.Any("changes", doc.Changes))
+	return nil
+}
+
+// extract loads the upload into sections. A versioned upload whose source
+// was indexed before takes over that document's ID, and one with the same
+// checksum, chunking strategy and parent sections, or lack of them, is not
+// indexed again at all.
+func (s *service) extract(ctx context.Context, r *run) error {
+	doc := r.job.Document
+	if r.job.Versioned {
+		id, err := s.documentID(ctx, doc)
+		if err != nil {
+			return err
+		}
+		if id != "" {
+			doc.ID = id
+		}
+	}
+	indexed, err := s.indexed(ctx, doc, false)
+	if err != nil {
+		return err
+	}
+	if len(indexed) > 0 {
+		if sameChecksum(indexed, doc.Checksum) && indexedStrategy(indexed[0]) == doc.Chunking &&
+			(len(parentIDs(indexed)) > 0) == s.hierarchical() {
+			doc.Title, _ = indexed[0].Payload[PayloadTitle].(string)
+			doc.Chunks = len(indexed)
+			doc.Changes = &Changes{Unchanged: len(indexed)}
+			doc.Unchanged = true
+			r.unchanged = true
+			return nil
+		}
+	}
+
+	if r.data == nil {
+		if err := s.restore(ctx, r, artifactUpload, &r.data); err != nil {
+			return err
+		}
+	}
+	src := loaders.Source{Name: r.job.Name, MIMEType: r.job.MIMEType}
+	loader, _, err := s.loaders.Select(src, r.data)
+	if err != nil {
+		return err
+	}
+	loaded, err := loader.Load(ctx, r.data, src)
+	if err != nil {
+		if errors.Is(err, loaders.ErrEmptyDocument) || ctx.Err() != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrUnreadableFile, err)
+	}
+
+	r.loaded = loaded
+	r.job.Document.Title = loaded.Title
+	r.job.Document.Metadata = loaded.Metadata
+	r.job.Document.Sections = len(loaded.Sections)
+	return s.checkpoint(ctx, r, artifactDocument, loaded)
+}
+
+// chunk splits the sections into chunks small enough to embed, with the
+// strategy of the document's collection, first cutting them into parent
+// sections when those are enabled.
+func (s *service) chunk(ctx context.Context, r *run) error {
+	if r.loaded == nil {
+		if err := s.restore(ctx, r, artifactDocument, &r.loaded); err != nil {
+			return err
+		}
+	}
+	tok := tokenizer.ForModel(s.embedder.GetModel())
+	chunker, err := chunking.New(s.chunking(r.job.Document.Collection), tok, s.embedder)
+	if err != nil {
+		return err
+	}
+	var parents chunking.Chunker
+	if s.hierarchical() {
+		if parents, err = chunking.New(chunking.Config{MaxTokens: s.cfg.ParentTokens}, tok, nil); err != nil {
+			return err
+		}
+	}
+	r.chunks, err = splitDocument(ctx, r.loaded, chunker, parents)
+	if err != nil {
+		return err
+	}
+	if len(r.chunks) == 0 {
+		return loaders.ErrEmptyDocument
+	}
+	return s.checkpoint(ctx, r, artifactChunks, r.chunks)
+}
+
+// embed turns the chunks into points, embedding in batches only those not
+// indexed before. Point IDs are derived from the content, so a chunk that
+// didn't change since the last version of the file keeps its ID and vector.
+// Chunks are embedded under their document title and heading path, which
+// helps match questions that name the topic rather than repeat the passage.
+func (s *service) embed(ctx context.Context, r *run) error {
+	if r.loaded == nil {
+		if err := s.restore(ctx, r, artifactDocument, &r.loaded); err != nil {
+			return err
+		}
+	}
+	if r.chunks == nil {
+		if err := s.restore(ctx, r, artifactChunks, &r.chunks); err != nil {
+			return err
+		}
+	}
+
+	doc := r.job.Document
+	indexed, err := s.indexed(ctx, doc, true)
+	if err != nil {
+		return err
+	}
+	vectors := make(map[string]local.Vector, len(indexed))
+	for _, p := range indexed {
+		vectors[p.ID] = p.Vector
+	}
+
+	model := s.embedder.GetModel()
+	diff := &pointDiff{}
+	points := make([]local.Point, 0, len(r.chunks))
+	var texts []string
+	kept := make(map[string]bool)
+	keptParents := make(map[string]bool)
+	docText := r.loaded.Text()
+	for position, c := range r.chunks {
+		section := &r.loaded.Sections[c.Section]
+		text := embeddingText(doc.Title, section, c.Text)
+		id := chunkID(model, r.job.chunkKey(), text)
+		if kept[id] {
+			// The same passage twice under one heading is indexed once.
+			continue
+		}
+		kept[id] = true
+
+		p := local.Point{ID: id, Vector: vectors[id], Payload: chunkPayload(doc, section, c, position)}
+		if c.ParentEnd > 0 {
+			parent := newParent(r.job.chunkKey(), docText, c)
+			p.Payload[retrieval.PayloadParentID] = parent.ID
+			if !keptParents[parent.ID] {
+				keptParents[parent.ID] = true
+				diff.Parents = append(diff.Parents, parent)
+			}
+		}
+		if len(p.Vector) == 0 {
+			diff.Added = append(diff.Added, id)
+			texts = append(texts, text)
+		}
+		points = append(points, p)
+	}
+	for _, p := range indexed {
+		if !kept[p.ID] {
+			diff.Removed = append(diff.Removed, p.ID)
+		}
+	}
+	for _, id := range parentIDs(indexed) {
+		if !keptParents[id] {
+			diff.RemovedParents = append(diff.RemovedParents, id)
+		}
+	}
+
+	embedded := make([]local.Vector, 0, len(texts))
+	for start := 0; start < len(texts); start += embedBatchSize {
+		r.job.Progress = &Progress{Done: start, Total: len(texts)}
+		if err := s.update(ctx, r); err != nil {
+			return err
+		}
+
+		batch := texts[start:min(start+embedBatchSize, len(texts))]
+		vectors, err := s.embedder.CreateEmbeddings(ctx, batch)
+		if err != nil {
+			return fmt.Errorf("failed to embed chunks: %w", err)
+		}
+		if len(vectors) != len(batch) {
+			return fmt.Errorf("failed to embed chunks: got %d embeddings for %d chunks", len(vectors), len(batch))
+		}
+		for _, v := range vectors {
+			embedded = append(embedded, v)
+		}
+	}
+	for i := range points {
+		if len(points[i].Vector) == 0 {
+			points[i].Vector, embedded = embedded[0], embedded[1:]
+		}
+	}
+
+	r.points = points
+	r.diff = diff
+	r.job.Progress = &Progress{Done: len(texts), Total: len(texts)}
+	doc.Changes = &Changes{
+		Added:     len(diff.Added),
+		Removed:   len(diff.Removed),
+		Unchanged: len(points) - len(diff.Added),
+	}
+	if err := s.checkpoint(ctx, r, artifactPoints, points); err != nil {
+		return err
+	}
+	return s.checkpoint(ctx, r, artifactDiff, diff)
+}
+
+// upsert writes the points to the vector store, then deletes those of the
+// previous version of the file that are gone. Parent sections are saved
+// first, so no point refers to a parent not yet there. Point IDs were fixed
+// when they were embedded, so repeating the stage after a restart is
+// harmless.
+//
+// Kept points are written again along with the added ones: every payload
+// carries the file's checksum, which extract compares to skip unchanged
+// files, and its position in the document, so a new version of the file
+// changes them all. The local store rewrites the whole collection on any
+// change regardless.
+func (s *service) upsert(ctx context.Context, r *run) error {
+	if r.points == nil {
+		if err := s.restore(ctx, r, artifactPoints, &r.points); err != nil {
+			return err
+		}
+	}
+	if r.diff == nil {
+		if err := s.restore(ctx, r, artifactDiff, &r.diff); err != nil {
+			return err
+		}
+	}
+
+	doc := r.job.Document
+	if len(r.diff.Parents) > 0 && s.parents != nil {
+		if err := s.parents.SaveParents(ctx, doc.Collection, r.diff.Parents); err != nil {
+			return err
+		}
+	}
+	if err := s.vectors.CreateCollection(ctx, &local.CreateCollectionRequest{
+		CollectionName: doc.Collection,
+		VectorSize:     uint64(len(r.points[0].Vector)),
+	}); err != nil {
+		return err
+	}
+	if err := s.vectors.UpsertPoints(ctx, &local.UpsertPointsRequest{
+		CollectionName: doc.Collection,
+		Points:         r.points,
+	}); err != nil {
+		return err
+	}
+	if len(r.diff.Removed) > 0 {
+		if err := s.vectors.DeletePoints(ctx, &local.DeletePointsRequest{
+			CollectionName: doc.Collection,
+			PointIDs:       r.diff.Removed,
+		}); err != nil {
+			return err
+		}
+	}
+	if len(r.diff.RemovedParents) > 0 && s.parents != nil {
+		if err := s.parents.DeleteParents(ctx, doc.Collection, r.diff.RemovedParents); err != nil {
+			return err
+		}
+	}
+	doc.Chunks = len(r.points)
+	return nil
+}
+
+// documentID returns the ID of the document indexed in doc's collection
+// under doc's source, or "" if there is none.
+func (s *service) documentID(ctx context.Context, doc *Document) (string, error) {
+	points, err := s.listPoints(ctx, doc.Collection, &local.Payload{PayloadSource: doc.Source}, false)
+	if err != nil || len(points) == 0 {
+		return "", err
+	}
+	id, _ := points[0].Payload[PayloadDocumentID].(string)
+	return id, nil
+}
+
+// indexed returns the points of the version of doc already in its
+// collection, if any.
+func (s *service) indexed(ctx context.Context, doc *Document, withVector bool) ([]local.Point, error) {
+	return s.listPoints(ctx, doc.Collection, &local.Payload{PayloadDocumentID: doc.ID}, withVector)
+}
+
+// listPoints returns the points of collection matching filter, none if the
+// collection doesn't exist yet.
+func (s *service) listPoints(ctx context.Context, collection string, filter *local.Payload, withVector bool) ([]local.Point, error) {
+	resp, err := s.vectors.ListPoints(ctx, &local.ListPointsRequest{
+		CollectionName: collection,
+		Filter:         filter,
+		WithVector:     withVector,
+	})
+	if errors.Is(err, local.ErrCollectionNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexed chunks: %w", err)
+	}
+	return resp.Points, nil
+}
+
+// sameChecksum reports whether every point came from a file with checksum.
+func sameChecksum(points []local.Point, checksum string) bool {
+	for _, p := range points {
+		if sum, _ := p.Payload[PayloadChecksum].(string); sum != checksum {
+			return false
+		}
+	}
+	return true
+}
+
+// indexedStrategy returns the chunking strategy of an indexed chunk. Chunks
+// indexed before strategies could be chosen were split recursively.
+func indexedStrategy(p local.Point) chunking.Strategy {
+	if strategy, _ := p.Payload[PayloadChunking].(string); strategy != "" {
+		return chunking.Strategy(strategy)
+	}
+	return chunking.StrategyRecursive
+}
+
+// chunking returns how documents of collection are chunked.
+func (s *service) chunking(collection string) chunking.Config {
+	cfg := s.cfg.Chunking
+	if strategy, ok := s.cfg.Strategies[collection]; ok {
+		cfg.Strategy = strategy
+	}
+	return cfg
+}
+
+// hierarchical reports whether chunks are cut from parent sections.
+func (s *service) hierarchical() bool {
+	return s.cfg.ParentTokens > 0 && s.parents != nil
+}
+
+// parentIDs returns the parent sections points refer to, each once.
+func parentIDs(points []local.Point) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, p := range points {
+		if id, _ := p.Payload[retrieval.PayloadParentID].(string); id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// newParent returns the parent section c was cut from. Like chunks, parents
+// are named after their document's key and text, so an unchanged one keeps
+// its ID.
+func newParent(key, docText string, c chunk) retrieval.Parent {
+	text := docText[c.ParentStart:c.ParentEnd]
+	return retrieval.Parent{
+		ID:   uuid.NewSHA1(chunkNamespace, []byte("parent\x00"+key+"\x00"+text)).String(),
+		Text: text,
+		Payload: map[string]any{
+			PayloadStart: c.ParentStart,
+			PayloadEnd:   c.ParentEnd,
+		},
+	}
+}
+
+// chunkNamespace scopes the name-based UUIDs of chunks.
+var chunkNamespace = uuid.MustParse("6f1b7c1e-3d0a-4c8e-9a55-2b7f0e4d9c31")
+
+// chunkID derives the ID of a chunk's point from the embedding model, the
+// key of the document it came from (see Job.chunkKey) and the text embedded,
+// so unchanged chunks keep their ID across versions of a file and a new model
+// gets new points.
+func chunkID(model, key, text string) string {
+	return uuid.NewSHA1(chunkNamespace, []byte(model+"\x00"+key+"\x00"+text)).String()
+}
+
+// checkpoint saves the output of a stage for a persisted run.
+func (s *service) checkpoint(ctx context.Context, r *run, name string, v any) error {
+	if !r.persist {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	return s.store.SaveArtifact(ctx, r.job.ID, name, data)
+}
+
+// restore loads the output of an earlier stage saved by checkpoint, for a
+// run resumed from the store. The upload is kept as raw bytes, everything
+// else as JSON.
+func (s *service) restore(ctx context.Context, r *run, name string, v any) error {
+	if !r.persist {
+		return fmt.Errorf("missing %s", name)
+	}
+	data, err := s.store.LoadArtifact(ctx, r.job.ID, name)
+	if err != nil {
+		return fmt.Errorf("failed to resume job at stage %s: %w", r.job.Stage, err)
+	}
+	if raw, ok := v.(*[]byte); ok {
+		*raw = data
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+	return nil
+}
+
+// graph brings the knowledge graph in line with the document: chunks removed
+// from it are unlinked, and every chunk of it the graph has no record of is
+// extracted. Checking all of them, not only those just added, lets a graph
+// that was lost, enabled late or down during an earlier ingestion catch up
+// whenever the file is ingested again, even unchanged. It makes one LLM call
+// per chunk extracted; chunks that fail are logged and skipped, to be tried
+// again next time.
+func (s *service) graph(ctx context.Context, r *run) error {
+	doc := r.job.Document
+	if !doc.Unchanged {
+		if r.diff == nil {
+			if err := s.restore(ctx, r, artifactDiff, &r.diff); err != nil {
+				return err
+			}
+		}
+		for _, id := range r.diff.Removed {
+			if err := s.indexer.Remove(ctx, id); err != nil {
+				s.logger.Debug("Failed to remove chunk from the graph", zap.String("chunk_id", id), zap.Error(err))
+			}
+		}
+	}
+
+	points, err := s.indexed(ctx, doc, false)
+	if err != nil {
+		return err
+	}
+	ids := make([]string, len(points))
+	texts := make(map[string]string, len(points))
+	for i, p := range points {
+		ids[i] = p.ID
+		texts[p.ID], _ = p.Payload[retrieval.PayloadText].(string)
+	}
+	missing, err := s.indexer.Missing(ctx, ids)
+	if err != nil {
+		s.logger.Warn("Failed to check the knowledge graph", zap.String("document_id", doc.ID), zap.Error(err))
+		return nil
+	}
+
+	failed := 0
+	for i, id := range missing {
+		r.job.Progress = &Progress{Done: i, Total: len(missing)}
+		if err := s.update(ctx, r); err != nil {
+			return err
+		}
+		if _, err := s.indexer.Index(ctx, id, texts[id]); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			failed++
+			s.logger.Debug("Failed to index chunk in the graph", zap.String("chunk_id", id), zap.Error(err))
+		}
+	}
+	r.job.Progress = &Progress{Done: len(missing), Total: len(missing)}
+	if len(missing) > 0 {
+		s.logger.Info("Indexed document in the knowledge graph",
+			zap.String("document_id", doc.ID),
+			zap.Int("extracted", len(missing)-failed),
+			zap.Int("failed", failed))
+	}
+	return nil
+}
+
+func embeddingText(title string, section *loaders.Section, text string) string {
+	header := title
+	if path := section.SectionPath(); path != "" && path != title {
+		header += " > " + path
+	}
+	if header == "" {
+		return text
+	}
+	return header + "\n\n" + text
+}
+
+func chunkPayload(doc *Document, section *loaders.Section, c chunk, position int) local.Payload {
+	payload := local.Payload{
+		retrieval.PayloadText: c.Text,
+		PayloadDocumentID:     doc.ID,
+		PayloadTitle:          doc.Title,
+		PayloadSource:         doc.Source,
+		PayloadFormat:         doc.Format,
+		PayloadChunk:          position,
+		PayloadStart:          c.Start,
+		PayloadEnd:            c.End,
+		PayloadChunking:       string(doc.Chunking),
+		PayloadChecksum:       doc.Checksum,
+	}
+	if doc.URL != "" {
+		payload[PayloadURL] = doc.URL
+	}
+	if path := section.SectionPath(); path != "" {
+		payload[PayloadSection] = path
+	}
+	if section.Anchor != "" {
+		payload[PayloadAnchor] = section.Anchor
+	}
+	if section.Page > 0 {
+		payload[PayloadPage] = section.Page
+	}
+	return payload
+}
@@ -0,0 +1,258 @@
+package documents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// InMemoryJobStore keeps jobs in process memory; they are lost on restart.
+type InMemoryJobStore struct {
+	mu        sync.RWMutex
+	jobs      map[string]*Job
+	artifacts map[string]map[string][]byte
+}
+
+func NewInMemoryJobStore() *InMemoryJobStore {
+	return &InMemoryJobStore{
+		jobs:      make(map[string]*Job),
+		artifacts: make(map[string]map[string][]byte),
+	}
+}
+
+func (s *InMemoryJobStore) SaveJob(ctx context.Context, job *Job) error {
+	j := copyJob(job)
+	s.mu.Lock()
+	s.jobs[job.ID] = j
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *InMemoryJobStore) LoadJob(ctx context.Context, id string) (*Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return copyJob(job), nil
+}
+
+func (s *InMemoryJobStore) ListJobs(ctx context.Context) ([]*Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, copyJob(job))
+	}
+	return jobs, nil
+}
+
+func (s *InMemoryJobStore) DeleteJob(ctx context.Context, id string) error {
+	s.mu.Lock()
+	delete(s.jobs, id)
+	delete(s.artifacts, id)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *InMemoryJobStore) SaveArtifact(ctx context.Context, id, name string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.artifacts[id] == nil {
+		s.artifacts[id] = make(map[string][]byte)
+	}
+	s.artifacts[id][name] = data
+	return nil
+}
+
+func (s *InMemoryJobStore) LoadArtifact(ctx context.Context, id, name string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.artifacts[id][name]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return data, nil
+}
+
+func (s *InMemoryJobStore) DeleteArtifacts(ctx context.Context, id string) error {
+	s.mu.Lock()
+	delete(s.artifacts, id)
+	s.mu.Unlock()
+	return nil
+}
+
+// copyJob hands out copies so callers can't mutate a stored job.
+func copyJob(job *Job) *Job {
+	j := *job
+	if job.Progress != nil {
+		p := *job.Progress
+		j.Progress = &p
+	}
+	if job.Document != nil {
+		d := *job.Document
+		if d.Changes != nil {
+			c := *d.Changes
+			d.Changes = &c
+		}
+		j.Document = &d
+	}
+	return &j
+}
+
+// jobFile is the name of a job's state in its FileJobStore directory.
+const jobFile = "job.json"
+
+// FileJobStore keeps each job in its own directory, as job.json next to its
+// artifacts, so jobs survive restarts.
+type FileJobStore struct {
+	dir string
+}
+
+// NewFileJobStore creates dir if needed and returns a store backed by it.
+func NewFileJobStore(dir string) (*FileJobStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create jobs directory: %w", err)
+	}
+	return &FileJobStore{dir: dir}, nil
+}
+
+func (s *FileJobStore) SaveJob(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job %s: %w", job.ID, err)
+	}
+	if err := s.write(job.ID, jobFile, data); err != nil {
+		return fmt.Errorf("failed to save job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+func (s *FileJobStore) LoadJob(ctx context.Context, id string) (*Job, error) {
+	if !validJobID(id) {
+		return nil, ErrJobNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, id, jobFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job %s: %w", id, err)
+	}
+
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("failed to decode job %s: %w", id, err)
+	}
+	return &job, nil
+}
+
+func (s *FileJobStore) ListJobs(ctx context.Context) ([]*Job, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	var jobs []*Job
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		job, err := s.LoadJob(ctx, entry.Name())
+		if errors.Is(err, ErrJobNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+func (s *FileJobStore) DeleteJob(ctx context.Context, id string) error {
+	if !validJobID(id) {
+		return nil
+	}
+	if err := os.RemoveAll(filepath.Join(s.dir, id)); err != nil {
+		return fmt.Errorf("failed to delete job %s: %w", id, err)
+	}
+	return nil
+}
+
+func (s *FileJobStore) SaveArtifact(ctx context.Context, id, name string, data []byte) error {
+	if err := s.write(id, name, data); err != nil {
+		return fmt.Errorf("failed to save %s of job %s: %w", name, id, err)
+	}
+	return nil
+}
+
+func (s *FileJobStore) LoadArtifact(ctx context.Context, id, name string) ([]byte, error) {
+	if !validJobID(id) {
+		return nil, ErrJobNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, id, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s of job %s: %w", name, id, err)
+	}
+	return data, nil
+}
+
+func (s *FileJobStore) DeleteArtifacts(ctx context.Context, id string) error {
+	if !validJobID(id) {
+		return nil
+	}
+	entries, err := os.ReadDir(filepath.Join(s.dir, id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete data of job %s: %w", id, err)
+	}
+	for _, entry := range entries {
+		if entry.Name() == jobFile {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, id, entry.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to delete data of job %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// write replaces a file of a job atomically via a temporary file.
+func (s *FileJobStore) write(id, name string, data []byte) error {
+	dir := filepath.Join(s.dir, id)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, name))
+}
+
+// validJobID keeps ids taken from requests from naming paths outside the
+// store.
+func validJobID(id string) bool {
+	return id != "" && id != "." && id != ".." && filepath.Base(id) == id
+}
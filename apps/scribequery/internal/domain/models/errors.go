@@ -0,0 +1,9 @@
+package models
+
+import "errors"
+
+var (
+	ErrUnknownProvider = errors.New("unknown provider")
+	ErrNotManaged      = errors.New("provider does not support model management")
+	ErrModelRequired   = errors.New("model is required")
+)
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"context"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+)
+
+type Service interface {
+	// List returns the models offered by every configured provider. Set
+	// refresh to bypass the cached catalog.
+	List(ctx context.Context, refresh bool) (Catalog, error)
+
+	// The management operations below act on the named provider, or on the
+	// provider the model routes to when provider is empty.
+
+	Pull(ctx context.Context, provider, model string, onProgress func(progress ai.PullProgress) error) error
+	// Ensure pulls model unless the provider already has it.
+	Ensure(ctx context.Context, provider, model string, onProgress func(progress ai.PullProgress) error) error
+	Show(ctx context.Context, provider, model string) (*ai.ModelDetail, error)
+	Delete(ctx context.Context, provider, model string) error
+	Running(ctx context.Context, provider string) ([]ai.RunningModel, error)
+
+	// Prepare ensures each entry ("model" or "provider=model") is available,
+	// one after another, recording their progress for Preparations and
+	// calling onChange with each update. Failures are returned together once
+	// every entry has been tried.
+	Prepare(ctx context.Context, entries []string, onChange func(p Preparation)) error
+	// Preparations returns the progress of the entries of Prepare.
+	Preparations() []Preparation
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+)
+
+// Catalog is the aggregated list of models across providers.
+type Catalog struct {
+	Models          []ai.ModelInfo `json:"models"`
+	DefaultProvider string         `json:"default_provider"`
+	DefaultModel    string         `json:"default_model"`
+	FetchedAt       time.Time      `json:"fetched_at"`
+
+	// Errors lists providers that could not be reached during the last
+	// refresh; their models are missing from Models.
+	Errors []string `json:"errors,omitempty"`
+
+	// Stale is set when no provider could be reached and Models is the
+	// catalog fetched at FetchedAt. Errors then says why; the providers are
+	// not queried again before RetryAt.
+	Stale   bool       `json:"stale,omitempty"`
+	RetryAt *time.Time `json:"retry_at,omitempty"`
+}
+
+// Preparation states.
+const (
+	PreparationPending = "pending"
+	PreparationReady   = "ready"
+	PreparationFailed  = "failed"
+)
+
+// Preparation is the progress of making one model available at startup
+// (ENSURE_MODELS). While the model is pulled, Status is the provider's pull
+// status and Completed/Total count the bytes of the current layer.
+type Preparation struct {
+	Provider  string     `json:"provider,omitempty"`
+	Model     string     `json:"model"`
+	Status    string     `json:"status"`
+	Completed int64      `json:"completed,omitempty"`
+	Total     int64      `json:"total,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DoneAt    *time.Time `json:"done_at,omitempty"`
+}
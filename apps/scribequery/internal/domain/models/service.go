@@ -0,0 +1,304 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+)
+
+const (
+	defaultRefreshInterval = 10 * time.Minute
+
+	// After a refresh in which no provider answered, the next one waits
+	// minBackoff, doubling with each failure up to the refresh interval.
+	minBackoff = 15 * time.Second
+)
+
+type service struct {
+	router  *ai.Router
+	refresh time.Duration
+
+	mu       sync.Mutex
+	catalog  *Catalog
+	fetching *fetch // the refresh in progress, shared by every List waiting on it
+
+	// Set while providers are failing: the error of the last refresh and
+	// when to try again.
+	failures int
+	lastErr  error
+	retryAt  time.Time
+
+	prepMu       sync.Mutex
+	preparations []Preparation
+}
+
+// NewService creates a Service that caches the catalog for refresh (10
+// minutes when zero).
+func NewService(router *ai.Router, refresh time.Duration) Service {
+	if refresh <= 0 {
+		refresh = defaultRefreshInterval
+	}
+	return &service{
+		router:  router,
+		refresh: refresh,
+	}
+}
+
+func (s *service) List(ctx context.Context, refresh bool) (Catalog, error) {
+	s.mu.Lock()
+	if !refresh && s.catalog != nil && time.Since(s.catalog.FetchedAt) < s.refresh {
+		defer s.mu.Unlock()
+		return *s.catalog, nil
+	}
+	// Explicit refreshes always retry; others wait out the backoff.
+	if !refresh && s.lastErr != nil && time.Now().Before(s.retryAt) {
+		defer s.mu.Unlock()
+		return s.failed()
+	}
+	if f := s.fetching; f != nil {
+		s.mu.Unlock()
+		return f.wait(ctx)
+	}
+	f := &fetch{done: make(chan struct{})}
+	s.fetching = f
+	s.mu.Unlock()
+
+	// Providers are asked without holding s.mu, so a slow one doesn't block
+	// callers served from the cache.
+	models, err := s.router.ListModels(ctx)
+
+	s.mu.Lock()
+	f.catalog, f.err = s.store(f, models, err)
+	s.mu.Unlock()
+	close(f.done)
+	return f.catalog, f.err
+}
+
+// store records the outcome of fetch f and returns what List answers with.
+// A fetch overtaken by invalidate isn't kept. s.mu must be held.
+func (s *service) store(f *fetch, models []ai.ModelInfo, err error) (Catalog, error) {
+	current := s.fetching == f
+	if current {
+		s.fetching = nil
+	}
+
+	if err != nil && len(models) == 0 {
+		if !current {
+			return Catalog{}, err
+		}
+		s.failures++
+		s.lastErr = err
+		s.retryAt = time.Now().Add(s.backoff())
+		return s.failed()
+	}
+
+	catalog := &Catalog{
+		Models:          models,
+		DefaultProvider: s.router.DefaultProvider(),
+		DefaultModel:    s.router.GetModel(),
+		FetchedAt:       time.Now(),
+	}
+	if err != nil {
+		catalog.Errors = strings.Split(err.Error(), "\n")
+	}
+	if current {
+		s.catalog = catalog
+		s.failures, s.lastErr = 0, nil
+	}
+	return *catalog, nil
+}
+
+// fetch is a refresh of the catalog in progress.
+type fetch struct {
+	done    chan struct{} // closed once catalog and err are set
+	catalog Catalog
+	err     error
+}
+
+// wait returns the outcome of the fetch, or ctx's error if ctx ends first.
+func (f *fetch) wait(ctx context.Context) (Catalog, error) {
+	select {
+	case <-f.done:
+		return f.catalog, f.err
+	case <-ctx.Done():
+		return Catalog{}, ctx.Err()
+	}
+}
+
+// failed returns the previous catalog marked stale, or the last error when
+// there is none. s.mu must be held.
+func (s *service) failed() (Catalog, error) {
+	if s.catalog == nil {
+		return Catalog{}, s.lastErr
+	}
+	catalog := *s.catalog
+	catalog.Stale = true
+	catalog.Errors = strings.Split(s.lastErr.Error(), "\n")
+	retryAt := s.retryAt
+	catalog.RetryAt = &retryAt
+	return catalog, nil
+}
+
+// backoff returns how long to wait after the current run of failures.
+func (s *service) backoff() time.Duration {
+	d := minBackoff
+	for i := 1; i < s.failures && d < s.refresh; i++ {
+		d *= 2
+	}
+	return min(d, s.refresh)
+}
+
+func (s *service) Pull(ctx context.Context, provider, model string, onProgress func(progress ai.PullProgress) error) error {
+	if model == "" {
+		return ErrModelRequired
+	}
+	manager, err := s.manager(provider, model)
+	if err != nil {
+		return err
+	}
+	defer s.invalidate()
+	return manager.PullModel(ctx, model, onProgress)
+}
+
+func (s *service) Ensure(ctx context.Context, provider, model string, onProgress func(progress ai.PullProgress) error) error {
+	if model == "" {
+		return ErrModelRequired
+	}
+	manager, err := s.manager(provider, model)
+	if err != nil {
+		return err
+	}
+	defer s.invalidate()
+	return manager.EnsureModel(ctx, model, onProgress)
+}
+
+func (s *service) Show(ctx context.Context, provider, model string) (*ai.ModelDetail, error) {
+	if model == "" {
+		return nil, ErrModelRequired
+	}
+	manager, err := s.manager(provider, model)
+	if err != nil {
+		return nil, err
+	}
+	return manager.ShowModel(ctx, model)
+}
+
+func (s *service) Delete(ctx context.Context, provider, model string) error {
+	if model == "" {
+		return ErrModelRequired
+	}
+	manager, err := s.manager(provider, model)
+	if err != nil {
+		return err
+	}
+	defer s.invalidate()
+	return manager.DeleteModel(ctx, model)
+}
+
+func (s *service) Running(ctx context.Context, provider string) ([]ai.RunningModel, error) {
+	manager, err := s.manager(provider, "")
+	if err != nil {
+		return nil, err
+	}
+	return manager.ListRunningModels(ctx)
+}
+
+func (s *service) Prepare(ctx context.Context, entries []string, onChange func(p Preparation)) error {
+	s.prepMu.Lock()
+	s.preparations = make([]Preparation, 0, len(entries))
+	for _, entry := range entries {
+		provider, model, found := strings.Cut(entry, "=")
+		if !found {
+			provider, model = "", provider
+		}
+		s.preparations = append(s.preparations, Preparation{
+			Provider:  strings.TrimSpace(provider),
+			Model:     strings.TrimSpace(model),
+			Status:    PreparationPending,
+			UpdatedAt: time.Now(),
+		})
+	}
+	s.prepMu.Unlock()
+
+	var errs []error
+	for i, entry := range entries {
+		// update records a change to entry i and reports it.
+		update := func(change func(p *Preparation)) {
+			s.prepMu.Lock()
+			p := &s.preparations[i]
+			change(p)
+			p.UpdatedAt = time.Now()
+			snapshot := *p
+			s.prepMu.Unlock()
+			if onChange != nil {
+				onChange(snapshot)
+			}
+		}
+
+		s.prepMu.Lock()
+		provider, model := s.preparations[i].Provider, s.preparations[i].Model
+		s.prepMu.Unlock()
+
+		err := s.Ensure(ctx, provider, model, func(progress ai.PullProgress) error {
+			update(func(p *Preparation) {
+				p.Status, p.Completed, p.Total = progress.Status, progress.Completed, progress.Total
+			})
+			return nil
+		})
+		update(func(p *Preparation) {
+			now := time.Now()
+			p.DoneAt = &now
+			p.Status = PreparationReady
+			if err != nil {
+				p.Status, p.Error = PreparationFailed, err.Error()
+			}
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *service) Preparations() []Preparation {
+	s.prepMu.Lock()
+	defer s.prepMu.Unlock()
+	return slices.Clone(s.preparations)
+}
+
+// manager returns the model manager of the named provider, or of the provider
+// model routes to when name is empty.
+func (s *service) manager(name, model string) (ai.ModelManager, error) {
+	var p ai.ChatProvider
+	if name == "" {
+		name, p = s.router.Resolve(model)
+	} else {
+		var ok bool
+		if p, ok = s.router.Provider(name); !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, name)
+		}
+	}
+
+	manager, ok := ai.AsModelManager(p)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrNotManaged, name)
+	}
+	return manager, nil
+}
+
+// invalidate drops the cached catalog after the installed models changed,
+// along with a fetch that may predate the change, and any backoff since the
+// provider was just reached.
+func (s *service) invalidate() {
+	s.mu.Lock()
+	s.catalog = nil
+	s.fetching = nil
+	s.failures, s.lastErr = 0, nil
+	s.mu.Unlock()
+}
@@ -0,0 +1,8 @@
+package search
+
+import "errors"
+
+var (
+	ErrTopKTooLarge = errors.New("top_k is too large")
+	ErrNoSources    = errors.New("no indexed passages match the question")
+)
@@ -0,0 +1,17 @@
+package search
+
+import (
+	"context"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/retrieval"
+)
+
+type Service interface {
+	// Search returns the chunks relevant to a query without asking the LLM
+	// to answer it.
+	Search(ctx context.Context, req *retrieval.Request) (*Response, error)
+
+	// Answer asks the LLM to answer a question from the chunks relevant to
+	// it, citing them. It fails with ErrNoSources when nothing is relevant.
+	Answer(ctx context.Context, req *AnswerRequest) (*Answer, error)
+}
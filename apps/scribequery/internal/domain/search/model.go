@@ -0,0 +1,83 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/retrieval"
+)
+
+// MaxTopK caps how many chunks one search may return.
+const MaxTopK = 50
+
+type Response struct {
+	Query  string            `json:"query"`
+	Chunks []retrieval.Chunk `json:"chunks"`
+	Debug  *Debug            `json:"debug"`
+}
+
+// Debug tells how the query was transformed before retrieval and, for an
+// answer, which sources didn't fit in the model's context window.
+type Debug struct {
+	retrieval.Transformation
+	Error string `json:"error,omitempty"` // Why the transformation failed, in which case the query was searched as is
+
+	DroppedSources   []string `json:"dropped_sources,omitempty"`   // Chunk IDs left out of the prompt
+	TruncatedSources []string `json:"truncated_sources,omitempty"` // Chunk IDs shortened to fit
+}
+
+// AnswerRequest is a question to answer from the chunks retrieved for it.
+type AnswerRequest struct {
+	retrieval.Request
+	Model       string   `json:"model,omitempty"`       // Empty uses the default model
+	Temperature *float64 `json:"temperature,omitempty"` // nil uses the provider default; 0 makes the answer cacheable
+	Cache       bool     `json:"cache,omitempty"`       // Caches the answer even at a non-zero temperature
+}
+
+// Answer is the model's answer with the sources it cited.
+type Answer struct {
+	Query     string        `json:"query"`
+	Answer    string        `json:"answer"`
+	Model     string        `json:"model"`
+	Citations []Citation    `json:"citations"`
+	Usage     ai.ChatUsage  `json:"usage"`
+	Prompt    *ai.PromptRef `json:"prompt,omitempty"` // Template version the question was asked with
+	Debug     *Debug        `json:"debug"`
+}
+
+// Citation is a retrieved chunk the answer refers to as [Number].
+type Citation struct {
+	Number     int     `json:"number"`
+	ChunkID    string  `json:"chunk_id"`
+	DocumentID string  `json:"document_id,omitempty"`
+	Title      string  `json:"title,omitempty"`
+	Section    string  `json:"section,omitempty"` // Heading path, e.g. "Installation > Linux"
+	Page       int     `json:"page,omitempty"`
+	Anchor     string  `json:"anchor,omitempty"`
+	Source     string  `json:"source,omitempty"`
+	URL        string  `json:"url,omitempty"` // of a web page, at the section's anchor
+	Score      float32 `json:"score"`
+
+	RerankScore *float32 `json:"rerank_score,omitempty"`
+}
+
+// Label names where the cited passage is, e.g. "Manual, Setup > Network, p. 37".
+func (c Citation) Label() string {
+	parts := make([]string, 0, 3)
+	if c.Title != "" {
+		parts = append(parts, c.Title)
+	} else if c.Source != "" {
+		parts = append(parts, c.Source)
+	}
+	if c.Section != "" && c.Section != c.Title {
+		parts = append(parts, c.Section)
+	}
+	if c.Page > 0 {
+		parts = append(parts, fmt.Sprintf("p. %d", c.Page))
+	}
+	if len(parts) == 0 {
+		return c.ChunkID
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,180 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/domain/documents"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai/window"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/prompts"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/retrieval"
+)
+
+// citationRef matches a source number cited in an answer, e.g. "[2]".
+var citationRef = regexp.MustCompile(`\[(\d+)\]`)
+
+type service struct {
+	retriever   retrieval.Retriever
+	transformer *retrieval.QueryTransformer
+	provider    ai.ChatProvider
+	window      *window.Builder
+	prompts     *prompts.Registry
+}
+
+// NewService returns the search service. Queries are rewritten by
+// transformer as their mode asks before retrieval; provider answers
+// questions with the answer_question prompt from registry, given the
+// best-ranked sources that fit in the model's context window by builder.
+func NewService(retriever retrieval.Retriever, transformer *retrieval.QueryTransformer, provider ai.ChatProvider, builder *window.Builder, registry *prompts.Registry) Service {
+	return &service{retriever: retriever, transformer: transformer, provider: provider, window: builder, prompts: registry}
+}
+
+func (s *service) Search(ctx context.Context, req *retrieval.Request) (*Response, error) {
+	req.Query = strings.TrimSpace(req.Query)
+	if req.Query == "" {
+		return nil, retrieval.ErrEmptyQuery
+	}
+	if req.TopK > MaxTopK {
+		return nil, ErrTopKTooLarge
+	}
+
+	debug := &Debug{}
+	x, err := s.transformer.Transform(ctx, req)
+	if errors.Is(err, retrieval.ErrUnknownMode) {
+		return nil, err
+	}
+	if err != nil {
+		// A failed rewrite shouldn't fail the search.
+		debug.Error = err.Error()
+		x = &retrieval.Transformation{Mode: retrieval.ModeStandard, Queries: []string{req.Query}}
+	}
+	debug.Transformation = *x
+
+	chunks, err := retrieval.RetrieveTransformed(ctx, s.retriever, req, x)
+	if err != nil {
+		return nil, err
+	}
+	if chunks == nil {
+		chunks = []retrieval.Chunk{}
+	}
+	return &Response{Query: req.Query, Chunks: chunks, Debug: debug}, nil
+}
+
+func (s *service) Answer(ctx context.Context, req *AnswerRequest) (*Answer, error) {
+	found, err := s.Search(ctx, &req.Request)
+	if err != nil {
+		return nil, err
+	}
+	if len(found.Chunks) == 0 {
+		return nil, ErrNoSources
+	}
+
+	citations := make([]Citation, len(found.Chunks))
+	chunks := make([]window.Chunk, len(found.Chunks))
+	for i, chunk := range found.Chunks {
+		citations[i] = NewCitation(i+1, chunk)
+		// The window keeps the highest scores; rank by retrieval order so the
+		// kept sources keep their numbers.
+		chunks[i] = window.Chunk{
+			ID:     chunk.ID,
+			Text:   chunk.Text,
+			Score:  float64(len(found.Chunks) - i),
+			Source: citations[i].Label(),
+		}
+	}
+
+	// Fit the sources beside the prompt without them, dropping the
+	// lowest-ranked ones that don't.
+	question, _, err := s.prompts.Render(prompts.AnswerQuestion, map[string]any{
+		"question": req.Query,
+		"sources":  []string{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	model := req.Model
+	if model == "" {
+		model = s.provider.GetModel()
+	}
+	kept, report, err := s.window.FitChunks(model, window.Input{
+		Chunks:  chunks,
+		Current: ai.Message{Role: ai.RoleUser, Content: question},
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	found.Debug.DroppedSources = report.DroppedChunks
+	found.Debug.TruncatedSources = report.TruncatedChunks
+
+	citations = citations[:len(kept)]
+	sources := make([]string, len(kept))
+	for i, c := range kept {
+		sources[i] = fmt.Sprintf("[%d] %s\n%s", i+1, c.Source, c.Text)
+	}
+
+	prompt, ref, err := s.prompts.Render(prompts.AnswerQuestion, map[string]any{
+		"question": req.Query,
+		"sources":  sources,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.provider.Completion(ctx, []ai.Message{
+		{Role: ai.RoleUser, Content: prompt},
+	}, &ai.ChatOptions{Model: req.Model, Temperature: req.Temperature, Cache: req.Cache})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Answer{
+		Query:     req.Query,
+		Answer:    resp.Content,
+		Model:     resp.Model,
+		Citations: cited(resp.Content, citations),
+		Usage:     resp.Usage,
+		Prompt:    ref,
+		Debug:     found.Debug,
+	}, nil
+}
+
+// cited returns the citations an answer refers to, in the order it first
+// refers to them.
+func cited(answer string, citations []Citation) []Citation {
+	out := []Citation{}
+	seen := make(map[int]bool)
+	for _, m := range citationRef.FindAllStringSubmatch(answer, -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || n < 1 || n > len(citations) || seen[n] {
+			continue
+		}
+		seen[n] = true
+		out = append(out, citations[n-1])
+	}
+	return out
+}
+
+// NewCitation describes a retrieved chunk as source number of an answer.
+func NewCitation(number int, chunk retrieval.Chunk) Citation {
+	c := Citation{Number: number, ChunkID: chunk.ID, Score: chunk.Score, RerankScore: chunk.RerankScore}
+	c.DocumentID, _ = chunk.Payload[documents.PayloadDocumentID].(string)
+	c.Title, _ = chunk.Payload[documents.PayloadTitle].(string)
+	c.Section, _ = chunk.Payload[documents.PayloadSection].(string)
+	c.Anchor, _ = chunk.Payload[documents.PayloadAnchor].(string)
+	c.Source, _ = chunk.Payload[documents.PayloadSource].(string)
+	if c.URL, _ = chunk.Payload[documents.PayloadURL].(string); c.URL != "" && c.Anchor != "" {
+		c.URL += "#" + c.Anchor
+	}
+	// Numbers come back as float64 from a vector store persisted as JSON.
+	switch page := chunk.Payload[documents.PayloadPage].(type) {
+	case int:
+		c.Page = page
+	case float64:
+		c.Page = int(page)
+	}
+	return c
+}
@@ -0,0 +1,11 @@
+package sites
+
+import "errors"
+
+var (
+	ErrSiteNotFound   = errors.New("site not found")
+	ErrInvalidMode    = errors.New("mode must be page, links or sitemap")
+	ErrInvalidLimit   = errors.New("max_pages or max_depth is out of range")
+	ErrInvalidRefresh = errors.New("refresh must be a duration of at least a minute, e.g. \"24h\"")
+	ErrSiteCrawling   = errors.New("site is being crawled")
+)
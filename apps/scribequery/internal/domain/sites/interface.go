@@ -0,0 +1,38 @@
+package sites
+
+import "context"
+
+type Service interface {
+	// Add checks a site and queues its first crawl.
+	Add(ctx context.Context, req *AddRequest) (*Site, error)
+
+	// List returns the sites, without their pages.
+	List(ctx context.Context) ([]*Site, error)
+
+	// Get returns a site with its pages.
+	Get(ctx context.Context, id string) (*Site, error)
+
+	// Refresh queues a crawl of a site ahead of its next refresh.
+	Refresh(ctx context.Context, id string) (*Site, error)
+
+	// Crawl crawls a site now, returning it once done. Pages that changed
+	// are ingested again, incrementally, and pages that are gone or no
+	// longer reached are removed from the index.
+	Crawl(ctx context.Context, id string) (*Site, error)
+
+	// Delete forgets a site and removes its pages from the index.
+	Delete(ctx context.Context, id string) error
+
+	// Start crawls queued sites and refreshes due ones in the background
+	// until ctx ends, first queueing the crawls a restart interrupted.
+	Start(ctx context.Context) error
+}
+
+// Store persists sites.
+type Store interface {
+	SaveSite(ctx context.Context, site *Site) error
+	// LoadSite returns ErrSiteNotFound for an unknown site.
+	LoadSite(ctx context.Context, id string) (*Site, error)
+	ListSites(ctx context.Context) ([]*Site, error)
+	DeleteSite(ctx context.Context, id string) error
+}
@@ -0,0 +1,85 @@
+package sites
+
+import (
+	"time"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/crawler"
+)
+
+// Limits on what one site may ask for.
+const (
+	DefaultMaxPages = 100
+	MaxPages        = 5000
+	DefaultMaxDepth = 2
+	MaxDepth        = 10
+	MinRefresh      = time.Minute
+)
+
+// Status is where a site is between crawls.
+type Status string
+
+const (
+	StatusQueued   Status = "queued"   // waiting to be crawled
+	StatusCrawling Status = "crawling" // being crawled
+	StatusIdle     Status = "idle"     // crawled, waiting for its next refresh if it has one
+	StatusFailed   Status = "failed"   // the last crawl couldn't start, e.g. without a sitemap
+)
+
+// AddRequest describes a site to index.
+type AddRequest struct {
+	URL        string       `json:"url"`
+	Mode       crawler.Mode `json:"mode"`       // page, links or sitemap; default page
+	MaxDepth   int          `json:"max_depth"`  // links followed in links mode; default 2
+	MaxPages   int          `json:"max_pages"`  // pages fetched per crawl; default 100
+	Collection string       `json:"collection"` // empty uses the default collection
+	Refresh    string       `json:"refresh"`    // time between crawls, e.g. "24h"; empty crawls once
+}
+
+// Site is a web page, or the pages reached from it, kept indexed.
+type Site struct {
+	ID         string       `json:"id"`
+	URL        string       `json:"url"`
+	Mode       crawler.Mode `json:"mode"`
+	MaxDepth   int          `json:"max_depth,omitempty"`
+	MaxPages   int          `json:"max_pages"`
+	Collection string       `json:"collection,omitempty"`
+	Refresh    string       `json:"refresh,omitempty"`
+	Status     Status       `json:"status"`
+	Error      string       `json:"error,omitempty"`
+	LastCrawl  *CrawlStats  `json:"last_crawl,omitempty"`
+	NextCrawl  *time.Time   `json:"next_crawl,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+
+	// Pages are those found by the last crawls, by URL. They are left out
+	// of lists.
+	Pages []*Page `json:"pages,omitempty"`
+}
+
+// refreshEvery returns the time between crawls, or 0 for none.
+func (s *Site) refreshEvery() time.Duration {
+	d, _ := time.ParseDuration(s.Refresh)
+	return d
+}
+
+// Page is a fetched page of a site.
+type Page struct {
+	URL        string `json:"url"`
+	DocumentID string `json:"document_id,omitempty"`
+	crawler.Validators
+	Links     []string  `json:"links,omitempty"` // followed again when the page is unmodified
+	Error     string    `json:"error,omitempty"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// CrawlStats counts what a crawl did with the pages it fetched.
+type CrawlStats struct {
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Fetched    int        `json:"fetched"`
+	Ingested   int        `json:"ingested"`  // new or changed
+	Unchanged  int        `json:"unchanged"` // not modified, or with the same content
+	Removed    int        `json:"removed"`   // gone, or no longer reached, and removed from the index
+	Skipped    int        `json:"skipped"`   // disallowed by robots.txt or asking not to be indexed
+	Failed     int        `json:"failed"`
+}
@@ -0,0 +1,423 @@
+package sites
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/domain/documents"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/crawler"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/db/vector/local"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// checkInterval is how often Start looks for sites due for a refresh.
+const checkInterval = time.Minute
+
+// saveEvery is how many pages a crawl fetches between saving its progress.
+const saveEvery = 10
+
+type service struct {
+	documents documents.Service
+	fetcher   *crawler.Fetcher
+	store     Store
+	logger    *zap.Logger
+
+	mu       sync.Mutex
+	crawling map[string]bool
+	wake     chan struct{} // pokes the background loop when a crawl is queued
+}
+
+// NewService returns the site service. Pages are fetched with fetcher and
+// ingested, as web pages or files by their content type, with documents.
+func NewService(docs documents.Service, fetcher *crawler.Fetcher, store Store, logger *zap.Logger) Service {
+	return &service{
+		documents: docs,
+		fetcher:   fetcher,
+		store:     store,
+		logger:    logger,
+		crawling:  make(map[string]bool),
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+func (s *service) Add(ctx context.Context, req *AddRequest) (*Site, error) {
+	u, err := crawler.ParseURL(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	site := &Site{
+		ID:         uuid.NewString(),
+		URL:        u.String(),
+		Mode:       req.Mode,
+		MaxPages:   req.MaxPages,
+		Collection: req.Collection,
+		Refresh:    req.Refresh,
+		Status:     StatusQueued,
+	}
+
+	switch site.Mode {
+	case "":
+		site.Mode = crawler.ModePage
+	case crawler.ModePage, crawler.ModeSitemap:
+	case crawler.ModeLinks:
+		site.MaxDepth = req.MaxDepth
+		if site.MaxDepth == 0 {
+			site.MaxDepth = DefaultMaxDepth
+		}
+	default:
+		return nil, ErrInvalidMode
+	}
+	if site.MaxPages == 0 {
+		site.MaxPages = DefaultMaxPages
+	}
+	if site.Mode == crawler.ModePage {
+		site.MaxPages = 1
+	}
+	if site.MaxPages < 0 || site.MaxPages > MaxPages || site.MaxDepth < 0 || site.MaxDepth > MaxDepth {
+		return nil, ErrInvalidLimit
+	}
+	if site.Refresh != "" {
+		if d, err := time.ParseDuration(site.Refresh); err != nil || d < MinRefresh {
+			return nil, ErrInvalidRefresh
+		}
+	}
+	if site.Collection != "" {
+		if err := local.ValidateCollectionName(site.Collection); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now().UTC()
+	site.CreatedAt, site.UpdatedAt, site.NextCrawl = now, now, &now
+	if err := s.store.SaveSite(ctx, site); err != nil {
+		return nil, err
+	}
+	s.logger.Info("Site added",
+		zap.String("site_id", site.ID),
+		zap.String("url", site.URL),
+		zap.String("mode", string(site.Mode)))
+	s.poke()
+	return site, nil
+}
+
+func (s *service) List(ctx context.Context) ([]*Site, error) {
+	sites, err := s.store.ListSites(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, site := range sites {
+		site.Pages = nil
+	}
+	sort.Slice(sites, func(i, j int) bool {
+		return sites[i].CreatedAt.Before(sites[j].CreatedAt)
+	})
+	return sites, nil
+}
+
+func (s *service) Get(ctx context.Context, id string) (*Site, error) {
+	return s.store.LoadSite(ctx, id)
+}
+
+func (s *service) Refresh(ctx context.Context, id string) (*Site, error) {
+	site, err := s.store.LoadSite(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if s.isCrawling(id) {
+		return site, nil
+	}
+	now := time.Now().UTC()
+	site.Status, site.NextCrawl, site.UpdatedAt = StatusQueued, &now, now
+	if err := s.store.SaveSite(ctx, site); err != nil {
+		return nil, err
+	}
+	s.poke()
+	return site, nil
+}
+
+func (s *service) Crawl(ctx context.Context, id string) (*Site, error) {
+	s.mu.Lock()
+	if s.crawling[id] {
+		s.mu.Unlock()
+		return nil, ErrSiteCrawling
+	}
+	s.crawling[id] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.crawling, id)
+		s.mu.Unlock()
+	}()
+
+	site, err := s.store.LoadSite(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return site, s.crawl(ctx, site)
+}
+
+func (s *service) Delete(ctx context.Context, id string) error {
+	site, err := s.store.LoadSite(ctx, id)
+	if err != nil {
+		return err
+	}
+	if s.isCrawling(id) {
+		return ErrSiteCrawling
+	}
+	for _, page := range site.Pages {
+		if err := s.removeDocument(ctx, site, page); err != nil {
+			return err
+		}
+	}
+	s.logger.Info("Site deleted", zap.String("site_id", id), zap.Int("pages", len(site.Pages)))
+	return s.store.DeleteSite(ctx, id)
+}
+
+func (s *service) Start(ctx context.Context) error {
+	sites, err := s.store.ListSites(ctx)
+	if err != nil {
+		return err
+	}
+	for _, site := range sites {
+		if site.Status != StatusCrawling {
+			continue
+		}
+		now := time.Now().UTC()
+		site.Status, site.NextCrawl = StatusQueued, &now
+		if err := s.store.SaveSite(ctx, site); err != nil {
+			return err
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+		for {
+			s.crawlDue(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-s.wake:
+			}
+		}
+	}()
+	return nil
+}
+
+// crawlDue crawls the sites whose next crawl is due, one at a time, the
+// longest overdue first.
+func (s *service) crawlDue(ctx context.Context) {
+	sites, err := s.store.ListSites(ctx)
+	if err != nil {
+		s.logger.Error("Failed to list sites", zap.Error(err))
+		return
+	}
+	now := time.Now()
+	var due []*Site
+	for _, site := range sites {
+		if site.NextCrawl != nil && !site.NextCrawl.After(now) {
+			due = append(due, site)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextCrawl.Before(*due[j].NextCrawl)
+	})
+
+	for _, site := range due {
+		if _, err := s.Crawl(ctx, site.ID); err != nil && ctx.Err() == nil &&
+			!errors.Is(err, ErrSiteCrawling) && !errors.Is(err, ErrSiteNotFound) {
+			s.logger.Warn("Site crawl failed", zap.String("site_id", site.ID), zap.Error(err))
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// crawl fetches the pages of a site, asking only for those that changed
+// since the last crawl, and brings the index in line with them.
+func (s *service) crawl(ctx context.Context, site *Site) error {
+	now := time.Now().UTC()
+	stats := &CrawlStats{StartedAt: now}
+	site.Status, site.Error, site.LastCrawl, site.NextCrawl, site.UpdatedAt = StatusCrawling, "", stats, nil, now
+	if err := s.store.SaveSite(ctx, site); err != nil {
+		return err
+	}
+	s.logger.Info("Crawling site", zap.String("site_id", site.ID), zap.String("url", site.URL))
+
+	pages := make(map[string]*Page, len(site.Pages))
+	known := make(map[string]crawler.Known, len(site.Pages))
+	for _, page := range site.Pages {
+		pages[page.URL] = page
+		known[page.URL] = crawler.Known{Validators: page.Validators, Links: page.Links}
+	}
+	reached := make(map[string]bool)
+
+	err := s.fetcher.Crawl(ctx, site.URL, crawler.CrawlOptions{
+		Mode:     site.Mode,
+		MaxDepth: site.MaxDepth,
+		MaxPages: site.MaxPages,
+		Known:    known,
+	}, func(p *crawler.Page, err error) error {
+		stats.Fetched++
+		s.visit(ctx, site, pages, reached, p, err)
+		if stats.Fetched%saveEvery == 0 {
+			site.Pages = sortedPages(pages)
+			site.UpdatedAt = time.Now().UTC()
+			return s.store.SaveSite(ctx, site)
+		}
+		return nil
+	})
+
+	// Pages no longer reached are dropped, unless the crawl stopped short
+	// of them: it failed or hit its page limit.
+	if err == nil && stats.Fetched < site.MaxPages {
+		for url, page := range pages {
+			if reached[url] {
+				continue
+			}
+			if err := s.removeDocument(ctx, site, page); err != nil {
+				s.logger.Warn("Failed to remove page no longer on the site", zap.String("url", url), zap.Error(err))
+				continue
+			}
+			delete(pages, url)
+			stats.Removed++
+		}
+	}
+
+	finished := time.Now().UTC()
+	site.Pages = sortedPages(pages)
+	stats.FinishedAt = &finished
+	site.UpdatedAt = finished
+	switch {
+	case ctx.Err() != nil:
+		// Interrupted: crawl again once started.
+		site.Status, site.NextCrawl, stats.FinishedAt = StatusQueued, &finished, nil
+	case err != nil:
+		site.Status, site.Error = StatusFailed, err.Error()
+	default:
+		site.Status = StatusIdle
+	}
+	if every := site.refreshEvery(); every > 0 && site.NextCrawl == nil {
+		next := finished.Add(every)
+		site.NextCrawl = &next
+	}
+	// Saved even when ctx has ended, so the progress isn't lost.
+	if saveErr := s.store.SaveSite(context.Background(), site); saveErr != nil {
+		return saveErr
+	}
+
+	s.logger.Info("Crawled site",
+		zap.String("site_id", site.ID),
+		zap.String("status", string(site.Status)),
+		zap.Int("fetched", stats.Fetched),
+		zap.Int("ingested", stats.Ingested),
+		zap.Int("unchanged", stats.Unchanged),
+		zap.Int("removed", stats.Removed),
+		zap.Int("skipped", stats.Skipped),
+		zap.Int("failed", stats.Failed))
+	return err
+}
+
+// visit brings the index in line with one fetched page.
+func (s *service) visit(ctx context.Context, site *Site, pages map[string]*Page, reached map[string]bool, p *crawler.Page, fetchErr error) {
+	stats := site.LastCrawl
+	page := pages[p.URL]
+	if page == nil {
+		page = &Page{URL: p.URL}
+	}
+	page.FetchedAt = time.Now().UTC()
+
+	// Pages that are gone or mustn't be indexed leave the index now.
+	if errors.Is(fetchErr, crawler.ErrNotFound) || errors.Is(fetchErr, crawler.ErrDisallowed) || (fetchErr == nil && p.NoIndex) {
+		if page.DocumentID == "" {
+			stats.Skipped++
+		} else if err := s.removeDocument(ctx, site, page); err != nil {
+			s.logger.Warn("Failed to remove page", zap.String("url", p.URL), zap.Error(err))
+			reached[p.URL] = true
+			return
+		} else {
+			stats.Removed++
+		}
+		delete(pages, p.URL)
+		return
+	}
+
+	reached[p.URL] = true
+	pages[p.URL] = page
+	switch {
+	case fetchErr != nil:
+		// Kept as they were: the failure may well be temporary.
+		stats.Failed++
+		page.Error = fetchErr.Error()
+	case p.NotModified:
+		stats.Unchanged++
+		page.Error = ""
+	default:
+		doc, err := s.documents.Ingest(ctx, &documents.Upload{
+			Name:       p.URL,
+			Source:     p.URL,
+			URL:        p.URL,
+			MIMEType:   p.ContentType,
+			Data:       p.Data,
+			Collection: site.Collection,
+		})
+		if err != nil {
+			stats.Failed++
+			page.Error = err.Error()
+			if ctx.Err() == nil {
+				s.logger.Warn("Failed to ingest page", zap.String("url", p.URL), zap.Error(err))
+			}
+			return
+		}
+		if doc.Unchanged {
+			stats.Unchanged++
+		} else {
+			stats.Ingested++
+		}
+		page.DocumentID = doc.ID
+		page.Validators = p.Validators
+		page.Links = p.Links
+		page.Error = ""
+	}
+}
+
+// removeDocument removes the document of a page from the index.
+func (s *service) removeDocument(ctx context.Context, site *Site, page *Page) error {
+	if page.DocumentID == "" {
+		return nil
+	}
+	err := s.documents.Delete(ctx, site.Collection, page.DocumentID)
+	if err != nil && !errors.Is(err, documents.ErrDocumentNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (s *service) isCrawling(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.crawling[id]
+}
+
+// poke wakes the background loop without blocking.
+func (s *service) poke() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func sortedPages(pages map[string]*Page) []*Page {
+	out := make([]*Page, 0, len(pages))
+	for _, page := range pages {
+		out = append(out, page)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].URL < out[j].URL })
+	return out
+}
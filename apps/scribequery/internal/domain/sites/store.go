@@ -0,0 +1,170 @@
+package sites
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// InMemoryStore keeps sites in process memory; they are lost on restart.
+type InMemoryStore struct {
+	mu    sync.RWMutex
+	sites map[string][]byte // encoded, so callers can't mutate a stored site
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{sites: make(map[string][]byte)}
+}
+
+func (s *InMemoryStore) SaveSite(ctx context.Context, site *Site) error {
+	data, err := json.Marshal(site)
+	if err != nil {
+		return fmt.Errorf("failed to encode site %s: %w", site.ID, err)
+	}
+	s.mu.Lock()
+	s.sites[site.ID] = data
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *InMemoryStore) LoadSite(ctx context.Context, id string) (*Site, error) {
+	s.mu.RLock()
+	data, ok := s.sites[id]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrSiteNotFound
+	}
+	return decodeSite(id, data)
+}
+
+func (s *InMemoryStore) ListSites(ctx context.Context) ([]*Site, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sites := make([]*Site, 0, len(s.sites))
+	for id, data := range s.sites {
+		site, err := decodeSite(id, data)
+		if err != nil {
+			return nil, err
+		}
+		sites = append(sites, site)
+	}
+	return sites, nil
+}
+
+func (s *InMemoryStore) DeleteSite(ctx context.Context, id string) error {
+	s.mu.Lock()
+	delete(s.sites, id)
+	s.mu.Unlock()
+	return nil
+}
+
+func decodeSite(id string, data []byte) (*Site, error) {
+	var site Site
+	if err := json.Unmarshal(data, &site); err != nil {
+		return nil, fmt.Errorf("failed to decode site %s: %w", id, err)
+	}
+	return &site, nil
+}
+
+// FileStore keeps each site in its own JSON file, so sites and what their
+// pages were last fetched with survive restarts.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates dir if needed and returns a store backed by it.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create sites directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) SaveSite(ctx context.Context, site *Site) error {
+	data, err := json.Marshal(site)
+	if err != nil {
+		return fmt.Errorf("failed to encode site %s: %w", site.ID, err)
+	}
+	if err := s.write(site.ID, data); err != nil {
+		return fmt.Errorf("failed to save site %s: %w", site.ID, err)
+	}
+	return nil
+}
+
+func (s *FileStore) LoadSite(ctx context.Context, id string) (*Site, error) {
+	if !validSiteID(id) {
+		return nil, ErrSiteNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, id+".json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrSiteNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read site %s: %w", id, err)
+	}
+	return decodeSite(id, data)
+}
+
+func (s *FileStore) ListSites(ctx context.Context) ([]*Site, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sites: %w", err)
+	}
+	var sites []*Site
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		site, err := s.LoadSite(ctx, id)
+		if errors.Is(err, ErrSiteNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sites = append(sites, site)
+	}
+	return sites, nil
+}
+
+func (s *FileStore) DeleteSite(ctx context.Context, id string) error {
+	if !validSiteID(id) {
+		return nil
+	}
+	err := os.Remove(filepath.Join(s.dir, id+".json"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete site %s: %w", id, err)
+	}
+	return nil
+}
+
+// write replaces the file of a site atomically via a temporary file.
+func (s *FileStore) write(id string, data []byte) error {
+	tmp, err := os.CreateTemp(s.dir, id+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(s.dir, id+".json"))
+}
+
+// validSiteID keeps ids taken from requests from naming paths outside the
+// store.
+func validSiteID(id string) bool {
+	return id != "" && id != "." && id != ".." && filepath.Base(id) == id
+}
@@ -0,0 +1,157 @@
+package admin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/domain/models"
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/handlers"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai/cache"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// Handler serves operator endpoints. They are only registered when
+// ADMIN_TOKEN is set, and every request must carry it as a bearer token.
+type Handler struct {
+	models     models.Service
+	chatCache  *cache.Cache
+	embedCache *cache.Cache
+	env        *handlers.Environment
+}
+
+type modelRequest struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+}
+
+func (h *Handler) Init(basePath string, env *handlers.Environment) error {
+	h.env = env
+	h.models = env.Services.ModelService
+	h.chatCache = env.Services.ChatCache
+	h.embedCache = env.Services.EmbeddingCache
+
+	if env.Config.AdminToken == "" {
+		env.Logger.Info("ADMIN_TOKEN not set, admin endpoints are disabled")
+		return nil
+	}
+
+	group := env.Fiber.Group(basePath+"/admin", h.authorize)
+
+	group.Get("/models/running", h.runningModels)
+	group.Get("/models/ensure", h.preparedModels)
+	group.Get("/models/show", h.showModel)
+	group.Post("/models/pull", h.pullModel)
+	group.Delete("/models", h.deleteModel)
+	group.Get("/cache", h.cacheStats)
+
+	return nil
+}
+
+func (h *Handler) authorize(c *fiber.Ctx) error {
+	if caller, ok := handlers.Authenticate(c, h.env.Config); !ok || !caller.Admin {
+		return handlers.Unauthorized(c)
+	}
+	return c.Next()
+}
+
+// cacheStats reports the hit and miss counts of the response caches since
+// boot.
+func (h *Handler) cacheStats(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"chat":       h.chatCache.Stats(),
+		"embeddings": h.embedCache.Stats(),
+	})
+}
+
+func (h *Handler) runningModels(c *fiber.Ctx) error {
+	running, err := h.models.Running(c.Context(), c.Query("provider"))
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"models": running})
+}
+
+// preparedModels reports the progress of the ENSURE_MODELS pulls started at
+// boot.
+func (h *Handler) preparedModels(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"models": h.models.Preparations()})
+}
+
+func (h *Handler) showModel(c *fiber.Ctx) error {
+	detail, err := h.models.Show(c.Context(), c.Query("provider"), c.Query("model"))
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(detail)
+}
+
+func (h *Handler) deleteModel(c *fiber.Ctx) error {
+	if err := h.models.Delete(c.Context(), c.Query("provider"), c.Query("model")); err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// pullModel streams pull progress as server-sent events.
+func (h *Handler) pullModel(c *fiber.Ctx) error {
+	var request modelRequest
+	if err := c.BodyParser(&request); err != nil {
+		return handlers.BadRequest(c, "Invalid request body")
+	}
+	if request.Model == "" {
+		return h.errorResponse(c, models.ErrModelRequired)
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("Transfer-Encoding", "chunked")
+
+	handlers.StreamBody(c, func(w *bufio.Writer) {
+		ctx := context.Background()
+
+		err := h.models.Pull(ctx, request.Provider, request.Model, func(progress ai.PullProgress) error {
+			data, err := json.Marshal(progress)
+			if err != nil {
+				return err
+			}
+
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return err
+			}
+
+			return w.Flush()
+		})
+
+		if err != nil {
+			h.env.Logger.Error("Model pull failed", zap.String("model", request.Model), zap.Error(err))
+			_, code, message := handlers.ErrorStatus(err, "Failed to pull model")
+			errData, _ := json.Marshal(fiber.Map{"error": message, "code": code})
+			fmt.Fprintf(w, "event: error\ndata: %s\n\n", errData)
+			w.Flush()
+		}
+
+		fmt.Fprintf(w, "data: [DONE]\n\n")
+		w.Flush()
+	})
+
+	return nil
+}
+
+// errorResponse maps model management errors onto an HTTP response.
+func (h *Handler) errorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, models.ErrUnknownProvider):
+		return handlers.NotFound(c, err.Error())
+	case errors.Is(err, models.ErrNotManaged), errors.Is(err, models.ErrModelRequired):
+		return handlers.BadRequest(c, err.Error())
+	}
+
+	h.env.Logger.Error("Model management request failed", zap.Error(err))
+	return handlers.ErrorResponse(c, err, "Model management request failed")
+}
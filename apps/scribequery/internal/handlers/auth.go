@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/auth"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	ErrUnauthenticated = errors.New("missing or invalid credentials")
+	ErrForbidden       = errors.New("not allowed to act for this user")
+)
+
+// Caller is who sent a request, from its bearer token: a user, with a token
+// signed with USER_TOKEN_SECRET, or the operator, with ADMIN_TOKEN.
+type Caller struct {
+	UserID string
+	Admin  bool
+}
+
+// Authenticate identifies the caller of c. It returns false when the request
+// carries no valid token.
+func Authenticate(c *fiber.Ctx, cfg *config.Config) (Caller, bool) {
+	token, found := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	if !found || token == "" {
+		return Caller{}, false
+	}
+	if cfg.AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(cfg.AdminToken)) == 1 {
+		return Caller{Admin: true}, true
+	}
+	if userID, ok := auth.Verify(cfg.UserTokenSecret, token); ok {
+		return Caller{UserID: userID}, true
+	}
+	return Caller{}, false
+}
+
+// AuthorizeUser checks that the caller of c may read and change what is kept
+// about userID: it must be that user or the operator.
+func AuthorizeUser(c *fiber.Ctx, cfg *config.Config, userID string) error {
+	caller, ok := Authenticate(c, cfg)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if !caller.Admin && caller.UserID != userID {
+		return ErrForbidden
+	}
+	return nil
+}
@@ -4,14 +4,24 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
 
 	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/domain/chat"
 	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/handlers"
 	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"github.com/Joepolymath/DaVinci/memory"
 	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
 )
 
+var errInvalidBody = errors.New("invalid request body")
+
 type Handler struct {
 	service chat.Service
 	env     *handlers.Environment
@@ -25,47 +35,63 @@ func (h *Handler) Init(basePath string, env *handlers.Environment) error {
 
 	group.Post("/", h.chat)
 	group.Post("/stream", h.chatStream)
+	group.Get("/:id", h.history)
+	group.Delete("/:id", h.forget)
 
 	return nil
 }
 
 func (h *Handler) chat(c *fiber.Ctx) error {
-	var request ai.Message
-	if err := c.BodyParser(&request); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	request, session, err := h.parseRequest(c)
+	if err != nil {
+		return h.errorResponse(c, err)
 	}
 
-	response, err := h.service.Chat(c.Context(), []ai.Message{request})
+	response, err := h.service.Chat(c.Context(), session, []ai.Message{request.Message}, request.Options())
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to chat",
-		})
+		return h.errorResponse(c, err)
 	}
 
 	return c.JSON(response)
 }
 
 func (h *Handler) chatStream(c *fiber.Ctx) error {
-	var request ai.Message
-	if err := c.BodyParser(&request); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	request, session, err := h.parseRequest(c)
+	if err != nil {
+		return h.errorResponse(c, err)
 	}
 
-	messages := []ai.Message{request}
+	messages := []ai.Message{request.Message}
+	opts := request.Options()
+	if err := chat.ValidateMessages(messages); err != nil {
+		return h.errorResponse(c, err)
+	}
+	if request.ConversationID != "" {
+		if err := memory.ValidateID(request.ConversationID); err != nil {
+			return h.errorResponse(c, err)
+		}
+		// Check ownership before the stream starts, while an error can still
+		// get its own status code.
+		_, err := h.service.History(c.Context(), session)
+		if err != nil && !errors.Is(err, memory.ErrNotFound) && !errors.Is(err, chat.ErrMemoryDisabled) {
+			return h.errorResponse(c, err)
+		}
+	}
+	if request.UserID != "" {
+		if err := memory.ValidateUserID(request.UserID); err != nil {
+			return h.errorResponse(c, err)
+		}
+	}
 
 	c.Set("Content-Type", "text/event-stream")
 	c.Set("Cache-Control", "no-cache")
 	c.Set("Connection", "keep-alive")
 	c.Set("Transfer-Encoding", "chunked")
 
-	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+	handlers.StreamBody(c, func(w *bufio.Writer) {
 		ctx := context.Background()
 
-		err := h.service.ChatStream(ctx, messages, func(delta ai.ChatStreamDelta) error {
+		err := h.service.ChatStream(ctx, session, messages, opts, func(delta ai.ChatStreamDelta) error {
 			data, err := json.Marshal(delta)
 			if err != nil {
 				return err
@@ -79,7 +105,9 @@ func (h *Handler) chatStream(c *fiber.Ctx) error {
 		})
 
 		if err != nil {
-			errData, _ := json.Marshal(fiber.Map{"error": err.Error()})
+			h.env.Logger.Error("Chat stream failed", zap.Error(err))
+			_, code, message := handlers.ErrorStatus(err, "Failed to chat")
+			errData, _ := json.Marshal(fiber.Map{"error": message, "code": code})
 			fmt.Fprintf(w, "event: error\ndata: %s\n\n", errData)
 			w.Flush()
 		}
@@ -90,3 +118,139 @@ func (h *Handler) chatStream(c *fiber.Ctx) error {
 
 	return nil
 }
+
+func (h *Handler) history(c *fiber.Ctx) error {
+	conversation, err := h.service.History(c.Context(), h.conversation(c))
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+
+	return c.JSON(conversation)
+}
+
+func (h *Handler) forget(c *fiber.Ctx) error {
+	if err := h.service.Forget(c.Context(), h.conversation(c)); err != nil {
+		return h.errorResponse(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// conversation is the session for the conversation named in the path, as
+// the caller: a user may only read and forget their own conversations.
+func (h *Handler) conversation(c *fiber.Ctx) chat.Session {
+	caller, _ := handlers.Authenticate(c, h.env.Config)
+	return chat.Session{ConversationID: c.Params("id"), UserID: caller.UserID, Admin: caller.Admin}
+}
+
+// parseRequest reads a chat request with readRequest and checks its user: a
+// user_id must be the caller's own, unless the caller is the operator, and a
+// caller with a user token chats as that user when the request names none.
+func (h *Handler) parseRequest(c *fiber.Ctx) (chat.Request, chat.Session, error) {
+	request, err := readRequest(c)
+	if err != nil {
+		return request, chat.Session{}, err
+	}
+	caller, _ := handlers.Authenticate(c, h.env.Config)
+	if request.UserID == "" {
+		request.UserID = caller.UserID
+	} else if err := handlers.AuthorizeUser(c, h.env.Config, request.UserID); err != nil {
+		return request, chat.Session{}, err
+	}
+	session := request.Session()
+	session.Admin = caller.Admin
+	return request, session, nil
+}
+
+// readRequest reads a chat request from a JSON body, or from a multipart
+// form with "content", optional "role", "model", "conversation_id" and
+// "user_id" fields and image files
+// under "images".
+func readRequest(c *fiber.Ctx) (chat.Request, error) {
+	var request chat.Request
+	if !strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		if err := c.BodyParser(&request); err != nil {
+			return request, errInvalidBody
+		}
+		return request, nil
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return request, errInvalidBody
+	}
+
+	request.Role = formValue(form, "role")
+	if request.Role == "" {
+		request.Role = ai.RoleUser
+	}
+	request.Content = formValue(form, "content")
+	request.Model = formValue(form, "model")
+	request.ConversationID = formValue(form, "conversation_id")
+	request.UserID = formValue(form, "user_id")
+
+	files := form.File["images"]
+	if len(files) > chat.MaxImages {
+		return request, chat.ErrTooManyImages
+	}
+	for _, file := range files {
+		if file.Size > chat.MaxImageBytes {
+			return request, chat.ErrImageTooLarge
+		}
+		data, err := readFile(file)
+		if err != nil {
+			return request, errInvalidBody
+		}
+
+		mimeType, _, _ := mime.ParseMediaType(file.Header.Get(fiber.HeaderContentType))
+		if !strings.HasPrefix(mimeType, "image/") {
+			mimeType = http.DetectContentType(data)
+		}
+		request.Parts = append(request.Parts, ai.ImagePart(data, mimeType))
+	}
+	return request, nil
+}
+
+func formValue(form *multipart.Form, key string) string {
+	if values := form.Value[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func readFile(header *multipart.FileHeader) ([]byte, error) {
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// errorResponse maps chat domain and AI provider errors onto an HTTP response.
+func (h *Handler) errorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, errInvalidBody):
+		return handlers.BadRequest(c, "Invalid request body")
+	case errors.Is(err, handlers.ErrUnauthenticated):
+		return handlers.Unauthorized(c)
+	case errors.Is(err, handlers.ErrForbidden):
+		return handlers.Forbidden(c)
+	case errors.Is(err, chat.ErrNoMessages), errors.Is(err, chat.ErrEmptyMessage),
+		errors.Is(err, chat.ErrTooManyImages), errors.Is(err, chat.ErrImageTooLarge),
+		errors.Is(err, chat.ErrUnsupportedImage):
+		return handlers.BadRequest(c, err.Error())
+	case errors.Is(err, memory.ErrInvalidID), errors.Is(err, memory.ErrInvalidUserID):
+		return handlers.BadRequest(c, err.Error())
+	case errors.Is(err, memory.ErrNotFound), errors.Is(err, chat.ErrMemoryDisabled):
+		return handlers.NotFound(c, err.Error())
+	case errors.Is(err, memory.ErrNotOwner):
+		if _, ok := handlers.Authenticate(c, h.env.Config); !ok {
+			return handlers.Unauthorized(c)
+		}
+		return handlers.Forbidden(c)
+	}
+
+	h.env.Logger.Error("Chat request failed", zap.Error(err))
+	return handlers.ErrorResponse(c, err, "Failed to chat")
+}
@@ -0,0 +1,185 @@
+package documents
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/domain/documents"
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/handlers"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/db/vector/local"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/loaders"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/retrieval"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	service documents.Service
+	env     *handlers.Environment
+}
+
+func (h *Handler) Init(basePath string, env *handlers.Environment) error {
+	h.env = env
+	h.service = env.Services.DocumentService
+
+	group := env.Fiber.Group(basePath + "/documents")
+
+	group.Post("/", h.upload)
+
+	jobs := env.Fiber.Group(basePath + "/ingest/jobs")
+
+	jobs.Get("/:id", h.job)
+	jobs.Get("/:id/events", h.jobEvents)
+
+	return nil
+}
+
+// keepAliveInterval is how often an idle progress stream sends a comment,
+// which also notices clients that have gone away.
+const keepAliveInterval = 15 * time.Second
+
+// upload queues a multipart form with the document under "file", an
+// optional "collection" and an optional "source" for ingestion, answering
+// with the job. An upload with the source of a document already in the
+// collection replaces it; without one, it is always a new document. The
+// loader is chosen from the file's content type and extension.
+func (h *Handler) upload(c *fiber.Ctx) error {
+	header, err := c.FormFile("file")
+	if err != nil {
+		return h.errorResponse(c, documents.ErrNoFile)
+	}
+	if header.Size > documents.MaxUploadBytes {
+		return h.errorResponse(c, documents.ErrFileTooLarge)
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return handlers.BadRequest(c, "Invalid request body")
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return handlers.BadRequest(c, "Invalid request body")
+	}
+
+	job, err := h.service.Submit(c.Context(), &documents.Upload{
+		Name:       header.Filename,
+		MIMEType:   header.Header.Get(fiber.HeaderContentType),
+		Data:       data,
+		Collection: c.FormValue("collection"),
+		Source:     c.FormValue("source"),
+	})
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(job)
+}
+
+func (h *Handler) job(c *fiber.Ctx) error {
+	job, err := h.service.Job(c.Context(), c.Params("id"))
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.JSON(job)
+}
+
+// jobEvents streams the state of a job as server-sent events, one per
+// change, until it succeeds or fails.
+func (h *Handler) jobEvents(c *fiber.Ctx) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	updates, err := h.service.Watch(ctx, c.Params("id"))
+	if err != nil {
+		cancel()
+		return h.errorResponse(c, err)
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("Transfer-Encoding", "chunked")
+
+	handlers.StreamBody(c, func(w *bufio.Writer) {
+		defer cancel()
+
+		keepAlive := time.NewTicker(keepAliveInterval)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case job, ok := <-updates:
+				if !ok {
+					fmt.Fprintf(w, "data: [DONE]\n\n")
+					w.Flush()
+					return
+				}
+				data, err := json.Marshal(job)
+				if err != nil {
+					return
+				}
+				if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+					return
+				}
+				if err := w.Flush(); err != nil {
+					return
+				}
+			case <-keepAlive.C:
+				if _, err := fmt.Fprintf(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+
+	return nil
+}
+
+func (h *Handler) errorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, documents.ErrNoFile), errors.Is(err, local.ErrInvalidCollectionName):
+		return handlers.BadRequest(c, err.Error())
+	case errors.Is(err, documents.ErrJobNotFound):
+		return handlers.NotFound(c, err.Error())
+	case errors.Is(err, documents.ErrFileTooLarge):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": err.Error(),
+			"code":  handlers.CodeInvalidRequest,
+		})
+	case errors.Is(err, loaders.ErrUnsupportedFormat):
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+			"error": err.Error(),
+			"code":  handlers.CodeInvalidRequest,
+		})
+	case errors.Is(err, documents.ErrUnreadableFile), errors.Is(err, loaders.ErrEmptyDocument):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+			"code":  handlers.CodeInvalidRequest,
+		})
+	case errors.Is(err, local.ErrDimensionMismatch):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Collection was built with another embedding model",
+			"code":  handlers.CodeInvalidRequest,
+		})
+	case errors.Is(err, documents.ErrQueueFull):
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"error": err.Error(),
+			"code":  handlers.CodeRateLimited,
+		})
+	case errors.Is(err, retrieval.ErrNoEmbedder):
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Ingestion needs an embedding provider",
+			"code":  handlers.CodeProviderUnavailable,
+		})
+	}
+
+	h.env.Logger.Error("Document request failed", zap.Error(err))
+	return handlers.ErrorResponse(c, err, "Failed to ingest document")
+}
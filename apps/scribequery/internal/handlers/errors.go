@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Machine-readable error codes returned in the "code" field of error responses.
+const (
+	CodeInvalidRequest        = "invalid_request"
+	CodeUnauthorized          = "unauthorized"
+	CodeForbidden             = "forbidden"
+	CodeNotFound              = "not_found"
+	CodeRateLimited           = "rate_limited"
+	CodeContextLengthExceeded = "context_length_exceeded"
+	CodeContentFiltered       = "content_filtered"
+	CodeModelNotFound         = "model_not_found"
+	CodeProviderAuthFailed    = "provider_auth_failed"
+	CodeProviderQuota         = "provider_quota_exceeded"
+	CodeProviderUnavailable   = "provider_unavailable"
+	CodeTimeout               = "timeout"
+	CodeInternal              = "internal_error"
+)
+
+type errorMapping struct {
+	kind    error
+	status  int
+	code    string
+	message string
+}
+
+var errorMappings = []errorMapping{
+	{ai.ErrRateLimited, fiber.StatusTooManyRequests, CodeRateLimited, "AI provider rate limit reached, retry later"},
+	{ai.ErrContextLengthExceeded, fiber.StatusRequestEntityTooLarge, CodeContextLengthExceeded, "Request exceeds the model's context window"},
+	{ai.ErrContentFiltered, fiber.StatusUnprocessableEntity, CodeContentFiltered, "Request was blocked by the provider's content filter"},
+	{ai.ErrModelNotFound, fiber.StatusNotFound, CodeModelNotFound, "Requested model is not available"},
+	{ai.ErrAuthFailed, fiber.StatusBadGateway, CodeProviderAuthFailed, "AI provider rejected our credentials"},
+	{ai.ErrQuotaExceeded, fiber.StatusBadGateway, CodeProviderQuota, "AI provider account is out of quota"},
+	{ai.ErrProviderUnavailable, fiber.StatusServiceUnavailable, CodeProviderUnavailable, "AI provider is unavailable"},
+	{ai.ErrInvalidRequest, fiber.StatusBadRequest, CodeInvalidRequest, "AI provider rejected the request"},
+	{context.DeadlineExceeded, fiber.StatusGatewayTimeout, CodeTimeout, "Request timed out"},
+}
+
+// ErrorStatus maps err to an HTTP status, a machine-readable code and a
+// client-facing message. fallback is the message used for unclassified errors.
+func ErrorStatus(err error, fallback string) (int, string, string) {
+	for _, m := range errorMappings {
+		if errors.Is(err, m.kind) {
+			return m.status, m.code, m.message
+		}
+	}
+	return fiber.StatusInternalServerError, CodeInternal, fallback
+}
+
+// ErrorResponse writes err as a JSON error body with the mapped status code.
+func ErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	status, code, message := ErrorStatus(err, fallback)
+	return c.Status(status).JSON(fiber.Map{
+		"error": message,
+		"code":  code,
+	})
+}
+
+// BadRequest writes a 400 response with the invalid_request code.
+func BadRequest(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": message,
+		"code":  CodeInvalidRequest,
+	})
+}
+
+// Unauthorized writes a 401 response with the unauthorized code.
+func Unauthorized(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"error": "Missing or invalid credentials",
+		"code":  CodeUnauthorized,
+	})
+}
+
+// Forbidden writes a 403 response with the forbidden code.
+func Forbidden(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"error": "Not allowed to access this resource",
+		"code":  CodeForbidden,
+	})
+}
+
+// NotFound writes a 404 response with the not_found code.
+func NotFound(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		"error": message,
+		"code":  CodeNotFound,
+	})
+}
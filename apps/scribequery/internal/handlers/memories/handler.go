@@ -0,0 +1,84 @@
+package memories
+
+import (
+	"errors"
+
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/domain/chat"
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/handlers"
+	"github.com/Joepolymath/DaVinci/memory"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// Handler exposes what is remembered about each user across conversations.
+// Each request must carry that user's token or ADMIN_TOKEN.
+type Handler struct {
+	service chat.Service
+	env     *handlers.Environment
+}
+
+func (h *Handler) Init(basePath string, env *handlers.Environment) error {
+	h.env = env
+	h.service = env.Services.ChatService
+
+	if env.Config.AdminToken == "" && env.Config.UserTokenSecret == "" {
+		env.Logger.Info("Neither ADMIN_TOKEN nor USER_TOKEN_SECRET set, memory endpoints are disabled")
+		return nil
+	}
+
+	group := env.Fiber.Group(basePath + "/users/:user/memories")
+
+	group.Get("/", h.authorize, h.list)
+	group.Delete("/", h.authorize, h.forgetAll)
+	group.Delete("/:id", h.authorize, h.forget)
+
+	return nil
+}
+
+func (h *Handler) authorize(c *fiber.Ctx) error {
+	if err := handlers.AuthorizeUser(c, h.env.Config, c.Params("user")); err != nil {
+		return h.errorResponse(c, err)
+	}
+	return c.Next()
+}
+
+func (h *Handler) list(c *fiber.Ctx) error {
+	facts, err := h.service.Facts(c.Context(), c.Params("user"))
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"memories": facts})
+}
+
+func (h *Handler) forget(c *fiber.Ctx) error {
+	if err := h.service.ForgetFact(c.Context(), c.Params("user"), c.Params("id")); err != nil {
+		return h.errorResponse(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) forgetAll(c *fiber.Ctx) error {
+	if err := h.service.ForgetFacts(c.Context(), c.Params("user")); err != nil {
+		return h.errorResponse(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) errorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, handlers.ErrUnauthenticated):
+		return handlers.Unauthorized(c)
+	case errors.Is(err, handlers.ErrForbidden):
+		return handlers.Forbidden(c)
+	case errors.Is(err, memory.ErrInvalidUserID):
+		return handlers.BadRequest(c, err.Error())
+	case errors.Is(err, memory.ErrFactNotFound), errors.Is(err, chat.ErrFactsDisabled):
+		return handlers.NotFound(c, err.Error())
+	}
+
+	h.env.Logger.Error("Memory request failed", zap.Error(err))
+	return handlers.ErrorResponse(c, err, "Failed to access memories")
+}
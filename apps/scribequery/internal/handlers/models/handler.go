@@ -0,0 +1,35 @@
+package models
+
+import (
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/domain/models"
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/handlers"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	service models.Service
+	env     *handlers.Environment
+}
+
+func (h *Handler) Init(basePath string, env *handlers.Environment) error {
+	h.env = env
+	h.service = env.Services.ModelService
+
+	group := env.Fiber.Group(basePath + "/models")
+
+	group.Get("/", h.list)
+
+	return nil
+}
+
+// list returns the model catalog. Pass ?refresh=true to re-query the providers.
+func (h *Handler) list(c *fiber.Ctx) error {
+	catalog, err := h.service.List(c.Context(), c.QueryBool("refresh"))
+	if err != nil {
+		h.env.Logger.Error("Failed to list models", zap.Error(err))
+		return handlers.ErrorResponse(c, err, "Failed to list models")
+	}
+
+	return c.JSON(catalog)
+}
@@ -0,0 +1,52 @@
+package prompts
+
+import (
+	"errors"
+
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/handlers"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/prompts"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	registry *prompts.Registry
+	env      *handlers.Environment
+}
+
+func (h *Handler) Init(basePath string, env *handlers.Environment) error {
+	h.env = env
+	h.registry = env.Services.Prompts
+
+	group := env.Fiber.Group(basePath + "/prompts")
+
+	group.Get("/", h.list)
+	group.Get("/:name", h.get)
+
+	return nil
+}
+
+// list returns every prompt template. Pass ?reload=true to re-read the
+// override directory first, e.g. after adding a new file.
+func (h *Handler) list(c *fiber.Ctx) error {
+	if c.QueryBool("reload") {
+		if err := h.registry.Reload(); err != nil {
+			h.env.Logger.Error("Failed to reload prompts", zap.Error(err))
+			return handlers.BadRequest(c, err.Error())
+		}
+	}
+
+	return c.JSON(fiber.Map{"prompts": h.registry.List()})
+}
+
+func (h *Handler) get(c *fiber.Ctx) error {
+	template, err := h.registry.Get(c.Params("name"))
+	if errors.Is(err, prompts.ErrNotFound) {
+		return handlers.NotFound(c, err.Error())
+	}
+	if err != nil {
+		return handlers.ErrorResponse(c, err, "Failed to load prompt")
+	}
+
+	return c.JSON(template)
+}
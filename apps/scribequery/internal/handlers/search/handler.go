@@ -0,0 +1,78 @@
+package search
+
+import (
+	"errors"
+
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/domain/search"
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/handlers"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/db/vector/local"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/retrieval"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	service search.Service
+	env     *handlers.Environment
+}
+
+func (h *Handler) Init(basePath string, env *handlers.Environment) error {
+	h.env = env
+	h.service = env.Services.SearchService
+
+	group := env.Fiber.Group(basePath + "/search")
+
+	group.Post("/", h.search)
+	group.Post("/answer", h.answer)
+
+	return nil
+}
+
+// search runs raw retrieval, which is handy to debug what the model would be
+// given for a question.
+func (h *Handler) search(c *fiber.Ctx) error {
+	var request retrieval.Request
+	if err := c.BodyParser(&request); err != nil {
+		return handlers.BadRequest(c, "Invalid request body")
+	}
+
+	response, err := h.service.Search(c.Context(), &request)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+
+	return c.JSON(response)
+}
+
+// answer answers a question from the retrieved chunks, citing them.
+func (h *Handler) answer(c *fiber.Ctx) error {
+	var request search.AnswerRequest
+	if err := c.BodyParser(&request); err != nil {
+		return handlers.BadRequest(c, "Invalid request body")
+	}
+
+	response, err := h.service.Answer(c.Context(), &request)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+
+	return c.JSON(response)
+}
+
+func (h *Handler) errorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, retrieval.ErrEmptyQuery), errors.Is(err, retrieval.ErrUnknownMode),
+		errors.Is(err, search.ErrTopKTooLarge):
+		return handlers.BadRequest(c, err.Error())
+	case errors.Is(err, local.ErrCollectionNotFound), errors.Is(err, search.ErrNoSources):
+		return handlers.NotFound(c, err.Error())
+	case errors.Is(err, retrieval.ErrNoEmbedder):
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Search needs an embedding provider",
+			"code":  handlers.CodeProviderUnavailable,
+		})
+	}
+
+	h.env.Logger.Error("Search failed", zap.Error(err))
+	return handlers.ErrorResponse(c, err, "Failed to search")
+}
@@ -0,0 +1,116 @@
+package sites
+
+import (
+	"errors"
+
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/domain/sites"
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/handlers"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/crawler"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/db/vector/local"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// Handler manages web pages and sites kept indexed. Crawls run in the
+// background; a site's status and last_crawl tell how they went. Adding,
+// refreshing and deleting sites makes the server fetch arbitrary URLs, so
+// those need ADMIN_TOKEN.
+type Handler struct {
+	service sites.Service
+	env     *handlers.Environment
+}
+
+func (h *Handler) Init(basePath string, env *handlers.Environment) error {
+	h.env = env
+	h.service = env.Services.SiteService
+
+	group := env.Fiber.Group(basePath + "/sites")
+
+	group.Get("/", h.list)
+	group.Get("/:id", h.get)
+
+	if env.Config.AdminToken == "" {
+		env.Logger.Info("ADMIN_TOKEN not set, adding, refreshing and deleting sites is disabled")
+		return nil
+	}
+	group.Post("/", h.authorize, h.add)
+	group.Post("/:id/refresh", h.authorize, h.refresh)
+	group.Delete("/:id", h.authorize, h.delete)
+
+	return nil
+}
+
+func (h *Handler) authorize(c *fiber.Ctx) error {
+	if caller, ok := handlers.Authenticate(c, h.env.Config); !ok || !caller.Admin {
+		return handlers.Unauthorized(c)
+	}
+	return c.Next()
+}
+
+func (h *Handler) add(c *fiber.Ctx) error {
+	var request sites.AddRequest
+	if err := c.BodyParser(&request); err != nil {
+		return handlers.BadRequest(c, "Invalid request body")
+	}
+
+	site, err := h.service.Add(c.Context(), &request)
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(site)
+}
+
+func (h *Handler) list(c *fiber.Ctx) error {
+	list, err := h.service.List(c.Context())
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"sites": list})
+}
+
+func (h *Handler) get(c *fiber.Ctx) error {
+	site, err := h.service.Get(c.Context(), c.Params("id"))
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+
+	return c.JSON(site)
+}
+
+func (h *Handler) refresh(c *fiber.Ctx) error {
+	site, err := h.service.Refresh(c.Context(), c.Params("id"))
+	if err != nil {
+		return h.errorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(site)
+}
+
+func (h *Handler) delete(c *fiber.Ctx) error {
+	if err := h.service.Delete(c.Context(), c.Params("id")); err != nil {
+		return h.errorResponse(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) errorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, crawler.ErrInvalidURL), errors.Is(err, sites.ErrInvalidMode),
+		errors.Is(err, sites.ErrInvalidLimit), errors.Is(err, sites.ErrInvalidRefresh),
+		errors.Is(err, local.ErrInvalidCollectionName):
+		return handlers.BadRequest(c, err.Error())
+	case errors.Is(err, sites.ErrSiteNotFound):
+		return handlers.NotFound(c, err.Error())
+	case errors.Is(err, sites.ErrSiteCrawling):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+			"code":  handlers.CodeInvalidRequest,
+		})
+	}
+
+	h.env.Logger.Error("Site request failed", zap.Error(err))
+	return handlers.ErrorResponse(c, err, "Failed to process site request")
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"bufio"
+	"net"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// StreamBody streams the response body of c with write. fasthttp sets the
+// connection's write deadline once, before the body, so a stream running
+// longer than the app's WriteTimeout would be cut off; StreamBody moves the
+// deadline forward on every flush instead, which bounds each write rather
+// than the whole stream.
+func StreamBody(c *fiber.Ctx, write func(w *bufio.Writer)) {
+	conn := c.Context().Conn()
+	timeout := c.App().Config().WriteTimeout
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if timeout <= 0 {
+			write(w)
+			return
+		}
+		write(bufio.NewWriter(&deadlineWriter{w: w, conn: conn, timeout: timeout}))
+	})
+}
+
+// deadlineWriter flushes every write to w through to the connection,
+// giving it timeout to complete.
+type deadlineWriter struct {
+	w       *bufio.Writer
+	conn    net.Conn
+	timeout time.Duration
+}
+
+func (d *deadlineWriter) Write(p []byte) (int, error) {
+	if err := d.conn.SetWriteDeadline(time.Now().Add(d.timeout)); err != nil {
+		return 0, err
+	}
+	n, err := d.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, d.w.Flush()
+}
@@ -19,6 +19,8 @@ func InitRouterWithConfig(cfg *config.Config) *fiber.App {
 		IdleTimeout:  5 * time.Second,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
+		// Large enough for a chat message with several image attachments.
+		BodyLimit: 32 << 20,
 	})
 
 	origins := cfg.ORIGINS
@@ -0,0 +1,103 @@
+package watch
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// fileState records what was last done with a watched file.
+type fileState struct {
+	Source     string    `json:"source"` // the name it was ingested under
+	Collection string    `json:"collection"`
+	DocumentID string    `json:"document_id,omitempty"`
+	Checksum   string    `json:"checksum,omitempty"`
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"mod_time"`
+	Error      string    `json:"error,omitempty"` // why it couldn't be ingested
+	SyncedAt   time.Time `json:"synced_at"`
+}
+
+// unchanged reports whether the file still has the size and modification
+// time it had when it was synced.
+func (f fileState) unchanged(info fs.FileInfo) bool {
+	return f.Size == info.Size() && f.ModTime.Equal(info.ModTime())
+}
+
+// state maps the paths of watched files to what was last done with them. It
+// is saved to a file, if it has one, after every change.
+type state struct {
+	path  string
+	retry []string             // files that failed for a reason that may pass, to sync again
+	Files map[string]fileState `json:"files"`
+}
+
+// loadState reads the state saved at path, starting afresh when there is
+// none. An empty path keeps it in memory.
+func loadState(path string) (*state, error) {
+	s := &state{path: path, Files: make(map[string]fileState)}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if s.Files == nil {
+		s.Files = make(map[string]fileState)
+	}
+	return s, nil
+}
+
+// under returns the paths recorded at or below path.
+func (s *state) under(path string) []string {
+	prefix := path + string(filepath.Separator)
+	var paths []string
+	for p := range s.Files {
+		if p == path || strings.HasPrefix(p, prefix) {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// save replaces the state file atomically via a hidden temporary file, which
+// the watcher ignores should the state live in a watched directory.
+func (s *state) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
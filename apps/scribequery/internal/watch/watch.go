@@ -0,0 +1,396 @@
+// Package watch keeps the index in line with watched folders: files are
+// ingested when they appear, re-ingested incrementally when they change and
+// removed from the index when they are deleted.
+package watch
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Joepolymath/DaVinci/apps/scribequery/internal/domain/documents"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/fswatch"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/loaders"
+	"go.uber.org/zap"
+)
+
+// ErrNoDirs is returned by Run when there is nothing to watch.
+var ErrNoDirs = errors.New("no directories to watch")
+
+// retryDelay is how long a file that failed to ingest for a reason that may
+// pass, such as the embedding provider being down, waits to be tried again.
+const retryDelay = time.Minute
+
+// Config tunes watching. Zero values use the defaults.
+type Config struct {
+	Dirs       []string      // watched when Run is given none
+	Collection string        // indexed into; empty uses the default collection
+	Debounce   time.Duration // quiet time before a changed path is synced; default 2s
+	StatePath  string        // file recording what was ingested; empty keeps it in memory
+}
+
+// Watcher ingests the files of watched directories.
+type Watcher struct {
+	documents documents.Service
+	loaders   *loaders.Registry
+	cfg       Config
+	logger    *zap.Logger
+}
+
+func New(service documents.Service, registry *loaders.Registry, cfg Config, logger *zap.Logger) *Watcher {
+	if cfg.Debounce <= 0 {
+		cfg.Debounce = 2 * time.Second
+	}
+	if cfg.StatePath != "" {
+		if abs, err := filepath.Abs(cfg.StatePath); err == nil {
+			cfg.StatePath = abs
+		}
+	}
+	return &Watcher{documents: service, loaders: registry, cfg: cfg, logger: logger}
+}
+
+// Run watches dirs, or the configured directories when none are given, until
+// ctx ends. It starts by syncing everything that changed since the state was
+// saved, so files added, modified or removed while nothing was watching are
+// picked up. Events on a path are debounced: it is synced once they have
+// stopped for the debounce time, so a file being copied is read only once it
+// is complete.
+func (w *Watcher) Run(ctx context.Context, dirs ...string) error {
+	if len(dirs) == 0 {
+		dirs = w.cfg.Dirs
+	}
+	if len(dirs) == 0 {
+		return ErrNoDirs
+	}
+	roots := make([]string, len(dirs))
+	for i, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		roots[i] = abs
+	}
+
+	st, err := loadState(w.cfg.StatePath)
+	if err != nil {
+		return err
+	}
+	events, err := fswatch.New()
+	if err != nil {
+		return err
+	}
+	defer events.Close()
+	// Watch before scanning, so nothing changing during the scan is missed.
+	for _, root := range roots {
+		if err := events.Add(root); err != nil {
+			return err
+		}
+	}
+
+	w.logger.Info("Watching directories for documents",
+		zap.Strings("dirs", roots),
+		zap.Duration("debounce", w.cfg.Debounce),
+		zap.String("state", w.cfg.StatePath))
+	for _, root := range roots {
+		w.sync(ctx, st, roots, root)
+	}
+
+	pending := make(map[string]time.Time)
+	timer := time.NewTimer(w.cfg.Debounce)
+	timer.Stop()
+	retryFailed(st, pending)
+	resetTimer(timer, pending)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case ev, ok := <-events.Events():
+			if !ok {
+				return events.Err()
+			}
+			deadline := time.Now().Add(w.cfg.Debounce)
+			if ev.Op == fswatch.Overflow {
+				w.logger.Warn("Missed file events, rescanning watched directories")
+				for _, root := range roots {
+					pending[root] = deadline
+				}
+			} else if w.watched(roots, ev.Path, ev.Dir) {
+				pending[ev.Path] = deadline
+			}
+			resetTimer(timer, pending)
+
+		case <-timer.C:
+			now := time.Now()
+			var due []string
+			for path, deadline := range pending {
+				if !deadline.After(now) {
+					due = append(due, path)
+				}
+			}
+			// Parents sort first, so a directory's files are synced with it.
+			sort.Strings(due)
+			for _, path := range due {
+				delete(pending, path)
+				w.sync(ctx, st, roots, path)
+			}
+			retryFailed(st, pending)
+			resetTimer(timer, pending)
+		}
+	}
+}
+
+// retryFailed schedules the files that failed to sync for a reason that may
+// pass to be synced again after retryDelay.
+func retryFailed(st *state, pending map[string]time.Time) {
+	deadline := time.Now().Add(retryDelay)
+	for _, path := range st.retry {
+		if _, ok := pending[path]; !ok {
+			pending[path] = deadline
+		}
+	}
+	st.retry = nil
+}
+
+// resetTimer sets timer to fire at the earliest pending deadline.
+func resetTimer(timer *time.Timer, pending map[string]time.Time) {
+	var next time.Time
+	for _, deadline := range pending {
+		if next.IsZero() || deadline.Before(next) {
+			next = deadline
+		}
+	}
+	if !next.IsZero() {
+		timer.Reset(time.Until(next))
+	}
+}
+
+// sync brings the index in line with path. A file is ingested if it changed
+// since it was last synced, a directory is synced file by file, and the
+// files recorded at or below a path that no longer exists are removed.
+func (w *Watcher) sync(ctx context.Context, st *state, roots []string, path string) {
+	if ctx.Err() != nil {
+		return
+	}
+	root := rootOf(roots, path)
+	info, err := os.Stat(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if path == root {
+			// An unmounted share shouldn't empty the index.
+			w.logger.Warn("Watched directory is gone, keeping its documents", zap.String("dir", path))
+			return
+		}
+		w.remove(ctx, st, path)
+	case err != nil:
+		w.logger.Warn("Failed to read watched path", zap.String("path", path), zap.Error(err))
+	case info.IsDir():
+		w.syncDir(ctx, st, roots, path)
+	case w.watched(roots, path, false):
+		w.syncFile(ctx, st, root, path, info)
+	}
+}
+
+// syncDir syncs the files below dir and removes those recorded there that
+// are gone.
+func (w *Watcher) syncDir(ctx context.Context, st *state, roots []string, dir string) {
+	root := rootOf(roots, dir)
+	seen := make(map[string]bool)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			w.logger.Warn("Failed to read watched path", zap.String("path", path), zap.Error(err))
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !w.watched(roots, path, false) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		seen[path] = true
+		w.syncFile(ctx, st, root, path, info)
+		return nil
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			w.logger.Warn("Failed to scan watched directory", zap.String("dir", dir), zap.Error(err))
+		}
+		return
+	}
+
+	for _, path := range st.under(dir) {
+		if !seen[path] {
+			w.remove(ctx, st, path)
+		}
+	}
+}
+
+// syncFile ingests a file unless its size and modification time show it
+// hasn't changed since it was last synced. Ingestion is incremental, so
+// only the chunks that changed are embedded again.
+func (w *Watcher) syncFile(ctx context.Context, st *state, root, path string, info fs.FileInfo) {
+	prev, known := st.Files[path]
+	if known && prev.unchanged(info) {
+		return
+	}
+	rel, err := filepath.Rel(filepath.Dir(root), path)
+	if err != nil {
+		return
+	}
+	entry := fileState{
+		Source:     filepath.ToSlash(rel),
+		Collection: w.cfg.Collection,
+		DocumentID: prev.DocumentID,
+		Size:       info.Size(),
+		ModTime:    info.ModTime(),
+		SyncedAt:   time.Now().UTC(),
+	}
+
+	doc, err := w.ingest(ctx, path, entry.Source, info)
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		w.logger.Warn("Failed to ingest watched file", zap.String("path", path), zap.Error(err))
+		if !permanent(err) {
+			// Left as it was and synced again after retryDelay.
+			st.retry = append(st.retry, path)
+			return
+		}
+		// Recorded so the file isn't retried until it changes.
+		entry.Error = err.Error()
+	} else {
+		entry.Collection = doc.Collection
+		entry.DocumentID = doc.ID
+		entry.Checksum = doc.Checksum
+		if !doc.Unchanged {
+			fields := []zap.Field{
+				zap.String("path", path),
+				zap.String("document_id", doc.ID),
+				zap.Int("chunks", doc.Chunks),
+			}
+			if c := doc.Changes; c != nil {
+				fields = append(fields, zap.Int("added", c.Added), zap.Int("removed", c.Removed), zap.Int("unchanged", c.Unchanged))
+			}
+			w.logger.Info("Ingested watched file", fields...)
+		}
+	}
+
+	st.Files[path] = entry
+	w.save(st)
+}
+
+// permanent reports whether ingesting a file failed because of the file
+// itself, so trying again before it changes would fail the same way.
+func permanent(err error) bool {
+	return errors.Is(err, documents.ErrUnreadableFile) || errors.Is(err, documents.ErrFileTooLarge) ||
+		errors.Is(err, documents.ErrNoFile) || errors.Is(err, loaders.ErrUnsupportedFormat) ||
+		errors.Is(err, loaders.ErrEmptyDocument)
+}
+
+func (w *Watcher) ingest(ctx context.Context, path, source string, info fs.FileInfo) (*documents.Document, error) {
+	if info.Size() > documents.MaxUploadBytes {
+		return nil, documents.ErrFileTooLarge
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return w.documents.Ingest(ctx, &documents.Upload{
+		Name:       source,
+		Source:     source,
+		Data:       data,
+		Collection: w.cfg.Collection,
+	})
+}
+
+// remove deletes the documents of the files recorded at or below path.
+// Entries whose document couldn't be deleted are kept to retry later.
+func (w *Watcher) remove(ctx context.Context, st *state, path string) {
+	paths := st.under(path)
+	if len(paths) == 0 {
+		return
+	}
+	for _, p := range paths {
+		entry := st.Files[p]
+		if entry.DocumentID != "" {
+			err := w.documents.Delete(ctx, entry.Collection, entry.DocumentID)
+			if err != nil && !errors.Is(err, documents.ErrDocumentNotFound) {
+				w.logger.Warn("Failed to remove document of deleted file",
+					zap.String("path", p),
+					zap.String("document_id", entry.DocumentID),
+					zap.Error(err))
+				continue
+			}
+			w.logger.Info("Removed document of deleted file",
+				zap.String("path", p),
+				zap.String("document_id", entry.DocumentID))
+		}
+		delete(st.Files, p)
+	}
+	w.save(st)
+}
+
+func (w *Watcher) save(st *state) {
+	if err := st.save(); err != nil {
+		w.logger.Error("Failed to save watch state", zap.String("path", st.path), zap.Error(err))
+	}
+}
+
+// watched reports whether path is below a root, outside hidden directories
+// and not hidden itself and, unless it is a directory, a file in a format a
+// loader reads.
+func (w *Watcher) watched(roots []string, path string, dir bool) bool {
+	root := rootOf(roots, path)
+	if root == "" {
+		return false
+	}
+	if path == root {
+		return true
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		if strings.HasPrefix(part, ".") {
+			return false
+		}
+	}
+	if dir {
+		return true
+	}
+	if path == w.cfg.StatePath {
+		return false
+	}
+	_, _, err = w.loaders.Select(loaders.Source{Name: filepath.Base(path)}, nil)
+	return err == nil
+}
+
+// rootOf returns the root path is at or below, or "" if none.
+func rootOf(roots []string, path string) string {
+	for _, root := range roots {
+		if path == root || strings.HasPrefix(path, root+string(filepath.Separator)) {
+			return root
+		}
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package chunking
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/embedding"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/tokenizer"
+)
+
+// New returns the chunker of cfg.Strategy, counting tokens with tok.
+// embedder is only used, and then required, by the semantic strategy.
+func New(cfg Config, tok tokenizer.Tokenizer, embedder embedding.Provider) (Chunker, error) {
+	cfg = cfg.withDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch cfg.Strategy {
+	case StrategyFixed:
+		return &fixed{tok: tok, maxTokens: cfg.MaxTokens, overlap: cfg.Overlap}, nil
+	case StrategyRecursive:
+		return newRecursive(tok, cfg.MaxTokens, textLevels), nil
+	case StrategyMarkdown:
+		return &markdown{
+			tok:       tok,
+			maxTokens: cfg.MaxTokens,
+			text:      newRecursive(tok, cfg.MaxTokens, textLevels),
+			code:      newRecursive(tok, cfg.MaxTokens, codeLevels),
+		}, nil
+	case StrategySemantic:
+		if embedder == nil {
+			return nil, ErrNoEmbedder
+		}
+		return &semantic{
+			tok:        tok,
+			maxTokens:  cfg.MaxTokens,
+			percentile: cfg.Percentile,
+			embedder:   embedder,
+			fallback:   newRecursive(tok, cfg.MaxTokens, textLevels),
+		}, nil
+	}
+	return nil, ErrUnknownStrategy
+}
+
+// ParseCollections parses a comma-separated list of collection=strategy
+// pairs, e.g. "api-docs=markdown,papers=semantic".
+func ParseCollections(spec string) (map[string]Strategy, error) {
+	strategies := make(map[string]Strategy)
+	for _, item := range strings.Split(spec, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		collection, name, found := strings.Cut(item, "=")
+		if collection = strings.TrimSpace(collection); !found || collection == "" {
+			return nil, fmt.Errorf("invalid collection chunking %q: expected collection=strategy", item)
+		}
+		strategy, err := ParseStrategy(name)
+		if err != nil {
+			return nil, fmt.Errorf("collection %q: %w", collection, err)
+		}
+		strategies[collection] = strategy
+	}
+	return strategies, nil
+}
+
+// span is a range of bytes of the text being split.
+type span struct {
+	start, end int
+}
+
+// appendChunk appends the text of s, without surrounding whitespace, unless
+// nothing is left of it.
+func appendChunk(chunks []Chunk, text string, s span) []Chunk {
+	s = trimSpan(text, s)
+	if s.start == s.end {
+		return chunks
+	}
+	return append(chunks, Chunk{Text: text[s.start:s.end], Start: s.start, End: s.end})
+}
+
+func trimSpan(text string, s span) span {
+	part := text[s.start:s.end]
+	trimmed := strings.TrimLeftFunc(part, unicode.IsSpace)
+	s.start += len(part) - len(trimmed)
+	s.end = s.start + len(strings.TrimRightFunc(trimmed, unicode.IsSpace))
+	return s
+}
+
+// cut splits s into pieces of at most maxTokens wherever they fall, for
+// text with nowhere better to break.
+func cut(chunks []Chunk, text string, s span, tok tokenizer.Tokenizer, maxTokens int) []Chunk {
+	for s.start < s.end {
+		n := len(tok.Truncate(text[s.start:s.end], maxTokens))
+		if n == 0 {
+			// A character worth more than maxTokens on its own.
+			_, n = utf8.DecodeRuneInString(text[s.start:s.end])
+		}
+		chunks = appendChunk(chunks, text, span{s.start, s.start + n})
+		s.start += n
+	}
+	return chunks
+}
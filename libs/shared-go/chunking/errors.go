@@ -0,0 +1,9 @@
+package chunking
+
+import "errors"
+
+var (
+	ErrUnknownStrategy = errors.New("unknown chunking strategy, expected fixed, recursive, markdown or semantic")
+	ErrInvalidOverlap  = errors.New("chunk overlap must be below the maximum chunk size")
+	ErrNoEmbedder      = errors.New("semantic chunking needs an embedding provider")
+)
@@ -0,0 +1,62 @@
+package chunking
+
+import (
+	"context"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/tokenizer"
+)
+
+// fixed slides a window of maxTokens over the text, each window starting
+// maxTokens-overlap tokens after the previous one. Windows end between
+// words when there is a space in their second half.
+type fixed struct {
+	tok       tokenizer.Tokenizer
+	maxTokens int
+	overlap   int
+}
+
+func (f *fixed) Split(ctx context.Context, text string) ([]Chunk, error) {
+	var chunks []Chunk
+	start := skipSpace(text, 0)
+	for start < len(text) {
+		end := start + len(f.tok.Truncate(text[start:], f.maxTokens))
+		if end == start {
+			_, n := utf8.DecodeRuneInString(text[start:])
+			end += n
+		}
+		if end < len(text) {
+			end = wordEnd(text, start, end)
+		}
+		chunks = appendChunk(chunks, text, span{start, end})
+		if end == len(text) {
+			break
+		}
+
+		next := end
+		if f.overlap > 0 {
+			next = wordEnd(text, start, start+len(f.tok.Truncate(text[start:end], f.maxTokens-f.overlap)))
+			if next <= start {
+				next = end
+			}
+		}
+		start = skipSpace(text, next)
+	}
+	return chunks, nil
+}
+
+// wordEnd moves end back to just after the last space of text[start:end],
+// unless that would lose more than half of it.
+func wordEnd(text string, start, end int) int {
+	if i := strings.LastIndexFunc(text[start:end], unicode.IsSpace); i >= 0 && i >= (end-start)/2 {
+		_, n := utf8.DecodeRuneInString(text[start+i:])
+		return start + i + n
+	}
+	return end
+}
+
+func skipSpace(text string, i int) int {
+	return len(text) - len(strings.TrimLeftFunc(text[i:], unicode.IsSpace))
+}
@@ -0,0 +1,11 @@
+package chunking
+
+import "context"
+
+// Chunker splits text into chunks of at most its configured number of
+// tokens.
+type Chunker interface {
+	// Split returns the chunks of text in order. Whitespace between chunks
+	// is left out, so their offsets may leave gaps.
+	Split(ctx context.Context, text string) ([]Chunk, error)
+}
@@ -0,0 +1,126 @@
+package chunking
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/tokenizer"
+)
+
+// markdown packs whole Markdown blocks - paragraphs, lists, tables, fenced
+// code - into chunks, starting a new chunk at every heading. A block too
+// large for a chunk is split on its own: code between lines, prose as the
+// recursive strategy does, so a fence is never split across chunks unless
+// the code alone doesn't fit.
+type markdown struct {
+	tok       tokenizer.Tokenizer
+	maxTokens int
+	text      *recursive
+	code      *recursive
+}
+
+// block is a run of Markdown lines that belong together.
+type block struct {
+	span
+	heading bool
+	code    bool
+}
+
+func (m *markdown) Split(ctx context.Context, text string) ([]Chunk, error) {
+	var chunks []Chunk
+	current := span{}
+	empty, headingOnly := true, false
+	flush := func() {
+		if !empty {
+			chunks = appendChunk(chunks, text, current)
+		}
+		empty, headingOnly = true, false
+	}
+
+	for _, b := range markdownBlocks(text) {
+		if b.heading {
+			flush()
+		}
+		if !empty && m.tok.Count(text[current.start:b.end]) <= m.maxTokens {
+			current.end = b.end
+			headingOnly = false
+			continue
+		}
+		if m.tok.Count(text[b.start:b.end]) <= m.maxTokens {
+			flush()
+			current, empty, headingOnly = b.span, false, b.heading
+			continue
+		}
+
+		splitter := m.text
+		if b.code {
+			splitter = m.code
+		}
+		parts := splitter.split(nil, text, b.span, 0)
+		if headingOnly && len(parts) > 0 && m.tok.Count(text[current.start:parts[0].End]) <= m.maxTokens {
+			// Keep the heading with the start of what's under it.
+			s := trimSpan(text, span{current.start, parts[0].End})
+			parts[0] = Chunk{Text: text[s.start:s.end], Start: s.start, End: s.end}
+			empty = true
+		}
+		flush()
+		chunks = append(chunks, parts...)
+	}
+	flush()
+	return chunks, nil
+}
+
+// markdownBlocks splits text into blocks at blank lines and headings,
+// keeping each fenced code block, blank lines included, as one block.
+func markdownBlocks(text string) []block {
+	var blocks []block
+	current := block{span: span{-1, -1}}
+	flush := func() {
+		if current.start >= 0 {
+			blocks = append(blocks, current)
+		}
+		current = block{span: span{-1, -1}}
+	}
+
+	fence := ""
+	for start := 0; start < len(text); {
+		end := len(text)
+		if i := strings.IndexByte(text[start:], '\n'); i >= 0 {
+			end = start + i + 1
+		}
+		line := strings.TrimSpace(text[start:end])
+
+		switch {
+		case fence != "":
+			current.end = end
+			if strings.HasPrefix(line, fence) && strings.Trim(line, fence[:1]) == "" {
+				fence = ""
+				flush()
+			}
+		case strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~"):
+			flush()
+			fence = line[:len(line)-len(strings.TrimLeft(line, line[:1]))]
+			current = block{span: span{start, end}, code: true}
+		case line == "":
+			flush()
+		case isHeading(line):
+			flush()
+			blocks = append(blocks, block{span: span{start, end}, heading: true})
+		default:
+			if current.start < 0 {
+				current.start = start
+			}
+			current.end = end
+		}
+		start = end
+	}
+	flush()
+	return blocks
+}
+
+// isHeading reports whether line is an ATX heading, "#" to "######"
+// followed by a space.
+func isHeading(line string) bool {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	return level >= 1 && level <= 6 && (len(line) == level || line[level] == ' ')
+}
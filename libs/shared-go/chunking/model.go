@@ -0,0 +1,76 @@
+package chunking
+
+import "strings"
+
+// Strategy is how text is split into chunks.
+type Strategy string
+
+const (
+	StrategyFixed     Strategy = "fixed"     // windows of MaxTokens, each sharing Overlap tokens with the one before
+	StrategyRecursive Strategy = "recursive" // at headings, else paragraphs, lines, sentences or words
+	StrategyMarkdown  Strategy = "markdown"  // at Markdown headings and blocks, never inside a code block
+	StrategySemantic  Strategy = "semantic"  // where the meaning of adjacent sentences drifts apart
+)
+
+// Strategies lists the strategies New accepts.
+var Strategies = []Strategy{StrategyFixed, StrategyRecursive, StrategyMarkdown, StrategySemantic}
+
+// Config tunes a Chunker. Zero values use the defaults.
+type Config struct {
+	Strategy  Strategy // default StrategyRecursive
+	MaxTokens int      // largest chunk; default 512
+	Overlap   int      // tokens repeated at the start of the next fixed window; must be below MaxTokens
+
+	// Percentile of the distances between adjacent sentences above which
+	// the semantic strategy starts a new chunk; default 95. Lower values
+	// make smaller chunks.
+	Percentile float64
+}
+
+func (c Config) withDefaults() Config {
+	if c.Strategy == "" {
+		c.Strategy = StrategyRecursive
+	}
+	if c.MaxTokens <= 0 {
+		c.MaxTokens = 512
+	}
+	if c.Overlap < 0 {
+		c.Overlap = 0
+	}
+	if c.Percentile <= 0 || c.Percentile > 100 {
+		c.Percentile = 95
+	}
+	return c
+}
+
+// Validate reports settings New would refuse, apart from a missing
+// embedder.
+func (c Config) Validate() error {
+	c = c.withDefaults()
+	if _, err := ParseStrategy(string(c.Strategy)); err != nil {
+		return err
+	}
+	if c.Overlap >= c.MaxTokens {
+		return ErrInvalidOverlap
+	}
+	return nil
+}
+
+// Chunk is a piece of the split text. Text is always text[Start:End], so
+// a chunk can be traced back to, and highlighted in, its source.
+type Chunk struct {
+	Text  string `json:"text"`
+	Start int    `json:"start"` // byte offset of the first byte
+	End   int    `json:"end"`   // byte offset just past the last byte
+}
+
+// ParseStrategy returns the strategy named s, case-insensitively.
+func ParseStrategy(s string) (Strategy, error) {
+	strategy := Strategy(strings.ToLower(strings.TrimSpace(s)))
+	for _, known := range Strategies {
+		if strategy == known {
+			return strategy, nil
+		}
+	}
+	return "", ErrUnknownStrategy
+}
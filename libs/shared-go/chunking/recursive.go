@@ -0,0 +1,113 @@
+package chunking
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/tokenizer"
+)
+
+// level is one kind of place text may be split at.
+type level struct {
+	separators []string
+	// before splits ahead of the separator, keeping it with the text that
+	// follows, as a heading belongs to what's under it.
+	before bool
+}
+
+// textLevels are where prose is split, from the most to the least natural.
+var textLevels = []level{
+	{separators: []string{"\n# "}, before: true},
+	{separators: []string{"\n## "}, before: true},
+	{separators: []string{"\n### ", "\n#### ", "\n##### ", "\n###### "}, before: true},
+	{separators: []string{"\n\n"}},
+	{separators: []string{"\n"}},
+	{separators: []string{". ", "? ", "! "}},
+	{separators: []string{" "}},
+}
+
+// codeLevels are where code is split: between blocks of lines, else lines,
+// else words.
+var codeLevels = []level{
+	{separators: []string{"\n\n"}},
+	{separators: []string{"\n"}},
+	{separators: []string{" "}},
+}
+
+// recursive splits text at the first level that yields pieces small enough,
+// going down a level only for the pieces that are still too large, and
+// packs as many adjacent pieces as fit into each chunk.
+type recursive struct {
+	tok       tokenizer.Tokenizer
+	maxTokens int
+	levels    []level
+}
+
+func newRecursive(tok tokenizer.Tokenizer, maxTokens int, levels []level) *recursive {
+	return &recursive{tok: tok, maxTokens: maxTokens, levels: levels}
+}
+
+func (r *recursive) Split(ctx context.Context, text string) ([]Chunk, error) {
+	return r.split(nil, text, span{0, len(text)}, 0), nil
+}
+
+func (r *recursive) split(chunks []Chunk, text string, s span, depth int) []Chunk {
+	if r.tok.Count(text[s.start:s.end]) <= r.maxTokens {
+		return appendChunk(chunks, text, s)
+	}
+	if depth == len(r.levels) {
+		return cut(chunks, text, s, r.tok, r.maxTokens)
+	}
+
+	current := span{s.start, s.start}
+	for _, unit := range units(text, s, r.levels[depth]) {
+		if r.tok.Count(text[current.start:unit.end]) <= r.maxTokens {
+			current.end = unit.end
+			continue
+		}
+		chunks = appendChunk(chunks, text, current)
+		if r.tok.Count(text[unit.start:unit.end]) <= r.maxTokens {
+			current = unit
+			continue
+		}
+		chunks = r.split(chunks, text, unit, depth+1)
+		current = span{unit.end, unit.end}
+	}
+	return appendChunk(chunks, text, current)
+}
+
+// units splits s at the separators of l into adjacent spans covering it.
+func units(text string, s span, l level) []span {
+	var spans []span
+	start := s.start
+	for i := s.start; i < s.end; {
+		n := separatorAt(text[i:s.end], l.separators)
+		if n == 0 {
+			i++
+			continue
+		}
+		at := i + n
+		if l.before {
+			at = i
+		}
+		if at > start {
+			spans = append(spans, span{start, at})
+			start = at
+		}
+		i += n
+	}
+	if start < s.end {
+		spans = append(spans, span{start, s.end})
+	}
+	return spans
+}
+
+// separatorAt returns the length of the separator text starts with, or 0.
+func separatorAt(text string, separators []string) int {
+	for _, sep := range separators {
+		if strings.HasPrefix(text, sep) {
+			return len(sep)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,103 @@
+package chunking
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/embedding"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/tokenizer"
+)
+
+// embedBatchSize is how many sentences are embedded per request.
+const embedBatchSize = 64
+
+// sentenceLevel is where text is split into sentences.
+var sentenceLevel = level{separators: []string{". ", "? ", "! ", "\n"}}
+
+// semantic embeds every sentence together with its neighbours and starts a
+// new chunk where the distance between adjacent sentences is above the
+// configured percentile of all such distances, which is where the topic
+// tends to change. Chunks still too large are split recursively. Each
+// sentence costs an embedding, so ingestion is slower and dearer than with
+// the other strategies.
+type semantic struct {
+	tok        tokenizer.Tokenizer
+	maxTokens  int
+	percentile float64
+	embedder   embedding.Provider
+	fallback   *recursive
+}
+
+func (c *semantic) Split(ctx context.Context, text string) ([]Chunk, error) {
+	var sentences []span
+	for _, s := range units(text, span{0, len(text)}, sentenceLevel) {
+		if s = trimSpan(text, s); s.start < s.end {
+			sentences = append(sentences, s)
+		}
+	}
+	if len(sentences) < 3 {
+		return c.fallback.Split(ctx, text)
+	}
+
+	// Single sentences embed noisily; a window of three smooths that out.
+	windows := make([]string, len(sentences))
+	for i := range sentences {
+		first, last := sentences[max(i-1, 0)], sentences[min(i+1, len(sentences)-1)]
+		windows[i] = text[first.start:last.end]
+	}
+	vectors := make([][]float32, 0, len(windows))
+	for start := 0; start < len(windows); start += embedBatchSize {
+		batch := windows[start:min(start+embedBatchSize, len(windows))]
+		embedded, err := c.embedder.CreateEmbeddings(ctx, batch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed sentences: %w", err)
+		}
+		if len(embedded) != len(batch) {
+			return nil, fmt.Errorf("failed to embed sentences: got %d embeddings for %d sentences", len(embedded), len(batch))
+		}
+		vectors = append(vectors, embedded...)
+	}
+
+	distances := make([]float64, len(sentences)-1)
+	for i := range distances {
+		distances[i] = 1 - cosine(vectors[i], vectors[i+1])
+	}
+	threshold := percentile(distances, c.percentile)
+
+	var chunks []Chunk
+	group := span{sentences[0].start, sentences[0].end}
+	for i, d := range distances {
+		if d > threshold {
+			chunks = c.fallback.split(chunks, text, group, 0)
+			group.start = sentences[i+1].start
+		}
+		group.end = sentences[i+1].end
+	}
+	return c.fallback.split(chunks, text, group, 0), nil
+}
+
+// percentile returns the p-th percentile (0-100) of values, interpolating
+// between the nearest ranks.
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := min(lower+1, len(sorted)-1)
+	return sorted[lower] + (rank-float64(lower))*(sorted[upper]-sorted[lower])
+}
+
+func cosine(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
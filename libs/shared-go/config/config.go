@@ -3,7 +3,10 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -44,10 +47,132 @@ func loadConfig() *Config {
 		ORIGINS:          os.Getenv("ORIGINS"),
 		OpenAIAPIKey:     os.Getenv("OPENAI_API_KEY"),
 		OpenAIModel:      os.Getenv("OPENAI_MODEL"),
+		OpenAIEmbedModel: os.Getenv("OPENAI_EMBEDDING_MODEL"),
 		LocalHost:        os.Getenv("LOCAL_HOST"),
 		LocalModel:       os.Getenv("LOCAL_MODEL"),
 		Provider:         os.Getenv("PROVIDER"),
+		Providers:        os.Getenv("PROVIDERS"),
+		ModelRoutes:      os.Getenv("MODEL_ROUTES"),
+		EnsureModels:     os.Getenv("ENSURE_MODELS"),
+		AdminToken:       os.Getenv("ADMIN_TOKEN"),
+		UserTokenSecret:  os.Getenv("USER_TOKEN_SECRET"),
+
+		EnsureModelsTimeout: getEnvDuration("ENSURE_MODELS_TIMEOUT"),
+
+		OpenAIRequestsPerMinute: getEnvInt("OPENAI_RPM"),
+		OpenAITokensPerMinute:   getEnvInt("OPENAI_TPM"),
+		LocalRequestsPerMinute:  getEnvInt("LOCAL_RPM"),
+		LocalTokensPerMinute:    getEnvInt("LOCAL_TPM"),
+		InteractiveReserve:      getEnvFloat("RATE_LIMIT_INTERACTIVE_RESERVE"),
+
+		AICacheMaxEntries: getEnvInt("AI_CACHE_MAX_ENTRIES"),
+		AICacheTTL:        getEnvDuration("AI_CACHE_TTL"),
+		AICacheDir:        os.Getenv("AI_CACHE_DIR"),
+
+		ModelCatalogRefresh: getEnvDuration("MODEL_CATALOG_REFRESH"),
+
+		ContextWindow:         getEnvInt("CONTEXT_WINDOW"),
+		ContextReservedOutput: getEnvInt("CONTEXT_RESERVED_OUTPUT"),
+		ContextSummarize:      getEnvBool("CONTEXT_SUMMARIZE"),
+		ContextSummaryModel:   os.Getenv("CONTEXT_SUMMARY_MODEL"),
+
+		PromptsDir: os.Getenv("PROMPTS_DIR"),
+
+		MemoryStrategy:  os.Getenv("MEMORY_STRATEGY"),
+		MemoryMaxTurns:  getEnvInt("MEMORY_MAX_TURNS"),
+		MemoryMaxTokens: getEnvInt("MEMORY_MAX_TOKENS"),
+		MemoryDir:       os.Getenv("MEMORY_DIR"),
+
+		MemoryFacts:          getEnvBool("MEMORY_FACTS"),
+		MemoryFactsModel:     os.Getenv("MEMORY_FACTS_MODEL"),
+		MemoryFactsRecall:    getEnvInt("MEMORY_FACTS_RECALL"),
+		MemoryFactsDedupe:    getEnvFloat("MEMORY_FACTS_DEDUPE"),
+		MemoryFactsThreshold: getEnvFloat("MEMORY_FACTS_THRESHOLD"),
+
+		VectorDir:        os.Getenv("VECTOR_DIR"),
+		VectorCollection: os.Getenv("VECTOR_COLLECTION"),
+
+		GraphStore:    os.Getenv("GRAPH_STORE"),
+		GraphModel:    os.Getenv("GRAPH_MODEL"),
+		GraphHops:     getEnvInt("GRAPH_HOPS"),
+		Neo4jURL:      os.Getenv("NEO4J_URL"),
+		Neo4jUsername: os.Getenv("NEO4J_USERNAME"),
+		Neo4jPassword: os.Getenv("NEO4J_PASSWORD"),
+		Neo4jDatabase: os.Getenv("NEO4J_DATABASE"),
+
+		ChunkTokens:   getEnvInt("CHUNK_TOKENS"),
+		IngestWorkers: getEnvInt("INGEST_WORKERS"),
+		IngestQueue:   getEnvInt("INGEST_QUEUE"),
+		JobsDir:       os.Getenv("JOBS_DIR"),
+
+		ChunkStrategy:    os.Getenv("CHUNK_STRATEGY"),
+		ChunkOverlap:     getEnvInt("CHUNK_OVERLAP"),
+		ChunkPercentile:  getEnvFloat("CHUNK_PERCENTILE"),
+		ChunkCollections: os.Getenv("CHUNK_COLLECTIONS"),
+
+		ParentTokens: getEnvInt("PARENT_TOKENS"),
+		ParentsDir:   os.Getenv("PARENTS_DIR"),
+
+		QueryMode:        os.Getenv("QUERY_MODE"),
+		QueryModel:       os.Getenv("QUERY_MODEL"),
+		QueryParaphrases: getEnvInt("QUERY_PARAPHRASES"),
+
+		Reranker:        os.Getenv("RERANKER"),
+		RerankURL:       os.Getenv("RERANK_URL"),
+		RerankModel:     os.Getenv("RERANK_MODEL"),
+		RerankAPIKey:    os.Getenv("RERANK_API_KEY"),
+		RerankOverFetch: getEnvInt("RERANK_OVERFETCH"),
+		RerankDepth:     getEnvInt("RERANK_DEPTH"),
+		RerankMinScore:  getEnvFloat("RERANK_MIN_SCORE"),
+
+		WatchDirs:       os.Getenv("WATCH_DIRS"),
+		WatchCollection: os.Getenv("WATCH_COLLECTION"),
+		WatchDebounce:   getEnvDuration("WATCH_DEBOUNCE"),
+		WatchState:      os.Getenv("WATCH_STATE"),
+
+		WebUserAgent: os.Getenv("WEB_USER_AGENT"),
+		WebDelay:     getEnvDuration("WEB_DELAY"),
+		SitesDir:     os.Getenv("SITES_DIR"),
+	}
+}
+
+// getEnvInt returns the integer value of key, or 0 if it is unset or invalid.
+func getEnvInt(key string) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("Ignoring invalid integer for %s: %q", key, v)
+		return 0
+	}
+	return n
+}
+
+// getEnvFloat returns the float value of key, or 0 if it is unset or invalid.
+func getEnvFloat(key string) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return 0
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Printf("Ignoring invalid number for %s: %q", key, v)
+		return 0
+	}
+	return f
+}
+
+// ProviderEnv returns PROVIDER_<NAME>_<KEY> for the chat provider called name
+// in PROVIDERS, e.g. PROVIDER_CHEAP_MODEL for "cheap=local", or fallback when
+// it is unset. The name is upper-cased, with '-' read as '_'.
+func (c *Config) ProviderEnv(name, key, fallback string) string {
+	env := "PROVIDER_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_" + key
+	if v := os.Getenv(env); v != "" {
+		return v
 	}
+	return fallback
 }
 
 func LoadConfig() (*Config, error) {
@@ -56,3 +181,33 @@ func LoadConfig() (*Config, error) {
 	})
 	return configInstance, nil
 }
+
+// getEnvDuration parses key as a Go duration (e.g. "24h"), or returns 0 if it
+// is unset or invalid.
+func getEnvDuration(key string) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("Ignoring invalid duration for %s: %q", key, v)
+		return 0
+	}
+	return d
+}
+
+// getEnvBool parses key as a boolean (e.g. "true", "1"), or returns false if
+// it is unset or invalid.
+func getEnvBool(key string) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Printf("Ignoring invalid boolean for %s: %q", key, v)
+		return false
+	}
+	return b
+}
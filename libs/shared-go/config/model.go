@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 type Config struct {
 	ScribeQueryPort  string `mapstructure:"SCRIBE_QUERY_PORT"`
 	WeaviateScheme   string `mapstructure:"WEAVIATE_SCHEME"`
@@ -9,7 +11,110 @@ type Config struct {
 	ORIGINS          string `mapstructure:"ORIGINS"`
 	OpenAIAPIKey     string `mapstructure:"OPENAI_API_KEY"`
 	OpenAIModel      string `mapstructure:"OPENAI_MODEL"`
+	OpenAIEmbedModel string `mapstructure:"OPENAI_EMBEDDING_MODEL"`
 	LocalHost        string `mapstructure:"LOCAL_HOST"`
 	LocalModel       string `mapstructure:"LOCAL_MODEL"`
 	Provider         string `mapstructure:"PROVIDER"`
+	Providers        string `mapstructure:"PROVIDERS"`     // e.g. "openai,cheap=local"; defaults to PROVIDER
+	ModelRoutes      string `mapstructure:"MODEL_ROUTES"`  // e.g. "gpt-*=openai,llama*=cheap"
+	EnsureModels     string `mapstructure:"ENSURE_MODELS"` // models to pull at startup, e.g. "llama3:8b,cheap=nomic-embed-text"
+	AdminToken       string `mapstructure:"ADMIN_TOKEN"`   // bearer token for /api/admin; empty disables the admin endpoints
+
+	EnsureModelsTimeout time.Duration `mapstructure:"ENSURE_MODELS_TIMEOUT"` // how long ENSURE_MODELS may pull for; 0 uses 30m
+
+	// Secret signing the bearer tokens that identify users. Without it only
+	// ADMIN_TOKEN may act for a user (user_id, /api/users/:user/memories).
+	UserTokenSecret string `mapstructure:"USER_TOKEN_SECRET"`
+
+	// Client-side rate limits per provider (0 disables the limit)
+	OpenAIRequestsPerMinute int     `mapstructure:"OPENAI_RPM"`
+	OpenAITokensPerMinute   int     `mapstructure:"OPENAI_TPM"`
+	LocalRequestsPerMinute  int     `mapstructure:"LOCAL_RPM"`
+	LocalTokensPerMinute    int     `mapstructure:"LOCAL_TPM"`
+	InteractiveReserve      float64 `mapstructure:"RATE_LIMIT_INTERACTIVE_RESERVE"`
+
+	// Completion and embedding response cache
+	AICacheMaxEntries int           `mapstructure:"AI_CACHE_MAX_ENTRIES"`
+	AICacheTTL        time.Duration `mapstructure:"AI_CACHE_TTL"`
+	AICacheDir        string        `mapstructure:"AI_CACHE_DIR"` // empty keeps the cache in memory only
+
+	// How long the aggregated model catalog is served before it is refreshed
+	ModelCatalogRefresh time.Duration `mapstructure:"MODEL_CATALOG_REFRESH"`
+
+	// Context window management for chat requests
+	ContextWindow         int    `mapstructure:"CONTEXT_WINDOW"`          // overrides the model's window; 0 uses the catalog
+	ContextReservedOutput int    `mapstructure:"CONTEXT_RESERVED_OUTPUT"` // tokens kept for the answer when max_tokens is unset
+	ContextSummarize      bool   `mapstructure:"CONTEXT_SUMMARIZE"`       // summarize dropped turns instead of discarding them
+	ContextSummaryModel   string `mapstructure:"CONTEXT_SUMMARY_MODEL"`   // model used for summaries; empty uses the default
+
+	// Directory of prompt template overrides (<name>.tmpl); empty uses the built-in prompts only
+	PromptsDir string `mapstructure:"PROMPTS_DIR"`
+
+	// Conversation memory for chat requests carrying a conversation_id
+	MemoryStrategy  string `mapstructure:"MEMORY_STRATEGY"`   // window, tokens, summary or none; empty uses tokens
+	MemoryMaxTurns  int    `mapstructure:"MEMORY_MAX_TURNS"`  // turns kept by the window strategy
+	MemoryMaxTokens int    `mapstructure:"MEMORY_MAX_TOKENS"` // tokens kept by the tokens and summary strategies
+	MemoryDir       string `mapstructure:"MEMORY_DIR"`        // empty keeps conversations in memory only
+
+	// Long-term memory of facts about users, for chat requests carrying a user_id
+	MemoryFacts          bool    `mapstructure:"MEMORY_FACTS"`           // requires an embedding provider
+	MemoryFactsModel     string  `mapstructure:"MEMORY_FACTS_MODEL"`     // model extracting facts; empty uses the default
+	MemoryFactsRecall    int     `mapstructure:"MEMORY_FACTS_RECALL"`    // facts recalled into the system prompt per turn
+	MemoryFactsDedupe    float64 `mapstructure:"MEMORY_FACTS_DEDUPE"`    // similarity above which a fact replaces a known one
+	MemoryFactsThreshold float64 `mapstructure:"MEMORY_FACTS_THRESHOLD"` // minimum similarity for a fact to be recalled
+
+	// Directory of the in-process vector store; empty keeps vectors in memory only
+	VectorDir        string `mapstructure:"VECTOR_DIR"`
+	VectorCollection string `mapstructure:"VECTOR_COLLECTION"` // collection searched by default
+	VectorReadOnly   bool   `mapstructure:"-"`                 // set by commands that only read, which may then share VECTOR_DIR
+
+	// Knowledge graph used to expand retrieval with connected chunks
+	GraphStore    string `mapstructure:"GRAPH_STORE"` // memory, neo4j or none; empty is none
+	GraphModel    string `mapstructure:"GRAPH_MODEL"` // model extracting entities; empty uses the default
+	GraphHops     int    `mapstructure:"GRAPH_HOPS"`  // relations followed from the question's entities
+	Neo4jURL      string `mapstructure:"NEO4J_URL"`   // HTTP endpoint, e.g. http://localhost:7474
+	Neo4jUsername string `mapstructure:"NEO4J_USERNAME"`
+	Neo4jPassword string `mapstructure:"NEO4J_PASSWORD"`
+	Neo4jDatabase string `mapstructure:"NEO4J_DATABASE"`
+
+	// Document ingestion, run as background jobs
+	ChunkTokens   int    `mapstructure:"CHUNK_TOKENS"`   // maximum tokens per chunk; 0 uses 512
+	IngestWorkers int    `mapstructure:"INGEST_WORKERS"` // jobs processed at once; 0 uses 2
+	IngestQueue   int    `mapstructure:"INGEST_QUEUE"`   // jobs waiting before uploads are refused; 0 uses 64
+	JobsDir       string `mapstructure:"JOBS_DIR"`       // empty keeps jobs in memory, so they don't survive restarts
+
+	// Chunking strategy: fixed, recursive, markdown or semantic
+	ChunkStrategy    string  `mapstructure:"CHUNK_STRATEGY"`    // empty uses recursive
+	ChunkOverlap     int     `mapstructure:"CHUNK_OVERLAP"`     // tokens shared by consecutive fixed windows
+	ChunkPercentile  float64 `mapstructure:"CHUNK_PERCENTILE"`  // semantic: distance percentile starting a new chunk; 0 uses 95
+	ChunkCollections string  `mapstructure:"CHUNK_COLLECTIONS"` // per-collection strategies, e.g. api-docs=markdown,papers=semantic
+
+	// Parent-document retrieval: chunks match, their parent sections are returned
+	ParentTokens int    `mapstructure:"PARENT_TOKENS"` // maximum tokens per parent section; 0 indexes chunks alone
+	ParentsDir   string `mapstructure:"PARENTS_DIR"`   // empty keeps parents in memory, so chunks are returned alone after a restart
+
+	// Query transformation before retrieval, selectable per request
+	QueryMode        string `mapstructure:"QUERY_MODE"`        // standard, multi_query, hyde or step_back; empty uses standard
+	QueryModel       string `mapstructure:"QUERY_MODEL"`       // model rewriting queries; empty uses the default
+	QueryParaphrases int    `mapstructure:"QUERY_PARAPHRASES"` // written in multi_query mode; 0 uses 3
+
+	// Reranking of retrieved chunks
+	Reranker        string  `mapstructure:"RERANKER"`         // llm, tei or cohere (also Jina); empty or none disables reranking
+	RerankURL       string  `mapstructure:"RERANK_URL"`       // scoring endpoint of tei and cohere, e.g. http://localhost:8080/rerank
+	RerankModel     string  `mapstructure:"RERANK_MODEL"`     // chat model for llm, model name for cohere; empty uses the default
+	RerankAPIKey    string  `mapstructure:"RERANK_API_KEY"`   // bearer token for the scoring endpoint, if it needs one
+	RerankOverFetch int     `mapstructure:"RERANK_OVERFETCH"` // candidates fetched per chunk returned; 0 uses 4
+	RerankDepth     int     `mapstructure:"RERANK_DEPTH"`     // most candidates reranked; 0 uses 40
+	RerankMinScore  float64 `mapstructure:"RERANK_MIN_SCORE"` // reranked chunks scoring below are dropped; 0 keeps them all
+
+	// Watched folders, whose files are ingested as they appear, change and go
+	WatchDirs       string        `mapstructure:"WATCH_DIRS"`       // comma-separated; empty disables watching
+	WatchCollection string        `mapstructure:"WATCH_COLLECTION"` // empty uses VECTOR_COLLECTION
+	WatchDebounce   time.Duration `mapstructure:"WATCH_DEBOUNCE"`   // quiet time before a changed file is ingested; 0 uses 2s
+	WatchState      string        `mapstructure:"WATCH_STATE"`      // file recording what was ingested; empty re-reads every file on start
+
+	// Web pages and sites, crawled politely and refreshed periodically
+	WebUserAgent string        `mapstructure:"WEB_USER_AGENT"` // also matched against robots.txt; empty uses ScribeQuery/1.0
+	WebDelay     time.Duration `mapstructure:"WEB_DELAY"`      // minimum time between requests to a host; 0 uses 1s
+	SitesDir     string        `mapstructure:"SITES_DIR"`      // empty keeps sites in memory, so they aren't refreshed after a restart
 }
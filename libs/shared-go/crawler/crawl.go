@@ -0,0 +1,74 @@
+package crawler
+
+import (
+	"context"
+	"net/url"
+)
+
+// Crawl fetches the pages reached from start, breadth first, and hands each
+// to visit with the error fetching it, if any; a page that couldn't be
+// fetched only has its URL and depth set. Links are only followed on the
+// host of start. Crawl stops at the first error visit returns, and returns
+// it.
+func (f *Fetcher) Crawl(ctx context.Context, start string, opts CrawlOptions, visit func(*Page, error) error) error {
+	opts = opts.withDefaults()
+	startURL, err := ParseURL(start)
+	if err != nil {
+		return err
+	}
+
+	type item struct {
+		url   string
+		depth int
+	}
+	var queue []item
+	if opts.Mode == ModeSitemap {
+		pages, err := f.Sitemap(ctx, startURL.String(), opts.MaxPages)
+		if err != nil {
+			return err
+		}
+		for _, page := range pages {
+			queue = append(queue, item{url: page})
+		}
+	} else {
+		queue = []item{{url: startURL.String()}}
+	}
+	seen := make(map[string]bool, len(queue))
+	for _, it := range queue {
+		seen[it.url] = true
+	}
+
+	for fetched := 0; len(queue) > 0 && fetched < opts.MaxPages; fetched++ {
+		it := queue[0]
+		queue = queue[1:]
+
+		known := opts.Known[it.url]
+		page, err := f.Fetch(ctx, it.url, known.Validators)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			page = &Page{URL: it.url}
+		} else if page.NotModified {
+			page.Links = known.Links
+		}
+		page.Depth = it.depth
+		seen[page.URL] = true
+		if err := visit(page, err); err != nil {
+			return err
+		}
+
+		if opts.Mode != ModeLinks || it.depth >= opts.MaxDepth {
+			continue
+		}
+		for _, link := range page.Links {
+			u, err := url.Parse(link)
+			if err != nil || u.Host != startURL.Host || seen[link] {
+				continue
+			}
+			seen[link] = true
+			queue = append(queue, item{url: link, depth: it.depth + 1})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+package crawler
+
+import "errors"
+
+var (
+	ErrInvalidURL = errors.New("URL must be absolute http or https")
+	ErrDisallowed = errors.New("disallowed by robots.txt")
+	ErrNotFound   = errors.New("page not found")
+	ErrTooLarge   = errors.New("response is too large")
+	ErrNoSitemap  = errors.New("no sitemap found")
+)
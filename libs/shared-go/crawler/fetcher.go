@@ -0,0 +1,221 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// robotsTTL is how long a host's robots.txt is trusted before it is
+// fetched again.
+const robotsTTL = 24 * time.Hour
+
+// maxRobotsBytes caps the robots.txt read; larger files are cut off.
+const maxRobotsBytes = 512 << 10
+
+// Fetcher fetches pages politely: robots.txt is honoured and requests to a
+// host are spaced out. It is safe for concurrent use, and requests to one
+// host wait for each other.
+type Fetcher struct {
+	cfg    Config
+	agent  string // product token of the user agent, matched in robots.txt
+	client *http.Client
+
+	mu    sync.Mutex
+	hosts map[string]*host
+}
+
+// host is the state kept per scheme and host.
+type host struct {
+	turn sync.Mutex // held while waiting for and sending a request
+	next time.Time  // earliest time of the next request
+
+	robots  *robots // guarded by Fetcher.mu
+	expires time.Time
+}
+
+func NewFetcher(cfg Config) *Fetcher {
+	cfg = cfg.withDefaults()
+	agent, _, _ := strings.Cut(cfg.UserAgent, "/")
+	return &Fetcher{
+		cfg:    cfg,
+		agent:  strings.ToLower(strings.TrimSpace(agent)),
+		client: &http.Client{Timeout: cfg.Timeout},
+		hosts:  make(map[string]*host),
+	}
+}
+
+// ParseURL checks that rawURL is an absolute http or https URL and returns
+// it normalized, without its fragment.
+func ParseURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidURL, rawURL)
+	}
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment, u.RawFragment = "", ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	return u, nil
+}
+
+// Fetch gets a page once robots.txt allows it and the host's turn has come.
+// Given the validators of an earlier fetch, an unchanged page comes back
+// NotModified. A page that is gone returns ErrNotFound.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string, prev Validators) (*Page, error) {
+	u, err := ParseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := f.robots(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if !rules.allowed(u.RequestURI()) {
+		return nil, ErrDisallowed
+	}
+
+	resp, err := f.get(ctx, u, rules.delay, func(req *http.Request) {
+		if prev.ETag != "" {
+			req.Header.Set("If-None-Match", prev.ETag)
+		}
+		if prev.LastModified != "" {
+			req.Header.Set("If-Modified-Since", prev.LastModified)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	page := &Page{URL: resp.Request.URL.String()}
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		page.NotModified = true
+		page.Validators = prev
+		return page, nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return nil, ErrNotFound
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	page.Data, err = readBody(resp.Body, f.cfg.MaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	page.ContentType = resp.Header.Get("Content-Type")
+	page.ETag = resp.Header.Get("ETag")
+	page.LastModified = resp.Header.Get("Last-Modified")
+
+	noindex, nofollow := parseDirectives(resp.Header.Values("X-Robots-Tag"), f.agent)
+	if isHTML(page.ContentType) {
+		scan := scanHTML(resp.Request.URL, page.Data, f.agent)
+		noindex, nofollow = noindex || scan.noindex, nofollow || scan.nofollow
+		if !nofollow {
+			page.Links = scan.links
+		}
+	}
+	page.NoIndex = noindex
+	return page, nil
+}
+
+// robots returns the robots.txt rules of u's host, fetching them when they
+// are unknown or stale. A missing robots.txt allows everything and one that
+// fails on the server disallows everything for now.
+func (f *Fetcher) robots(ctx context.Context, u *url.URL) (*robots, error) {
+	h := f.host(u)
+	f.mu.Lock()
+	rules, expires := h.robots, h.expires
+	f.mu.Unlock()
+	if rules != nil && time.Now().Before(expires) {
+		return rules, nil
+	}
+
+	robotsURL := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+	resp, err := f.get(ctx, robotsURL, 0, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch robots.txt: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxRobotsBytes))
+		rules = parseRobots(data, f.agent)
+	case resp.StatusCode >= 400 && resp.StatusCode <= 499:
+		rules = allowAll
+	default:
+		return disallowAll, nil
+	}
+
+	f.mu.Lock()
+	h.robots, h.expires = rules, time.Now().Add(robotsTTL)
+	f.mu.Unlock()
+	return rules, nil
+}
+
+func (f *Fetcher) host(u *url.URL) *host {
+	key := u.Scheme + "://" + u.Host
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	h := f.hosts[key]
+	if h == nil {
+		h = &host{}
+		f.hosts[key] = h
+	}
+	return h
+}
+
+// get sends a GET request once the host's turn has come, spacing requests
+// to it by the configured delay or the robots.txt one, whichever is longer.
+func (f *Fetcher) get(ctx context.Context, u *url.URL, delay time.Duration, prepare func(*http.Request)) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.cfg.UserAgent)
+	if prepare != nil {
+		prepare(req)
+	}
+
+	h := f.host(u)
+	h.turn.Lock()
+	defer h.turn.Unlock()
+	if wait := time.Until(h.next); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	resp, err := f.client.Do(req)
+	h.next = time.Now().Add(max(f.cfg.Delay, delay))
+	return resp, err
+}
+
+// readBody reads at most limit bytes, failing with ErrTooLarge beyond.
+func readBody(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+func isHTML(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
@@ -0,0 +1,125 @@
+package crawler
+
+import (
+	"bytes"
+	"net/url"
+	"path"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// assetExts are extensions of links that are never documents.
+var assetExts = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".webp": true, ".ico": true,
+	".css": true, ".js": true, ".json": true, ".xml": true, ".rss": true, ".atom": true,
+	".zip": true, ".gz": true, ".tar": true, ".tgz": true, ".exe": true, ".dmg": true, ".iso": true,
+	".mp3": true, ".mp4": true, ".webm": true, ".mov": true, ".avi": true, ".woff": true, ".woff2": true, ".ttf": true,
+}
+
+// htmlScan is what a crawl needs from an HTML page.
+type htmlScan struct {
+	links    []string
+	noindex  bool
+	nofollow bool
+}
+
+// scanHTML collects the same-host links of a page, resolved against its URL
+// or <base>, and its robots meta directives for agent. Links marked
+// rel="nofollow" and links to assets are left out.
+func scanHTML(base *url.URL, data []byte, agent string) htmlScan {
+	var (
+		scan htmlScan
+		seen = make(map[string]bool)
+		z    = html.NewTokenizer(bytes.NewReader(data))
+	)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return scan
+		case html.StartTagToken, html.SelfClosingTagToken:
+		default:
+			continue
+		}
+
+		name, hasAttr := z.TagName()
+		attrs := make(map[string]string)
+		for hasAttr {
+			var key, value []byte
+			key, value, hasAttr = z.TagAttr()
+			attrs[string(key)] = string(value)
+		}
+
+		switch string(name) {
+		case "base":
+			if u, err := base.Parse(attrs["href"]); err == nil && attrs["href"] != "" {
+				base = u
+			}
+		case "meta":
+			if metaName := strings.ToLower(attrs["name"]); metaName == "robots" || metaName == agent {
+				noindex, nofollow := parseDirectives([]string{attrs["content"]}, "")
+				scan.noindex = scan.noindex || noindex
+				scan.nofollow = scan.nofollow || nofollow
+			}
+		case "a", "area":
+			if hasToken(attrs["rel"], "nofollow") {
+				continue
+			}
+			u, err := ParseURL(resolve(base, attrs["href"]))
+			if err != nil || u.Host != strings.ToLower(base.Host) || assetExts[strings.ToLower(path.Ext(u.Path))] {
+				continue
+			}
+			if link := u.String(); !seen[link] {
+				seen[link] = true
+				scan.links = append(scan.links, link)
+			}
+		}
+	}
+}
+
+func resolve(base *url.URL, href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return ""
+	}
+	u, err := base.Parse(href)
+	if err != nil {
+		return ""
+	}
+	return u.String()
+}
+
+// parseDirectives reads robots directives from meta tag contents or
+// X-Robots-Tag headers. Header values may be scoped to an agent
+// ("otherbot: noindex"); those for other agents are ignored.
+func parseDirectives(values []string, agent string) (noindex, nofollow bool) {
+	for _, value := range values {
+		if scope, rest, ok := strings.Cut(value, ":"); ok && !strings.Contains(scope, ",") {
+			scope = strings.ToLower(strings.TrimSpace(scope))
+			if scope != agent {
+				continue
+			}
+			value = rest
+		}
+		for _, d := range strings.Split(strings.ToLower(value), ",") {
+			switch strings.TrimSpace(d) {
+			case "noindex":
+				noindex = true
+			case "nofollow":
+				nofollow = true
+			case "none":
+				noindex, nofollow = true, true
+			}
+		}
+	}
+	return noindex, nofollow
+}
+
+func hasToken(list, token string) bool {
+	for _, t := range strings.Fields(strings.ToLower(list)) {
+		if t == token {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package crawler
+
+import "time"
+
+// DefaultUserAgent identifies the crawler; robots.txt groups are matched
+// against its product token, "ScribeQuery".
+const DefaultUserAgent = "ScribeQuery/1.0"
+
+// Config tunes fetching. Zero values use the defaults.
+type Config struct {
+	UserAgent string        // default DefaultUserAgent
+	Delay     time.Duration // minimum time between requests to one host, raised by a robots.txt Crawl-delay; default 1s
+	Timeout   time.Duration // per request; default 30s
+	MaxBytes  int64         // largest response body read; default 30 MiB
+}
+
+func (c Config) withDefaults() Config {
+	if c.UserAgent == "" {
+		c.UserAgent = DefaultUserAgent
+	}
+	if c.Delay <= 0 {
+		c.Delay = time.Second
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 30 * time.Second
+	}
+	if c.MaxBytes <= 0 {
+		c.MaxBytes = 30 << 20
+	}
+	return c
+}
+
+// Mode is how far a crawl reaches from its start URL.
+type Mode string
+
+const (
+	ModePage    Mode = "page"    // the start URL only
+	ModeLinks   Mode = "links"   // pages linked from it on the same host, up to a depth
+	ModeSitemap Mode = "sitemap" // the pages listed by a sitemap
+)
+
+// Validators are the cache validators of a fetched page, sent back to ask
+// for it only if it changed.
+type Validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// Known is what a crawl remembers of a page from an earlier one.
+type Known struct {
+	Validators
+	Links []string // followed when the page comes back unmodified
+}
+
+// CrawlOptions scope a crawl. Zero values use the defaults.
+type CrawlOptions struct {
+	Mode     Mode             // default ModePage
+	MaxDepth int              // links followed from the start page in ModeLinks; default 2
+	MaxPages int              // pages fetched at most; default 100
+	Known    map[string]Known // earlier fetches by URL, fetched again only if they changed
+}
+
+func (o CrawlOptions) withDefaults() CrawlOptions {
+	if o.Mode == "" {
+		o.Mode = ModePage
+	}
+	if o.MaxDepth <= 0 {
+		o.MaxDepth = 2
+	}
+	if o.MaxPages <= 0 {
+		o.MaxPages = 100
+	}
+	return o
+}
+
+// Page is a fetched URL.
+type Page struct {
+	URL         string // after redirects
+	Depth       int    // links followed to reach it
+	ContentType string
+	Data        []byte
+	Validators
+
+	// NotModified is set when the page hasn't changed since it was fetched
+	// with the validators given; Data is empty and Links are the known ones.
+	NotModified bool
+	// NoIndex is set when the page asks not to be indexed.
+	NoIndex bool
+	// Links are the same-host pages an HTML page links to, unless it asks
+	// for its links not to be followed.
+	Links []string
+}
@@ -0,0 +1,142 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robots holds the rules of a robots.txt that apply to one user agent.
+type robots struct {
+	rules    []robotsRule
+	delay    time.Duration // Crawl-delay
+	sitemaps []string
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+var (
+	allowAll    = &robots{}
+	disallowAll = &robots{rules: []robotsRule{{allow: false, pattern: "/"}}}
+)
+
+// parseRobots reads the rules of a robots.txt for agent, the lowercased
+// product token of a user agent. The groups naming it apply, or else those
+// for "*".
+func parseRobots(data []byte, agent string) *robots {
+	type group struct {
+		agents []string
+		rules  []robotsRule
+		delay  time.Duration
+	}
+	var (
+		groups   []*group
+		current  *group
+		sitemaps []string
+		inAgents bool // consecutive User-agent lines open one group
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+
+		if key == "user-agent" {
+			if !inAgents {
+				current = &group{}
+				groups = append(groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			inAgents = true
+			continue
+		}
+		inAgents = false
+		switch key {
+		case "allow", "disallow":
+			// An empty Disallow allows everything, which is the default.
+			if current != nil && value != "" {
+				current.rules = append(current.rules, robotsRule{allow: key == "allow", pattern: value})
+			}
+		case "crawl-delay":
+			if secs, err := strconv.ParseFloat(value, 64); current != nil && err == nil && secs > 0 {
+				current.delay = time.Duration(secs * float64(time.Second))
+			}
+		case "sitemap":
+			sitemaps = append(sitemaps, value)
+		}
+	}
+
+	pick := func(name string) *robots {
+		var r *robots
+		for _, g := range groups {
+			for _, a := range g.agents {
+				if a == name {
+					if r == nil {
+						r = &robots{}
+					}
+					r.rules = append(r.rules, g.rules...)
+					r.delay = max(r.delay, g.delay)
+					break
+				}
+			}
+		}
+		return r
+	}
+	r := pick(agent)
+	if r == nil {
+		r = pick("*")
+	}
+	if r == nil {
+		r = &robots{}
+	}
+	r.sitemaps = sitemaps
+	return r
+}
+
+// allowed reports whether a path, with its query, may be fetched. The
+// longest matching rule decides, and allow wins a tie.
+func (r *robots) allowed(path string) bool {
+	best, allow := -1, true
+	for _, rule := range r.rules {
+		n := len(rule.pattern)
+		if n < best || (n == best && allow) || !matchRobots(rule.pattern, path) {
+			continue
+		}
+		best, allow = n, rule.allow
+	}
+	return allow
+}
+
+// matchRobots matches a path against a robots.txt pattern, a prefix in
+// which * matches any run of characters and a final $ anchors the end.
+func matchRobots(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = pattern[:len(pattern)-1]
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(path[pos:], part)
+		}
+		j := strings.Index(path[pos:], part)
+		if j < 0 {
+			return false
+		}
+		pos += j + len(part)
+	}
+	return !anchored || pos == len(path)
+}
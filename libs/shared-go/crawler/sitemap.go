@@ -0,0 +1,131 @@
+package crawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxSitemaps caps the sitemaps read for one crawl, following indexes.
+const maxSitemaps = 50
+
+// sitemapXML decodes both a <urlset> and a <sitemapindex>.
+type sitemapXML struct {
+	URLs []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+// Sitemap returns up to limit pages listed by the sitemap at rawURL,
+// following sitemap indexes. A URL that doesn't name an XML file stands for
+// its host, whose sitemaps are those listed in robots.txt or else
+// /sitemap.xml. Only pages on the host of rawURL are returned.
+func (f *Fetcher) Sitemap(ctx context.Context, rawURL string, limit int) ([]string, error) {
+	u, err := ParseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	var sitemaps []string
+	if p := strings.ToLower(u.Path); strings.HasSuffix(p, ".xml") || strings.HasSuffix(p, ".xml.gz") {
+		sitemaps = []string{u.String()}
+	} else {
+		rules, err := f.robots(ctx, u)
+		if err != nil {
+			return nil, err
+		}
+		sitemaps = append(sitemaps, rules.sitemaps...)
+		if len(sitemaps) == 0 {
+			sitemaps = []string{(&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/sitemap.xml"}).String()}
+		}
+	}
+
+	var (
+		pages   []string
+		seen    = make(map[string]bool)
+		lastErr error
+	)
+	for i := 0; i < len(sitemaps) && i < maxSitemaps && len(pages) < limit; i++ {
+		listed, nested, err := f.readSitemap(ctx, sitemaps[i])
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			lastErr = fmt.Errorf("sitemap %s: %w", sitemaps[i], err)
+			continue
+		}
+		sitemaps = append(sitemaps, nested...)
+		for _, loc := range listed {
+			page, err := ParseURL(loc)
+			if err != nil || page.Host != u.Host || seen[page.String()] {
+				continue
+			}
+			seen[page.String()] = true
+			pages = append(pages, page.String())
+			if len(pages) == limit {
+				break
+			}
+		}
+	}
+
+	if len(pages) == 0 {
+		if lastErr != nil {
+			return nil, lastErr
+		}
+		return nil, ErrNoSitemap
+	}
+	return pages, nil
+}
+
+// readSitemap fetches a sitemap, possibly gzipped, and returns the pages
+// and the sitemaps it lists.
+func (f *Fetcher) readSitemap(ctx context.Context, rawURL string) (pages, sitemaps []string, err error) {
+	u, err := ParseURL(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := f.get(ctx, u, 0, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := readBody(resp.Body, f.cfg.MaxBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, err
+		}
+		if data, err = readBody(zr, f.cfg.MaxBytes); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var doc sitemapXML
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.CharsetReader = func(_ string, r io.Reader) (io.Reader, error) { return r, nil }
+	if err := dec.Decode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("invalid sitemap: %w", err)
+	}
+	for _, entry := range doc.URLs {
+		pages = append(pages, strings.TrimSpace(entry.Loc))
+	}
+	for _, entry := range doc.Sitemaps {
+		sitemaps = append(sitemaps, strings.TrimSpace(entry.Loc))
+	}
+	return pages, sitemaps, nil
+}
@@ -7,14 +7,17 @@ import (
 type Provider interface {
 	CreateEmbedding(ctx context.Context, text string) ([]float32, error)
 
-	CreateEmbeddings(ctx context.Context, texts []string) ([]float32, error)
+	// CreateEmbeddings returns one embedding per text, in the same order.
+	CreateEmbeddings(ctx context.Context, texts []string) ([][]float32, error)
 
 	IsEnabled() bool
+
+	GetModel() string
 }
 
 type Service interface {
 	CreateEmbedding(ctx context.Context, text string) ([]float32, error)
 
-	CreateEmbeddings(ctx context.Context, texts []string) ([]float32, error)
+	CreateEmbeddings(ctx context.Context, texts []string) ([][]float32, error)
 }
 
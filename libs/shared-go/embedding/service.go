@@ -44,7 +44,7 @@ func (s *embeddingService) CreateEmbedding(ctx context.Context, text string) ([]
 	return embedding, nil
 }
 
-func (s *embeddingService) CreateEmbeddings(ctx context.Context, texts []string) ([]float32, error) {
+func (s *embeddingService) CreateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
 	if len(texts) == 0 {
 		return nil, fmt.Errorf("texts cannot be empty")
 	}
@@ -56,9 +56,7 @@ func (s *embeddingService) CreateEmbeddings(ctx context.Context, texts []string)
 	s.logger.Debug("Creating embeddings",
 		zap.Int("text_count", len(texts)))
 
-	// For now, we'll create embeddings for all texts and return the first one
-	// This can be extended to return all embeddings if needed
-	embedding, err := s.provider.CreateEmbeddings(ctx, texts)
+	embeddings, err := s.provider.CreateEmbeddings(ctx, texts)
 	if err != nil {
 		s.logger.Error("Failed to create embeddings",
 			zap.Error(err))
@@ -66,9 +64,9 @@ func (s *embeddingService) CreateEmbeddings(ctx context.Context, texts []string)
 	}
 
 	s.logger.Debug("Embeddings created successfully",
-		zap.Int("dimension", len(embedding)),
+		zap.Int("dimension", len(embeddings[0])),
 		zap.Int("text_count", len(texts)))
 
-	return embedding, nil
+	return embeddings, nil
 }
 
@@ -0,0 +1,232 @@
+//go:build linux
+
+package fswatch
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// watchMask selects the inotify events reported for every watched directory.
+const watchMask = unix.IN_CREATE | unix.IN_MODIFY | unix.IN_CLOSE_WRITE | unix.IN_DELETE |
+	unix.IN_MOVED_FROM | unix.IN_MOVED_TO | unix.IN_DELETE_SELF | unix.IN_MOVE_SELF | unix.IN_ONLYDIR
+
+// Watcher reports changes below directory trees using inotify. Directories
+// created or moved into a watched tree are watched as they appear.
+type Watcher struct {
+	fd     int
+	file   *os.File // fd, read through the runtime poller so Close interrupts it
+	events chan Event
+	done   chan struct{}
+	close  sync.Once
+
+	mu    sync.Mutex
+	dirs  map[int]string // watch descriptor to directory
+	wds   map[string]int
+	roots map[string]bool
+	err   error
+}
+
+// New returns a watcher watching nothing yet.
+func New() (*Watcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("fswatch: %w", err)
+	}
+	w := &Watcher{
+		fd:     fd,
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		events: make(chan Event, 256),
+		done:   make(chan struct{}),
+		dirs:   make(map[int]string),
+		wds:    make(map[string]int),
+		roots:  make(map[string]bool),
+	}
+	go w.read()
+	return w, nil
+}
+
+// Add watches root and every directory below it.
+func (w *Watcher) Add(root string) error {
+	root = filepath.Clean(root)
+	info, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("fswatch: %s is not a directory", root)
+	}
+	if err := w.addTree(root, false); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	w.roots[root] = true
+	w.mu.Unlock()
+	return nil
+}
+
+// Events returns the changes seen. It is closed once the watcher is closed
+// or fails, after which Err returns the failure.
+func (w *Watcher) Events() <-chan Event {
+	return w.events
+}
+
+// Err returns why the watcher stopped, or nil while it runs or after Close.
+func (w *Watcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Close stops watching and closes Events.
+func (w *Watcher) Close() error {
+	var err error
+	w.close.Do(func() {
+		close(w.done)
+		err = w.file.Close()
+	})
+	return err
+}
+
+// addTree watches dir and the directories below it. With announce set, the
+// files found are reported as created: they may have been written before
+// their directory was watched.
+func (w *Watcher) addTree(dir string, announce bool) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Gone already, or unreadable: nothing to watch there.
+			if path != dir && (errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission)) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			if announce {
+				w.send(Event{Path: path, Op: Create})
+			}
+			return nil
+		}
+		wd, err := unix.InotifyAddWatch(w.fd, path, watchMask)
+		if err != nil {
+			return fmt.Errorf("fswatch: watching %s: %w", path, err)
+		}
+		w.mu.Lock()
+		w.dirs[wd] = path
+		w.wds[path] = wd
+		w.mu.Unlock()
+		return nil
+	})
+}
+
+// forget stops watching dir and the directories below it, which have moved
+// out of reach of their recorded paths.
+func (w *Watcher) forget(dir string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	prefix := dir + string(filepath.Separator)
+	for path, wd := range w.wds {
+		if path == dir || strings.HasPrefix(path, prefix) {
+			unix.InotifyRmWatch(w.fd, uint32(wd))
+			delete(w.wds, path)
+			delete(w.dirs, wd)
+		}
+	}
+}
+
+func (w *Watcher) read() {
+	defer close(w.events)
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			select {
+			case <-w.done:
+			default:
+				w.mu.Lock()
+				w.err = fmt.Errorf("fswatch: %w", err)
+				w.mu.Unlock()
+			}
+			return
+		}
+
+		for off := 0; off+unix.SizeofInotifyEvent <= n; {
+			raw := (*unix.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			start := off + unix.SizeofInotifyEvent
+			off = start + int(raw.Len)
+			if off > n {
+				break
+			}
+			name := strings.TrimRight(string(buf[start:off]), "\x00")
+			if !w.handle(int(raw.Wd), raw.Mask, name) {
+				return
+			}
+		}
+	}
+}
+
+// handle turns one inotify event into events on Events, returning false once
+// the watcher is closed.
+func (w *Watcher) handle(wd int, mask uint32, name string) bool {
+	if mask&unix.IN_Q_OVERFLOW != 0 {
+		return w.send(Event{Op: Overflow})
+	}
+
+	w.mu.Lock()
+	dir, ok := w.dirs[wd]
+	if mask&unix.IN_IGNORED != 0 {
+		delete(w.dirs, wd)
+		if w.wds[dir] == wd {
+			delete(w.wds, dir)
+		}
+	}
+	root := w.roots[dir]
+	w.mu.Unlock()
+	if !ok {
+		return true
+	}
+
+	path, isDir := filepath.Join(dir, name), mask&unix.IN_ISDIR != 0
+	switch {
+	case mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0:
+		if isDir {
+			if err := w.addTree(path, true); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				// Changes below it are missed; have the owner rescan.
+				return w.send(Event{Op: Overflow})
+			}
+		}
+		return w.send(Event{Path: path, Op: Create, Dir: isDir})
+	case mask&(unix.IN_MODIFY|unix.IN_CLOSE_WRITE) != 0:
+		return w.send(Event{Path: path, Op: Write})
+	case mask&(unix.IN_DELETE|unix.IN_MOVED_FROM) != 0:
+		if isDir && mask&unix.IN_MOVED_FROM != 0 {
+			w.forget(path)
+		}
+		return w.send(Event{Path: path, Op: Remove, Dir: isDir})
+	case mask&(unix.IN_DELETE_SELF|unix.IN_MOVE_SELF) != 0:
+		// Directories below a root are reported by their parent.
+		if mask&unix.IN_MOVE_SELF != 0 {
+			w.forget(dir)
+		}
+		if root {
+			return w.send(Event{Path: dir, Op: Remove, Dir: true})
+		}
+	}
+	return true
+}
+
+func (w *Watcher) send(ev Event) bool {
+	select {
+	case w.events <- ev:
+		return true
+	case <-w.done:
+		return false
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package fswatch
+
+// Watcher is unavailable without inotify; New returns ErrUnsupported.
+type Watcher struct{}
+
+func New() (*Watcher, error) {
+	return nil, ErrUnsupported
+}
+
+func (w *Watcher) Add(root string) error { return ErrUnsupported }
+
+func (w *Watcher) Events() <-chan Event { return nil }
+
+func (w *Watcher) Err() error { return ErrUnsupported }
+
+func (w *Watcher) Close() error { return nil }
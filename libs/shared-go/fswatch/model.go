@@ -0,0 +1,40 @@
+package fswatch
+
+import "errors"
+
+// ErrUnsupported is returned where the platform has no inotify.
+var ErrUnsupported = errors.New("fswatch: watching files needs inotify, which is only available on Linux")
+
+// Op is what happened to a path.
+type Op uint8
+
+const (
+	Create Op = iota + 1 // created, or moved into a watched directory
+	Write                // written to
+	Remove               // deleted, or moved out of the watched directories
+
+	// Overflow reports that the kernel dropped events. Path is empty and
+	// anything may have changed, so watched directories should be rescanned.
+	Overflow
+)
+
+func (op Op) String() string {
+	switch op {
+	case Create:
+		return "create"
+	case Write:
+		return "write"
+	case Remove:
+		return "remove"
+	case Overflow:
+		return "overflow"
+	}
+	return "unknown"
+}
+
+// Event is a change to a file or directory below a watched directory.
+type Event struct {
+	Path string
+	Op   Op
+	Dir  bool // the path is a directory
+}
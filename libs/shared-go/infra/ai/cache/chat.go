@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"go.uber.org/zap"
+)
+
+// chatProvider serves repeated deterministic completions from a Cache.
+type chatProvider struct {
+	ai.ChatProvider
+	cache  *Cache
+	logger *zap.Logger
+}
+
+// NewChatProvider wraps provider with response caching. Only deterministic
+// requests are cached: temperature 0, or ChatOptions.Cache set explicitly.
+func NewChatProvider(provider ai.ChatProvider, c *Cache, logger *zap.Logger) ai.ChatProvider {
+	return &chatProvider{ChatProvider: provider, cache: c, logger: logger}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *chatProvider) Unwrap() ai.ChatProvider {
+	return p.ChatProvider
+}
+
+func (p *chatProvider) Completion(ctx context.Context, messages []ai.Message, opts *ai.ChatOptions) (*ai.ChatResponse, error) {
+	if !cacheable(opts) {
+		return p.ChatProvider.Completion(ctx, messages, opts)
+	}
+
+	key := p.key(messages, opts)
+	if resp, ok := p.lookup(key); ok {
+		return resp, nil
+	}
+
+	resp, err := p.ChatProvider.Completion(ctx, messages, opts)
+	if err != nil {
+		return nil, err
+	}
+	p.store(key, resp)
+	return resp, nil
+}
+
+func (p *chatProvider) CompletionStream(ctx context.Context, messages []ai.Message, opts *ai.ChatOptions, onDelta func(delta ai.ChatStreamDelta) error) error {
+	if !cacheable(opts) {
+		return p.ChatProvider.CompletionStream(ctx, messages, opts, onDelta)
+	}
+
+	key := p.key(messages, opts)
+	if resp, ok := p.lookup(key); ok {
+		return onDelta(ai.ChatStreamDelta{
+			Content:      resp.Content,
+			Done:         true,
+			FinishReason: "stop",
+		})
+	}
+
+	var content strings.Builder
+	err := p.ChatProvider.CompletionStream(ctx, messages, opts, func(delta ai.ChatStreamDelta) error {
+		content.WriteString(delta.Content)
+		return onDelta(delta)
+	})
+	if err != nil {
+		return err
+	}
+
+	p.store(key, &ai.ChatResponse{Model: p.GetModel(), Content: content.String()})
+	return nil
+}
+
+func (p *chatProvider) key(messages []ai.Message, opts *ai.ChatOptions) string {
+	normalized := make([]ai.Message, len(messages))
+	for i, m := range messages {
+		normalized[i] = ai.Message{
+			Role:    strings.ToLower(strings.TrimSpace(m.Role)),
+			Content: normalize(m.Content),
+			Parts:   make([]ai.ContentPart, len(m.Parts)),
+		}
+		for j, part := range m.Parts {
+			if part.Type == ai.PartText {
+				part.Text = normalize(part.Text)
+			}
+			normalized[i].Parts[j] = part
+		}
+	}
+
+	keyOpts := *opts
+	keyOpts.Cache = false
+
+	return hashKey(struct {
+		Kind     string          `json:"kind"`
+		Model    string          `json:"model"`
+		Messages []ai.Message    `json:"messages"`
+		Options  *ai.ChatOptions `json:"options"`
+	}{"chat", p.GetModel(), normalized, &keyOpts})
+}
+
+func (p *chatProvider) lookup(key string) (*ai.ChatResponse, bool) {
+	data, ok := p.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	var resp ai.ChatResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		p.logger.Warn("Discarding corrupt cached completion", zap.Error(err))
+		return nil, false
+	}
+	return &resp, true
+}
+
+func (p *chatProvider) store(key string, resp *ai.ChatResponse) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	if err := p.cache.Set(key, data); err != nil {
+		p.logger.Warn("Failed to persist cached completion", zap.Error(err))
+	}
+}
+
+// cacheable reports whether a request is deterministic enough to cache.
+func cacheable(opts *ai.ChatOptions) bool {
+	if opts == nil {
+		return false
+	}
+	return opts.Cache || (opts.Temperature != nil && *opts.Temperature == 0)
+}
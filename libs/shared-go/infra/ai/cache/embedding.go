@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/embedding"
+	"go.uber.org/zap"
+)
+
+// embeddingProvider serves embeddings of previously seen texts from a Cache
+// and only sends the misses to the wrapped provider.
+type embeddingProvider struct {
+	embedding.Provider
+	cache  *Cache
+	logger *zap.Logger
+}
+
+// NewEmbeddingProvider wraps provider with per-text embedding caching.
+func NewEmbeddingProvider(provider embedding.Provider, c *Cache, logger *zap.Logger) embedding.Provider {
+	return &embeddingProvider{Provider: provider, cache: c, logger: logger}
+}
+
+func (p *embeddingProvider) CreateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := p.CreateEmbeddings(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) != 1 {
+		return nil, fmt.Errorf("got %d embeddings for 1 text", len(embeddings))
+	}
+	return embeddings[0], nil
+}
+
+func (p *embeddingProvider) CreateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	keys := make([]string, len(texts))
+
+	var (
+		missTexts   []string
+		missIndexes []int
+	)
+	for i, text := range texts {
+		keys[i] = p.key(text)
+		if v, ok := p.lookup(keys[i]); ok {
+			out[i] = v
+			continue
+		}
+		missTexts = append(missTexts, text)
+		missIndexes = append(missIndexes, i)
+	}
+
+	if len(missTexts) == 0 {
+		return out, nil
+	}
+
+	fresh, err := p.Provider.CreateEmbeddings(ctx, missTexts)
+	if err != nil {
+		return nil, err
+	}
+	if len(fresh) != len(missTexts) {
+		return nil, fmt.Errorf("got %d embeddings for %d texts", len(fresh), len(missTexts))
+	}
+	for j, i := range missIndexes {
+		out[i] = fresh[j]
+		if err := p.cache.Set(keys[i], encodeVector(fresh[j])); err != nil {
+			p.logger.Warn("Failed to persist cached embedding", zap.Error(err))
+		}
+	}
+	return out, nil
+}
+
+func (p *embeddingProvider) key(text string) string {
+	return hashKey(struct {
+		Kind  string `json:"kind"`
+		Model string `json:"model"`
+		Text  string `json:"text"`
+	}{"embedding", p.GetModel(), normalize(text)})
+}
+
+func (p *embeddingProvider) lookup(key string) ([]float32, bool) {
+	data, ok := p.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	v, err := decodeVector(data)
+	if err != nil {
+		p.logger.Warn("Discarding corrupt cached embedding", zap.Error(err))
+		return nil, false
+	}
+	return v, true
+}
@@ -0,0 +1,19 @@
+package cache
+
+import "time"
+
+const defaultMaxEntries = 10000
+
+// Config configures a Cache.
+type Config struct {
+	MaxEntries int           // In-memory LRU capacity (default 10000)
+	TTL        time.Duration // Entry lifetime; 0 keeps entries until evicted
+	Dir        string        // Optional directory for the on-disk store
+}
+
+// Stats reports cache effectiveness since creation.
+type Stats struct {
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Entries int    `json:"entries"` // Entries currently held in memory
+}
@@ -0,0 +1,202 @@
+package cache
+
+import (
+	"container/list"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache is a byte cache backed by an in-memory LRU and, optionally, a
+// directory on disk. Disk hits are promoted into memory.
+type Cache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // front is most recently used
+	max     int
+	ttl     time.Duration
+	dir     string
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+type entry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time // zero means no expiry
+}
+
+// New creates a Cache from cfg.
+func New(cfg Config) (*Cache, error) {
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = defaultMaxEntries
+	}
+	if cfg.Dir != "" {
+		if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+			return nil, fmt.Errorf("create cache directory %q: %w", cfg.Dir, err)
+		}
+	}
+
+	return &Cache{
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		max:     cfg.MaxEntries,
+		ttl:     cfg.TTL,
+		dir:     cfg.Dir,
+	}, nil
+}
+
+// Get returns the value stored under key and records a hit or miss.
+func (c *Cache) Get(key string) ([]byte, bool) {
+	if value, ok := c.getMemory(key); ok {
+		c.hits.Add(1)
+		return value, true
+	}
+
+	if value, expiresAt, ok := c.getDisk(key); ok {
+		c.setMemory(key, value, expiresAt)
+		c.hits.Add(1)
+		return value, true
+	}
+
+	c.misses.Add(1)
+	return nil, false
+}
+
+// Set stores value under key in memory and, when configured, on disk.
+// Disk write failures are returned but the memory entry is kept.
+func (c *Cache) Set(key string, value []byte) error {
+	var expiresAt time.Time
+	if c.ttl > 0 {
+		expiresAt = time.Now().Add(c.ttl)
+	}
+
+	c.setMemory(key, value, expiresAt)
+	return c.setDisk(key, value, expiresAt)
+}
+
+// Stats returns the hit and miss counters.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	n := c.order.Len()
+	c.mu.Unlock()
+
+	return Stats{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Entries: n,
+	}
+}
+
+func (c *Cache) getMemory(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if expired(e.expiresAt) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return e.value, true
+}
+
+func (c *Cache) setMemory(key string, value []byte, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*entry)
+		e.value = value
+		e.expiresAt = expiresAt
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).key)
+	}
+}
+
+// Disk entries are stored as an 8-byte big-endian expiry (unix nanoseconds,
+// 0 for none) followed by the value, sharded by the first two key characters.
+func (c *Cache) path(key string) string {
+	shard := key
+	if len(shard) > 2 {
+		shard = shard[:2]
+	}
+	return filepath.Join(c.dir, shard, key)
+}
+
+func (c *Cache) getDisk(key string) ([]byte, time.Time, bool) {
+	if c.dir == "" {
+		return nil, time.Time{}, false
+	}
+
+	data, err := os.ReadFile(c.path(key))
+	if err != nil || len(data) < 8 {
+		return nil, time.Time{}, false
+	}
+
+	var expiresAt time.Time
+	if ns := int64(binary.BigEndian.Uint64(data[:8])); ns != 0 {
+		expiresAt = time.Unix(0, ns)
+	}
+	if expired(expiresAt) {
+		os.Remove(c.path(key))
+		return nil, time.Time{}, false
+	}
+	return data[8:], expiresAt, true
+}
+
+func (c *Cache) setDisk(key string, value []byte, expiresAt time.Time) error {
+	if c.dir == "" {
+		return nil
+	}
+
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create cache shard: %w", err)
+	}
+
+	data := make([]byte, 8+len(value))
+	if !expiresAt.IsZero() {
+		binary.BigEndian.PutUint64(data[:8], uint64(expiresAt.UnixNano()))
+	}
+	copy(data[8:], value)
+
+	// Write to a temp file and rename so readers never see partial entries.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create cache entry: %w", err)
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if err := errors.Join(writeErr, closeErr); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("commit cache entry: %w", err)
+	}
+	return nil
+}
+
+func expired(expiresAt time.Time) bool {
+	return !expiresAt.IsZero() && time.Now().After(expiresAt)
+}
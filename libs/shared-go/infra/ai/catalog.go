@@ -0,0 +1,83 @@
+package ai
+
+import "path"
+
+// capability is what we know about a family of models that providers don't
+// report themselves. Patterns are path.Match globs tried in order, so more
+// specific entries must come first.
+type capability struct {
+	pattern       string
+	contextWindow int
+	modalities    []string
+	tools         bool
+}
+
+var (
+	textOnly      = []string{ModalityText}
+	textAndImage  = []string{ModalityText, ModalityImage}
+	embeddingOnly = []string{ModalityEmbedding}
+)
+
+var capabilities = []capability{
+	// OpenAI
+	{"gpt-4.1*", 1047576, textAndImage, true},
+	{"gpt-4o*", 128000, textAndImage, true},
+	{"gpt-4-turbo*", 128000, textAndImage, true},
+	{"gpt-4*", 8192, textOnly, true},
+	{"gpt-3.5-turbo*", 16385, textOnly, true},
+	{"o1-mini*", 128000, textOnly, false},
+	{"o1*", 200000, textAndImage, true},
+	{"o3*", 200000, textAndImage, true},
+	{"o4-mini*", 200000, textAndImage, true},
+	{"text-embedding-3-*", 8191, embeddingOnly, false},
+	{"text-embedding-ada-002*", 8191, embeddingOnly, false},
+
+	// Ollama
+	{"llama3.1*", 131072, textOnly, true},
+	{"llama3.2-vision*", 131072, textAndImage, false},
+	{"llama3.2*", 131072, textOnly, true},
+	{"llama3.3*", 131072, textOnly, true},
+	{"llama3*", 8192, textOnly, false},
+	{"llava*", 4096, textAndImage, false},
+	{"mistral-nemo*", 131072, textOnly, true},
+	{"mistral*", 32768, textOnly, true},
+	{"qwen2.5*", 32768, textOnly, true},
+	{"gemma2*", 8192, textOnly, false},
+	{"phi3*", 4096, textOnly, false},
+	{"nomic-embed-text*", 8192, embeddingOnly, false},
+	{"mxbai-embed-large*", 512, embeddingOnly, false},
+}
+
+// lookupCapability returns the catalog entry matching model.
+func lookupCapability(model string) (capability, bool) {
+	for _, c := range capabilities {
+		if ok, _ := path.Match(c.pattern, model); ok {
+			return c, true
+		}
+	}
+	return capability{}, false
+}
+
+// annotate fills in the catalog capabilities of info that the provider
+// didn't report.
+func annotate(info ModelInfo) ModelInfo {
+	c, ok := lookupCapability(info.ID)
+	if !ok {
+		return info
+	}
+	if info.ContextWindow == 0 {
+		info.ContextWindow = c.contextWindow
+	}
+	if len(info.Modalities) == 0 {
+		info.Modalities = c.modalities
+	}
+	info.SupportsTools = info.SupportsTools || c.tools
+	return info
+}
+
+// ContextWindow returns the catalog context window for model, or 0 if the
+// model is unknown.
+func ContextWindow(model string) int {
+	c, _ := lookupCapability(model)
+	return c.contextWindow
+}
@@ -0,0 +1,192 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Content part types.
+const (
+	PartText     = "text"
+	PartImageURL = "image_url"
+	PartImage    = "image"
+)
+
+// ImageTokens is what OpenAI charges for a 1024x1024 image at high detail.
+// Image dimensions aren't tracked, so token estimates cost every image alike.
+const ImageTokens = 765
+
+// ContentPart is one piece of a multimodal message: text, an image by URL or
+// inline image bytes.
+type ContentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+	Data     []byte    `json:"data,omitempty"`      // Image bytes, base64 in JSON
+	MIMEType string    `json:"mime_type,omitempty"` // e.g. "image/png", required with Data
+}
+
+// ImageURL references an image by http(s) or data: URL.
+type ImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"` // "low", "high" or "auto" (OpenAI only)
+}
+
+// TextPart returns a text content part.
+func TextPart(text string) ContentPart {
+	return ContentPart{Type: PartText, Text: text}
+}
+
+// ImageURLPart returns an image part referencing url.
+func ImageURLPart(url string) ContentPart {
+	return ContentPart{Type: PartImageURL, ImageURL: &ImageURL{URL: url}}
+}
+
+// ImagePart returns an inline image part.
+func ImagePart(data []byte, mimeType string) ContentPart {
+	return ContentPart{Type: PartImage, Data: data, MIMEType: mimeType}
+}
+
+// IsImage reports whether the part carries an image.
+func (p ContentPart) IsImage() bool {
+	return p.Type == PartImageURL || p.Type == PartImage
+}
+
+// DataURL returns the image as a data: URL for inline images, or the
+// referenced URL otherwise.
+func (p ContentPart) DataURL() string {
+	if p.Type == PartImageURL && p.ImageURL != nil {
+		return p.ImageURL.URL
+	}
+	return "data:" + p.MIMEType + ";base64," + base64.StdEncoding.EncodeToString(p.Data)
+}
+
+// ImageBytes returns the raw bytes of an inline image or a data: URL image.
+// Remote URLs are not fetched and return an error.
+func (p ContentPart) ImageBytes() ([]byte, error) {
+	switch p.Type {
+	case PartImage:
+		return p.Data, nil
+	case PartImageURL:
+		if p.ImageURL == nil {
+			return nil, errors.New("image_url part has no url")
+		}
+		header, data, found := strings.Cut(p.ImageURL.URL, ",")
+		if !found || !strings.HasPrefix(header, "data:") || !strings.HasSuffix(header, ";base64") {
+			return nil, fmt.Errorf("image url %q is not a base64 data url", truncate(p.ImageURL.URL, 32))
+		}
+		return base64.StdEncoding.DecodeString(data)
+	}
+	return nil, fmt.Errorf("%s part is not an image", p.Type)
+}
+
+func (p ContentPart) validate() error {
+	switch p.Type {
+	case PartText:
+		return nil
+	case PartImageURL:
+		if p.ImageURL == nil || p.ImageURL.URL == "" {
+			return errors.New("image_url part requires a url")
+		}
+	case PartImage:
+		if len(p.Data) == 0 || !strings.HasPrefix(p.MIMEType, "image/") {
+			return errors.New("image part requires data and an image mime_type")
+		}
+	default:
+		return fmt.Errorf("unsupported content part type %q", p.Type)
+	}
+	return nil
+}
+
+// Text returns the text of the message: Content followed by any text parts.
+func (m Message) Text() string {
+	if len(m.Parts) == 0 {
+		return m.Content
+	}
+	texts := make([]string, 0, len(m.Parts)+1)
+	if m.Content != "" {
+		texts = append(texts, m.Content)
+	}
+	for _, p := range m.Parts {
+		if p.Type == PartText {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// Images returns the image parts of the message.
+func (m Message) Images() []ContentPart {
+	var images []ContentPart
+	for _, p := range m.Parts {
+		if p.IsImage() {
+			images = append(images, p)
+		}
+	}
+	return images
+}
+
+// AllParts returns the message as content parts, with Content as a leading
+// text part.
+func (m Message) AllParts() []ContentPart {
+	if m.Content == "" {
+		return m.Parts
+	}
+	return append([]ContentPart{TextPart(m.Content)}, m.Parts...)
+}
+
+// MarshalJSON writes "content" as a string for text-only messages, keeping the
+// original wire format, and as an array of parts otherwise.
+func (m Message) MarshalJSON() ([]byte, error) {
+	if len(m.Parts) == 0 {
+		return json.Marshal(struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		}{m.Role, m.Content})
+	}
+	return json.Marshal(struct {
+		Role    string        `json:"role"`
+		Content []ContentPart `json:"content"`
+	}{m.Role, m.AllParts()})
+}
+
+// UnmarshalJSON accepts "content" as either a string or an array of parts.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*m = Message{Role: raw.Role}
+	content := bytes.TrimSpace(raw.Content)
+	if len(content) == 0 || bytes.Equal(content, []byte("null")) {
+		return nil
+	}
+	if content[0] == '"' {
+		return json.Unmarshal(content, &m.Content)
+	}
+
+	if err := json.Unmarshal(content, &m.Parts); err != nil {
+		return fmt.Errorf("content must be a string or an array of parts: %w", err)
+	}
+	for _, p := range m.Parts {
+		if err := p.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,164 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Sentinel error kinds. Provider failures are returned as *Error values that
+// match one of these through errors.Is.
+var (
+	ErrRateLimited           = errors.New("rate limited")
+	ErrContextLengthExceeded = errors.New("context length exceeded")
+	ErrAuthFailed            = errors.New("authentication failed")
+	ErrQuotaExceeded         = errors.New("quota exceeded") // the account is out of credit; retrying won't help
+	ErrContentFiltered       = errors.New("content filtered")
+	ErrModelNotFound         = errors.New("model not found")
+	ErrProviderUnavailable   = errors.New("provider unavailable")
+	ErrInvalidRequest        = errors.New("invalid request")
+)
+
+// Error is a classified provider failure.
+type Error struct {
+	Kind       error        // One of the Err* sentinels
+	Provider   ProviderType // Provider that produced the error
+	StatusCode int          // Upstream HTTP status, 0 if no response was received
+	Code       string       // Provider error code, e.g. "context_length_exceeded"
+	Message    string       // Provider error message
+	Err        error        // Underlying client error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %v: %s", e.Provider, e.Kind, e.Message)
+}
+
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// statusError is implemented by the HTTP errors of the provider clients.
+type statusError interface {
+	error
+	HTTPStatus() int
+}
+
+// codedError is implemented by provider errors that carry a machine-readable code.
+type codedError interface {
+	ErrorCode() string
+}
+
+// Classify maps a provider client error onto the ai error taxonomy. Errors
+// that are already classified, context cancellations and errors that don't
+// come from the provider (e.g. returned by a stream callback) are returned
+// unchanged.
+func Classify(provider ProviderType, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var classified *Error
+	if errors.As(err, &classified) {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	var se statusError
+	if errors.As(err, &se) {
+		code := ""
+		var ce codedError
+		if errors.As(err, &ce) {
+			code = ce.ErrorCode()
+		}
+
+		var kind error
+		switch provider {
+		case ProviderLocal:
+			kind = classifyLocal(se.HTTPStatus(), se.Error())
+		default:
+			kind = classifyOpenAI(se.HTTPStatus(), code, se.Error())
+		}
+
+		return &Error{
+			Kind:       kind,
+			Provider:   provider,
+			StatusCode: se.HTTPStatus(),
+			Code:       code,
+			Message:    se.Error(),
+			Err:        err,
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return &Error{
+			Kind:     ErrProviderUnavailable,
+			Provider: provider,
+			Message:  err.Error(),
+			Err:      err,
+		}
+	}
+
+	return err
+}
+
+// classifyOpenAI maps an OpenAI error response onto an error kind.
+// See https://platform.openai.com/docs/guides/error-codes.
+func classifyOpenAI(status int, code, message string) error {
+	switch code {
+	case "context_length_exceeded", "string_above_max_length":
+		return ErrContextLengthExceeded
+	case "rate_limit_exceeded":
+		return ErrRateLimited
+	case "insufficient_quota":
+		return ErrQuotaExceeded
+	case "invalid_api_key", "invalid_organization", "unsupported_country_region_territory":
+		return ErrAuthFailed
+	case "content_filter", "content_policy_violation":
+		return ErrContentFiltered
+	case "model_not_found":
+		return ErrModelNotFound
+	}
+
+	if strings.Contains(strings.ToLower(message), "maximum context length") {
+		return ErrContextLengthExceeded
+	}
+	return classifyStatus(status)
+}
+
+// classifyLocal maps an Ollama error response onto an error kind. Ollama has
+// no error codes, so the message is inspected where the status is ambiguous.
+func classifyLocal(status int, message string) error {
+	msg := strings.ToLower(message)
+	switch {
+	case strings.Contains(msg, "context length"), strings.Contains(msg, "context window"):
+		return ErrContextLengthExceeded
+	case strings.Contains(msg, "not found") && strings.Contains(msg, "model"):
+		return ErrModelNotFound
+	case strings.Contains(msg, "server busy"), strings.Contains(msg, "too many"):
+		return ErrRateLimited
+	}
+	return classifyStatus(status)
+}
+
+func classifyStatus(status int) error {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return ErrAuthFailed
+	case status == http.StatusNotFound:
+		return ErrModelNotFound
+	case status == http.StatusRequestEntityTooLarge:
+		return ErrContextLengthExceeded
+	case status >= 500:
+		return ErrProviderUnavailable
+	default:
+		return ErrInvalidRequest
+	}
+}
@@ -2,10 +2,12 @@ package ai
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 
 	localchats "github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai/local/chats"
 	openaichats "github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai/openai/chats"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai/retry"
 	"go.uber.org/zap"
 )
 
@@ -19,6 +21,9 @@ type ChatProviderConfig struct {
 	// Local (Ollama)-specific
 	LocalHost  string
 	LocalModel string
+
+	// Retry controls backoff for transient provider failures (nil uses retry.DefaultPolicy).
+	Retry *retry.Policy
 }
 
 func NewChatProvider(cfg *ChatProviderConfig, logger *zap.Logger) (ChatProvider, error) {
@@ -48,6 +53,7 @@ func newOpenAIAdapter(cfg *ChatProviderConfig, logger *zap.Logger) (*openAIAdapt
 	client, err := openaichats.NewClient(&openaichats.Config{
 		APIKey: cfg.OpenAIAPIKey,
 		Model:  cfg.OpenAIModel,
+		Retry:  cfg.Retry,
 	}, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create OpenAI chat client: %w", err)
@@ -61,12 +67,20 @@ func (a *openAIAdapter) Completion(ctx context.Context, messages []Message, opts
 
 	resp, err := a.client.Completion(ctx, oaiMsgs, oaiOpts)
 	if err != nil {
-		return nil, err
+		return nil, Classify(ProviderOpenAI, err)
 	}
 
 	content := ""
 	if len(resp.Choices) > 0 {
 		content = resp.Choices[0].Message.Content
+		if resp.Choices[0].FinishReason == "content_filter" {
+			return nil, &Error{
+				Kind:     ErrContentFiltered,
+				Provider: ProviderOpenAI,
+				Code:     "content_filter",
+				Message:  "completion was stopped by the content filter",
+			}
+		}
 	}
 
 	return &ChatResponse{
@@ -84,7 +98,7 @@ func (a *openAIAdapter) CompletionStream(ctx context.Context, messages []Message
 	oaiMsgs := toOpenAIMessages(messages)
 	oaiOpts := toOpenAIOptions(opts)
 
-	return a.client.CompletionStream(ctx, oaiMsgs, oaiOpts, func(chunk openaichats.StreamChunk) error {
+	err := a.client.CompletionStream(ctx, oaiMsgs, oaiOpts, func(chunk openaichats.StreamChunk) error {
 		content := ""
 		finishReason := ""
 		done := false
@@ -101,6 +115,7 @@ func (a *openAIAdapter) CompletionStream(ctx context.Context, messages []Message
 			FinishReason: finishReason,
 		})
 	})
+	return Classify(ProviderOpenAI, err)
 }
 
 func (a *openAIAdapter) Health(ctx context.Context) error {
@@ -115,6 +130,23 @@ func (a *openAIAdapter) GetModel() string {
 	return a.client.GetModel()
 }
 
+func (a *openAIAdapter) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	models, err := a.client.ListModels(ctx)
+	if err != nil {
+		return nil, Classify(ProviderOpenAI, err)
+	}
+
+	infos := make([]ModelInfo, 0, len(models))
+	for _, m := range models {
+		infos = append(infos, annotate(ModelInfo{
+			ID:       m.ID,
+			Provider: string(ProviderOpenAI),
+			OwnedBy:  m.OwnedBy,
+		}))
+	}
+	return infos, nil
+}
+
 // ---------------------------------------------------------------------------
 // Local (Ollama) adapter
 // ---------------------------------------------------------------------------
@@ -127,6 +159,7 @@ func newLocalAdapter(cfg *ChatProviderConfig, logger *zap.Logger) (*localAdapter
 	client, err := localchats.NewClient(&localchats.Config{
 		Host:  cfg.LocalHost,
 		Model: cfg.LocalModel,
+		Retry: cfg.Retry,
 	}, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create local LLM chat client: %w", err)
@@ -135,12 +168,15 @@ func newLocalAdapter(cfg *ChatProviderConfig, logger *zap.Logger) (*localAdapter
 }
 
 func (a *localAdapter) Completion(ctx context.Context, messages []Message, opts *ChatOptions) (*ChatResponse, error) {
-	localMsgs := toLocalMessages(messages)
+	localMsgs, err := toLocalMessages(messages)
+	if err != nil {
+		return nil, err
+	}
 	localOpts := toLocalOptions(opts)
 
 	resp, err := a.client.Completion(ctx, localMsgs, localOpts)
 	if err != nil {
-		return nil, err
+		return nil, Classify(ProviderLocal, err)
 	}
 
 	// Ollama doesn't report standard token counts; approximate from eval counts.
@@ -156,15 +192,19 @@ func (a *localAdapter) Completion(ctx context.Context, messages []Message, opts
 }
 
 func (a *localAdapter) CompletionStream(ctx context.Context, messages []Message, opts *ChatOptions, onDelta func(delta ChatStreamDelta) error) error {
-	localMsgs := toLocalMessages(messages)
+	localMsgs, err := toLocalMessages(messages)
+	if err != nil {
+		return err
+	}
 	localOpts := toLocalOptions(opts)
 
-	return a.client.CompletionStream(ctx, localMsgs, localOpts, func(chunk localchats.StreamChunk) error {
+	err = a.client.CompletionStream(ctx, localMsgs, localOpts, func(chunk localchats.StreamChunk) error {
 		return onDelta(ChatStreamDelta{
 			Content: chunk.Message.Content,
 			Done:    chunk.Done,
 		})
 	})
+	return Classify(ProviderLocal, err)
 }
 
 func (a *localAdapter) Health(ctx context.Context) error {
@@ -179,6 +219,97 @@ func (a *localAdapter) GetModel() string {
 	return a.client.GetModel()
 }
 
+func (a *localAdapter) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	models, err := a.client.ListModels(ctx)
+	if err != nil {
+		return nil, Classify(ProviderLocal, err)
+	}
+
+	infos := make([]ModelInfo, 0, len(models))
+	for _, m := range models {
+		info := ModelInfo{
+			ID:       m.Name,
+			Provider: string(ProviderLocal),
+			OwnedBy:  "local",
+			Size:     m.Size,
+		}
+		// Ollama reports a "clip" family for models with a vision projector.
+		for _, family := range m.Details.Families {
+			if family == "clip" || family == "mllama" {
+				info.Modalities = []string{ModalityText, ModalityImage}
+			}
+		}
+		infos = append(infos, annotate(info))
+	}
+	return infos, nil
+}
+
+func (a *localAdapter) PullModel(ctx context.Context, model string, onProgress func(progress PullProgress) error) error {
+	err := a.client.Pull(ctx, model, pullCallback(onProgress))
+	return Classify(ProviderLocal, err)
+}
+
+func (a *localAdapter) EnsureModel(ctx context.Context, model string, onProgress func(progress PullProgress) error) error {
+	err := a.client.EnsureModel(ctx, model, pullCallback(onProgress))
+	return Classify(ProviderLocal, err)
+}
+
+func (a *localAdapter) ShowModel(ctx context.Context, model string) (*ModelDetail, error) {
+	resp, err := a.client.Show(ctx, model)
+	if err != nil {
+		return nil, Classify(ProviderLocal, err)
+	}
+
+	info := ModelInfo{
+		ID:            model,
+		Provider:      string(ProviderLocal),
+		OwnedBy:       "local",
+		ContextWindow: resp.ContextLength(),
+	}
+	for _, capability := range resp.Capabilities {
+		switch capability {
+		case "vision":
+			info.Modalities = []string{ModalityText, ModalityImage}
+		case "embedding":
+			info.Modalities = []string{ModalityEmbedding}
+		case "tools":
+			info.SupportsTools = true
+		}
+	}
+
+	return &ModelDetail{
+		ModelInfo:         annotate(info),
+		Family:            resp.Details.Family,
+		ParameterSize:     resp.Details.ParameterSize,
+		QuantizationLevel: resp.Details.QuantizationLevel,
+		Parameters:        resp.Parameters,
+		Template:          resp.Template,
+		ModifiedAt:        resp.ModifiedAt,
+	}, nil
+}
+
+func (a *localAdapter) DeleteModel(ctx context.Context, model string) error {
+	return Classify(ProviderLocal, a.client.Delete(ctx, model))
+}
+
+func (a *localAdapter) ListRunningModels(ctx context.Context) ([]RunningModel, error) {
+	models, err := a.client.ListRunning(ctx)
+	if err != nil {
+		return nil, Classify(ProviderLocal, err)
+	}
+
+	running := make([]RunningModel, 0, len(models))
+	for _, m := range models {
+		running = append(running, RunningModel{
+			ID:        m.Name,
+			Size:      m.Size,
+			SizeVRAM:  m.SizeVRAM,
+			ExpiresAt: m.ExpiresAt,
+		})
+	}
+	return running, nil
+}
+
 // ---------------------------------------------------------------------------
 // Type conversion helpers
 // ---------------------------------------------------------------------------
@@ -187,6 +318,22 @@ func toOpenAIMessages(msgs []Message) []openaichats.Message {
 	out := make([]openaichats.Message, len(msgs))
 	for i, m := range msgs {
 		out[i] = openaichats.Message{Role: m.Role, Content: m.Content}
+		if len(m.Parts) == 0 {
+			continue
+		}
+		for _, p := range m.AllParts() {
+			part := openaichats.ContentPart{Type: PartText, Text: p.Text}
+			if p.IsImage() {
+				part = openaichats.ContentPart{
+					Type:     PartImageURL,
+					ImageURL: &openaichats.ImageURL{URL: p.DataURL()},
+				}
+				if p.ImageURL != nil {
+					part.ImageURL.Detail = p.ImageURL.Detail
+				}
+			}
+			out[i].Parts = append(out[i].Parts, part)
+		}
 	}
 	return out
 }
@@ -196,6 +343,7 @@ func toOpenAIOptions(opts *ChatOptions) *openaichats.Options {
 		return nil
 	}
 	return &openaichats.Options{
+		Model:       opts.Model,
 		Temperature: opts.Temperature,
 		TopP:        opts.TopP,
 		MaxTokens:   opts.MaxTokens,
@@ -203,12 +351,27 @@ func toOpenAIOptions(opts *ChatOptions) *openaichats.Options {
 	}
 }
 
-func toLocalMessages(msgs []Message) []localchats.Message {
+// toLocalMessages flattens multimodal messages into Ollama's text plus
+// base64 images form. Ollama can't fetch remote images, so only inline and
+// data: URL images are accepted.
+func toLocalMessages(msgs []Message) ([]localchats.Message, error) {
 	out := make([]localchats.Message, len(msgs))
 	for i, m := range msgs {
-		out[i] = localchats.Message{Role: m.Role, Content: m.Content}
+		out[i] = localchats.Message{Role: m.Role, Content: m.Text()}
+		for _, p := range m.Images() {
+			data, err := p.ImageBytes()
+			if err != nil {
+				return nil, &Error{
+					Kind:     ErrInvalidRequest,
+					Provider: ProviderLocal,
+					Message:  fmt.Sprintf("local models need inline image data: %v", err),
+					Err:      err,
+				}
+			}
+			out[i].Images = append(out[i].Images, base64.StdEncoding.EncodeToString(data))
+		}
 	}
-	return out
+	return out, nil
 }
 
 func toLocalOptions(opts *ChatOptions) *localchats.Options {
@@ -216,9 +379,25 @@ func toLocalOptions(opts *ChatOptions) *localchats.Options {
 		return nil
 	}
 	return &localchats.Options{
+		Model:       opts.Model,
 		Temperature: opts.Temperature,
 		TopP:        opts.TopP,
 		MaxTokens:   opts.MaxTokens,
 		Stop:        opts.Stop,
 	}
 }
+
+// pullCallback adapts an ai progress callback to the local client.
+func pullCallback(onProgress func(progress PullProgress) error) func(localchats.PullProgress) error {
+	if onProgress == nil {
+		return nil
+	}
+	return func(p localchats.PullProgress) error {
+		return onProgress(PullProgress{
+			Status:    p.Status,
+			Digest:    p.Digest,
+			Total:     p.Total,
+			Completed: p.Completed,
+		})
+	}
+}
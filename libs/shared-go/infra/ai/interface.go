@@ -12,4 +12,47 @@ type ChatProvider interface {
 	IsEnabled() bool
 
 	GetModel() string
+
+	// ListModels returns the models the provider can serve, annotated with
+	// the known capabilities from the local catalog.
+	ListModels(ctx context.Context) ([]ModelInfo, error)
+}
+
+// ModelManager is implemented by providers whose models can be installed and
+// removed at runtime (currently Ollama). Use AsModelManager to reach it
+// through decorators.
+type ModelManager interface {
+	// PullModel downloads a model, reporting progress to onProgress.
+	PullModel(ctx context.Context, model string, onProgress func(progress PullProgress) error) error
+
+	// EnsureModel pulls a model only if it is not already present.
+	EnsureModel(ctx context.Context, model string, onProgress func(progress PullProgress) error) error
+
+	ShowModel(ctx context.Context, model string) (*ModelDetail, error)
+
+	DeleteModel(ctx context.Context, model string) error
+
+	// ListRunningModels returns the models currently loaded into memory.
+	ListRunningModels(ctx context.Context) ([]RunningModel, error)
+}
+
+// Wrapper is implemented by ChatProvider decorators to expose the provider
+// they wrap.
+type Wrapper interface {
+	Unwrap() ChatProvider
+}
+
+// AsModelManager returns the ModelManager behind p, unwrapping decorators.
+func AsModelManager(p ChatProvider) (ModelManager, bool) {
+	for p != nil {
+		if m, ok := p.(ModelManager); ok {
+			return m, true
+		}
+		w, ok := p.(Wrapper)
+		if !ok {
+			break
+		}
+		p = w.Unwrap()
+	}
+	return nil, false
 }
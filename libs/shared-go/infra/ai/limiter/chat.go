@@ -0,0 +1,91 @@
+package limiter
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/tokenizer"
+)
+
+// chatProvider wraps an ai.ChatProvider so every completion first waits for
+// the limiter.
+type chatProvider struct {
+	ai.ChatProvider
+	limiter *Limiter
+}
+
+// NewChatProvider returns provider rate limited by l.
+func NewChatProvider(provider ai.ChatProvider, l *Limiter) ai.ChatProvider {
+	return &chatProvider{ChatProvider: provider, limiter: l}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *chatProvider) Unwrap() ai.ChatProvider {
+	return p.ChatProvider
+}
+
+func (p *chatProvider) Completion(ctx context.Context, messages []ai.Message, opts *ai.ChatOptions) (*ai.ChatResponse, error) {
+	reservation, err := p.limiter.Wait(ctx, PriorityFrom(ctx), p.estimate(messages, opts))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.ChatProvider.Completion(ctx, messages, opts)
+	if err != nil {
+		// A rejected request may still have been counted upstream; keep the
+		// estimate rather than refunding it.
+		return nil, err
+	}
+
+	if resp.Usage.TotalTokens > 0 {
+		reservation.Reconcile(resp.Usage.TotalTokens)
+	}
+	return resp, nil
+}
+
+func (p *chatProvider) CompletionStream(ctx context.Context, messages []ai.Message, opts *ai.ChatOptions, onDelta func(delta ai.ChatStreamDelta) error) error {
+	reservation, err := p.limiter.Wait(ctx, PriorityFrom(ctx), p.estimate(messages, opts))
+	if err != nil {
+		return err
+	}
+
+	// Streams don't report usage, so count the prompt and what was streamed.
+	tok := p.tokenizer(opts)
+	var completion strings.Builder
+	err = p.ChatProvider.CompletionStream(ctx, messages, opts, func(delta ai.ChatStreamDelta) error {
+		completion.WriteString(delta.Content)
+		return onDelta(delta)
+	})
+	if err == nil {
+		reservation.Reconcile(promptTokens(tok, messages) + tok.Count(completion.String()))
+	}
+	return err
+}
+
+// estimate approximates the tokens a request will consume: the prompt plus
+// the maximum completion, which is how OpenAI counts against TPM limits.
+func (p *chatProvider) estimate(messages []ai.Message, opts *ai.ChatOptions) int {
+	output := p.limiter.cfg.DefaultOutputTokens
+	if opts != nil && opts.MaxTokens > 0 {
+		output = opts.MaxTokens
+	}
+	return promptTokens(p.tokenizer(opts), messages) + output
+}
+
+// tokenizer returns the tokenizer of the model the request will use.
+func (p *chatProvider) tokenizer(opts *ai.ChatOptions) tokenizer.Tokenizer {
+	if opts != nil && opts.Model != "" {
+		return tokenizer.ForModel(opts.Model)
+	}
+	return tokenizer.ForModel(p.GetModel())
+}
+
+func promptTokens(tok tokenizer.Tokenizer, messages []ai.Message) int {
+	// Each message carries a few tokens of role and separator overhead.
+	total := 3
+	for _, m := range messages {
+		total += 4 + tok.Count(m.Text()) + ai.ImageTokens*len(m.Images())
+	}
+	return total
+}
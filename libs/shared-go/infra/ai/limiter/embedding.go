@@ -0,0 +1,39 @@
+package limiter
+
+import (
+	"context"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/embedding"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/tokenizer"
+)
+
+// embeddingProvider wraps an embedding.Provider so every request first waits
+// for the limiter.
+type embeddingProvider struct {
+	embedding.Provider
+	limiter *Limiter
+}
+
+// NewEmbeddingProvider returns provider rate limited by l.
+func NewEmbeddingProvider(provider embedding.Provider, l *Limiter) embedding.Provider {
+	return &embeddingProvider{Provider: provider, limiter: l}
+}
+
+func (p *embeddingProvider) CreateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	if _, err := p.limiter.Wait(ctx, PriorityFrom(ctx), tokenizer.Count(p.GetModel(), text)); err != nil {
+		return nil, err
+	}
+	return p.Provider.CreateEmbedding(ctx, text)
+}
+
+func (p *embeddingProvider) CreateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	tok := tokenizer.ForModel(p.GetModel())
+	tokens := 0
+	for _, t := range texts {
+		tokens += tok.Count(t)
+	}
+	if _, err := p.limiter.Wait(ctx, PriorityFrom(ctx), tokens); err != nil {
+		return nil, err
+	}
+	return p.Provider.CreateEmbeddings(ctx, texts)
+}
@@ -0,0 +1,225 @@
+package limiter
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultOutputTokens = 512
+
+// bucket is a token bucket refilled continuously at capacity per minute.
+// A zero capacity means unlimited. available may go negative when a call
+// used more tokens than it reserved.
+type bucket struct {
+	capacity  float64
+	available float64
+	last      time.Time
+}
+
+func newBucket(perMinute int, now time.Time) bucket {
+	return bucket{
+		capacity:  float64(perMinute),
+		available: float64(perMinute),
+		last:      now,
+	}
+}
+
+func (b *bucket) refill(now time.Time) {
+	if b.capacity == 0 {
+		return
+	}
+	elapsed := now.Sub(b.last)
+	b.last = now
+	b.available += b.capacity * elapsed.Minutes()
+	if b.available > b.capacity {
+		b.available = b.capacity
+	}
+}
+
+// wait returns how long until cost can be taken while leaving reserve
+// untouched. Zero means it can be taken now.
+func (b *bucket) wait(cost, reserve float64) time.Duration {
+	if b.capacity == 0 {
+		return 0
+	}
+	deficit := cost + reserve - b.available
+	if deficit <= 0 {
+		return 0
+	}
+	return time.Duration(deficit / b.capacity * float64(time.Minute))
+}
+
+type waiter struct {
+	priority Priority
+	seq      uint64
+	tokens   int
+}
+
+// Limiter enforces requests-per-minute and tokens-per-minute budgets for a
+// provider. Callers queue by priority, then arrival order; only the head of
+// the queue may be admitted, so a waiting interactive call always goes ahead
+// of background work.
+type Limiter struct {
+	mu       sync.Mutex
+	cfg      Config
+	requests bucket
+	tokens   bucket
+	queue    []*waiter
+	seq      uint64
+	changed  chan struct{}
+}
+
+// New creates a Limiter from cfg.
+func New(cfg Config) *Limiter {
+	if cfg.DefaultOutputTokens <= 0 {
+		cfg.DefaultOutputTokens = defaultOutputTokens
+	}
+	if cfg.InteractiveReserve < 0 {
+		cfg.InteractiveReserve = 0
+	}
+	if cfg.InteractiveReserve > 1 {
+		cfg.InteractiveReserve = 1
+	}
+
+	now := time.Now()
+	return &Limiter{
+		cfg:      cfg,
+		requests: newBucket(cfg.RequestsPerMinute, now),
+		tokens:   newBucket(cfg.TokensPerMinute, now),
+		changed:  make(chan struct{}),
+	}
+}
+
+// Reservation is an admitted call. Reconcile it with the real token usage
+// once the provider has answered.
+type Reservation struct {
+	limiter  *Limiter
+	reserved int
+	once     sync.Once
+}
+
+// Wait blocks until a call estimated at tokens can proceed at priority p, or
+// ctx is done.
+func (l *Limiter) Wait(ctx context.Context, p Priority, tokens int) (*Reservation, error) {
+	if tokens < 0 {
+		tokens = 0
+	}
+	// A single call larger than the whole budget could never be admitted;
+	// let it through once the bucket is full.
+	if limit := int(l.tokens.capacity); limit > 0 && tokens > limit {
+		tokens = limit
+	}
+
+	l.mu.Lock()
+	l.seq++
+	w := &waiter{priority: p, seq: l.seq, tokens: tokens}
+	l.queue = append(l.queue, w)
+	sort.SliceStable(l.queue, func(i, j int) bool {
+		if l.queue[i].priority != l.queue[j].priority {
+			return l.queue[i].priority > l.queue[j].priority
+		}
+		return l.queue[i].seq < l.queue[j].seq
+	})
+	l.mu.Unlock()
+
+	for {
+		l.mu.Lock()
+		delay := l.admit(w)
+		if delay == 0 {
+			l.broadcastLocked()
+			l.mu.Unlock()
+			return &Reservation{limiter: l, reserved: tokens}, nil
+		}
+		changed := l.changed
+		l.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			l.mu.Lock()
+			l.removeLocked(w)
+			l.broadcastLocked()
+			l.mu.Unlock()
+			return nil, ctx.Err()
+		case <-changed:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// admit takes capacity for w if it is at the head of the queue and both
+// buckets allow it. It returns 0 on success, otherwise how long to wait
+// before trying again.
+func (l *Limiter) admit(w *waiter) time.Duration {
+	now := time.Now()
+	l.requests.refill(now)
+	l.tokens.refill(now)
+
+	if len(l.queue) == 0 || l.queue[0] != w {
+		// Not our turn; we'll be woken when the head is admitted or leaves.
+		return time.Minute
+	}
+
+	var reqReserve, tokReserve float64
+	if w.priority < PriorityInteractive {
+		// Never reserve so much that the call could not fit in a full bucket.
+		reqReserve = min(l.requests.capacity*l.cfg.InteractiveReserve, l.requests.capacity-1)
+		tokReserve = min(l.tokens.capacity*l.cfg.InteractiveReserve, l.tokens.capacity-float64(w.tokens))
+	}
+
+	delay := max(l.requests.wait(1, reqReserve), l.tokens.wait(float64(w.tokens), tokReserve))
+	if delay > 0 {
+		return delay
+	}
+
+	if l.requests.capacity > 0 {
+		l.requests.available--
+	}
+	if l.tokens.capacity > 0 {
+		l.tokens.available -= float64(w.tokens)
+	}
+	l.queue = l.queue[1:]
+	return 0
+}
+
+func (l *Limiter) removeLocked(w *waiter) {
+	for i, q := range l.queue {
+		if q == w {
+			l.queue = append(l.queue[:i], l.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+// broadcastLocked wakes every waiter so the new queue head can try again.
+func (l *Limiter) broadcastLocked() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// Reconcile corrects the token bucket with the tokens the call actually
+// used, refunding over-estimates and charging under-estimates. Only the
+// first call has an effect.
+func (r *Reservation) Reconcile(actual int) {
+	if r == nil {
+		return
+	}
+	r.once.Do(func() {
+		l := r.limiter
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.tokens.capacity == 0 {
+			return
+		}
+		l.tokens.refill(time.Now())
+		l.tokens.available += float64(r.reserved - actual)
+		if l.tokens.available > l.tokens.capacity {
+			l.tokens.available = l.tokens.capacity
+		}
+		l.broadcastLocked()
+	})
+}
@@ -0,0 +1,50 @@
+package limiter
+
+import "context"
+
+// Priority orders callers waiting for the same limiter. Higher values are
+// admitted first.
+type Priority int
+
+const (
+	// PriorityBackground is for bulk work such as ingestion and re-embedding.
+	PriorityBackground Priority = iota
+	// PriorityInteractive is for requests a user is waiting on. It is the
+	// default for callers that don't set a priority.
+	PriorityInteractive
+)
+
+// Config holds the per-minute budgets enforced by a Limiter. Zero disables
+// the corresponding limit.
+type Config struct {
+	RequestsPerMinute int
+	TokensPerMinute   int
+
+	// InteractiveReserve is the fraction (0-1) of each budget that background
+	// callers may not consume, keeping headroom for interactive traffic.
+	InteractiveReserve float64
+
+	// DefaultOutputTokens is reserved for the completion when a chat request
+	// doesn't set MaxTokens (default 512).
+	DefaultOutputTokens int
+}
+
+// IsEnabled returns true if at least one limit is configured.
+func (c *Config) IsEnabled() bool {
+	return c.RequestsPerMinute > 0 || c.TokensPerMinute > 0
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a context whose limited calls use priority p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFrom returns the priority stored in ctx, or PriorityInteractive.
+func PriorityFrom(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityInteractive
+}
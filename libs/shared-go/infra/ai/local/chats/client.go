@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai/retry"
 	"go.uber.org/zap"
 )
 
@@ -20,12 +21,18 @@ const (
 	defaultModel   = "llama3:8b"
 	defaultTimeout = 5 * time.Minute
 	chatEndpoint   = "/api/chat"
+	tagsEndpoint   = "/api/tags"
+	pullEndpoint   = "/api/pull"
+	showEndpoint   = "/api/show"
+	deleteEndpoint = "/api/delete"
+	psEndpoint     = "/api/ps"
 )
 
 type Client struct {
 	host       string
 	model      string
 	httpClient *http.Client
+	retrier    *retry.Retrier
 	logger     *zap.Logger
 	enabled    bool
 }
@@ -51,6 +58,7 @@ func NewClient(cfg *Config, logger *zap.Logger) (*Client, error) {
 		httpClient: &http.Client{
 			Timeout: defaultTimeout,
 		},
+		retrier: retry.New(cfg.Retry, logger),
 		logger:  logger,
 		enabled: true,
 	}
@@ -71,14 +79,14 @@ func (c *Client) Completion(ctx context.Context, messages []Message, opts *Optio
 	}
 
 	reqBody := CompletionRequest{
-		Model:    c.model,
+		Model:    c.resolveModel(opts),
 		Messages: messages,
 		Stream:   false,
 		Options:  opts,
 	}
 
 	c.logger.Debug("Sending completion request",
-		zap.String("model", c.model),
+		zap.String("model", reqBody.Model),
 		zap.Int("message_count", len(messages)))
 
 	body, err := c.doRequest(ctx, reqBody)
@@ -122,14 +130,14 @@ func (c *Client) CompletionStream(ctx context.Context, messages []Message, opts
 	}
 
 	reqBody := CompletionRequest{
-		Model:    c.model,
+		Model:    c.resolveModel(opts),
 		Messages: messages,
 		Stream:   true,
 		Options:  opts,
 	}
 
 	c.logger.Debug("Sending streaming completion request",
-		zap.String("model", c.model),
+		zap.String("model", reqBody.Model),
 		zap.Int("message_count", len(messages)))
 
 	body, err := c.doRequest(ctx, reqBody)
@@ -209,6 +217,14 @@ func (c *Client) GetModel() string {
 	return c.model
 }
 
+// resolveModel returns the per-request model override, or the configured model.
+func (c *Client) resolveModel(opts *Options) string {
+	if opts != nil && opts.Model != "" {
+		return opts.Model
+	}
+	return c.model
+}
+
 // doRequest marshals the request body and sends the HTTP POST to the chat endpoint.
 // Returns the response body (caller must close it).
 func (c *Client) doRequest(ctx context.Context, reqBody CompletionRequest) (io.ReadCloser, error) {
@@ -219,12 +235,15 @@ func (c *Client) doRequest(ctx context.Context, reqBody CompletionRequest) (io.R
 	}
 
 	url := c.host + chatEndpoint
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		c.logger.Error("Failed to create HTTP request", zap.Error(err))
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	build := func() (*http.Request, error) {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonData))
+		if err != nil {
+			c.logger.Error("Failed to create HTTP request", zap.Error(err))
+			return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		return httpReq, nil
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
 
 	// For streaming requests, use a client without a timeout
 	// so the connection stays open for the duration of generation.
@@ -233,20 +252,66 @@ func (c *Client) doRequest(ctx context.Context, reqBody CompletionRequest) (io.R
 		httpClient = &http.Client{} // no timeout for streaming
 	}
 
-	resp, err := httpClient.Do(httpReq)
+	// Streams are not idempotent: the retrier only resends them when the
+	// server rejected the request outright, never after output started.
+	resp, err := c.retrier.Do(ctx, httpClient, !reqBody.Stream, build)
 	if err != nil {
 		c.logger.Error("Failed to send HTTP request", zap.Error(err))
 		return nil, fmt.Errorf("failed to send HTTP request: %w", err)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		defer resp.Body.Close()
-		body, _ := io.ReadAll(resp.Body)
-		c.logger.Error("LLM API error",
-			zap.Int("status", resp.StatusCode),
-			zap.String("body", string(body)))
-		return nil, fmt.Errorf("LLM API error (status %d): %s", resp.StatusCode, string(body))
+		return nil, c.responseError(resp)
 	}
 
 	return resp.Body, nil
 }
+
+// ListModels returns the models that have been pulled onto the local server.
+func (c *Client) ListModels(ctx context.Context) ([]Model, error) {
+	if !c.enabled {
+		return nil, errors.New("local LLM client is not enabled")
+	}
+
+	build := func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.host+tagsEndpoint, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+		}
+		return req, nil
+	}
+
+	resp, err := c.retrier.Do(ctx, c.httpClient, true, build)
+	if err != nil {
+		c.logger.Error("Failed to list models", zap.Error(err))
+		return nil, fmt.Errorf("failed to send HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, c.responseError(resp)
+	}
+
+	var list ModelList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		c.logger.Error("Failed to unmarshal model list", zap.Error(err))
+		return nil, fmt.Errorf("failed to unmarshal model list: %w", err)
+	}
+	return list.Models, nil
+}
+
+// responseError consumes a non-2xx response and returns it as a *RequestError.
+func (c *Client) responseError(resp *http.Response) error {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	c.logger.Error("LLM API error",
+		zap.Int("status", resp.StatusCode),
+		zap.String("body", string(body)))
+
+	message := string(body)
+	var errBody errorBody
+	if jsonErr := json.Unmarshal(body, &errBody); jsonErr == nil && errBody.Error != "" {
+		message = errBody.Error
+	}
+	return &RequestError{StatusCode: resp.StatusCode, Message: message}
+}
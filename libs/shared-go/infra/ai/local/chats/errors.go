@@ -0,0 +1,23 @@
+package chats
+
+import "fmt"
+
+// RequestError is returned when the local LLM server answers with a non-2xx status.
+type RequestError struct {
+	StatusCode int
+	Message    string // Ollama's "error" field, or the raw body if it wasn't JSON
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("LLM API error (status %d): %s", e.StatusCode, e.Message)
+}
+
+// HTTPStatus returns the HTTP status code of the failed response.
+func (e *RequestError) HTTPStatus() int {
+	return e.StatusCode
+}
+
+// errorBody is the JSON error shape returned by Ollama.
+type errorBody struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,193 @@
+package chats
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Pull downloads model onto the local server, reporting progress to
+// onProgress (which may be nil). Pulling a model that is already present
+// only verifies its layers.
+func (c *Client) Pull(ctx context.Context, model string, onProgress func(progress PullProgress) error) error {
+	if !c.enabled {
+		return errors.New("local LLM client is not enabled")
+	}
+	if model == "" {
+		return errors.New("model is required")
+	}
+
+	c.logger.Info("Pulling local model", zap.String("model", model))
+
+	// Downloads can take far longer than the request timeout.
+	body, err := c.send(ctx, &http.Client{}, http.MethodPost, pullEndpoint, PullRequest{Model: model, Stream: true})
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var progress PullProgress
+		if err := json.Unmarshal([]byte(line), &progress); err != nil {
+			c.logger.Error("Failed to unmarshal pull progress",
+				zap.Error(err),
+				zap.String("raw", line))
+			return fmt.Errorf("failed to unmarshal pull progress: %w", err)
+		}
+
+		// Ollama reports failures after the stream has started as an error line.
+		if progress.Error != "" {
+			c.logger.Error("Model pull failed",
+				zap.String("model", model),
+				zap.String("error", progress.Error))
+			return &RequestError{StatusCode: http.StatusInternalServerError, Message: progress.Error}
+		}
+
+		if onProgress != nil {
+			if err := onProgress(progress); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		c.logger.Error("Error reading pull stream", zap.Error(err))
+		return fmt.Errorf("error reading pull stream: %w", err)
+	}
+
+	c.logger.Info("Local model pulled", zap.String("model", model))
+	return nil
+}
+
+// Show returns the details of a pulled model.
+func (c *Client) Show(ctx context.Context, model string) (*ShowResponse, error) {
+	if !c.enabled {
+		return nil, errors.New("local LLM client is not enabled")
+	}
+	if model == "" {
+		return nil, errors.New("model is required")
+	}
+
+	body, err := c.send(ctx, c.httpClient, http.MethodPost, showEndpoint, ModelRequest{Model: model})
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var resp ShowResponse
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		c.logger.Error("Failed to unmarshal show response", zap.Error(err))
+		return nil, fmt.Errorf("failed to unmarshal show response: %w", err)
+	}
+	return &resp, nil
+}
+
+// Delete removes a model from the local server.
+func (c *Client) Delete(ctx context.Context, model string) error {
+	if !c.enabled {
+		return errors.New("local LLM client is not enabled")
+	}
+	if model == "" {
+		return errors.New("model is required")
+	}
+
+	body, err := c.send(ctx, c.httpClient, http.MethodDelete, deleteEndpoint, ModelRequest{Model: model})
+	if err != nil {
+		return err
+	}
+	body.Close()
+
+	c.logger.Info("Local model deleted", zap.String("model", model))
+	return nil
+}
+
+// ListRunning returns the models currently loaded into memory.
+func (c *Client) ListRunning(ctx context.Context) ([]RunningModel, error) {
+	if !c.enabled {
+		return nil, errors.New("local LLM client is not enabled")
+	}
+
+	body, err := c.send(ctx, c.httpClient, http.MethodGet, psEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var list RunningModelList
+	if err := json.NewDecoder(body).Decode(&list); err != nil {
+		c.logger.Error("Failed to unmarshal running model list", zap.Error(err))
+		return nil, fmt.Errorf("failed to unmarshal running model list: %w", err)
+	}
+	return list.Models, nil
+}
+
+// EnsureModel pulls model unless the server already has it.
+func (c *Client) EnsureModel(ctx context.Context, model string, onProgress func(progress PullProgress) error) error {
+	_, err := c.Show(ctx, model)
+	if err == nil {
+		return nil
+	}
+
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) || reqErr.StatusCode != http.StatusNotFound {
+		return err
+	}
+	return c.Pull(ctx, model, onProgress)
+}
+
+// send issues a management request with an optional JSON payload and returns
+// the response body (caller must close it). Every management call is safe to
+// repeat, so transient failures are retried.
+func (c *Client) send(ctx context.Context, httpClient *http.Client, method, endpoint string, payload any) (io.ReadCloser, error) {
+	var jsonData []byte
+	if payload != nil {
+		var err error
+		if jsonData, err = json.Marshal(payload); err != nil {
+			c.logger.Error("Failed to marshal request", zap.Error(err))
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	url := c.host + endpoint
+	build := func() (*http.Request, error) {
+		var body io.Reader
+		if jsonData != nil {
+			body = bytes.NewReader(jsonData)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, url, body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+		}
+		if jsonData != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		return req, nil
+	}
+
+	resp, err := c.retrier.Do(ctx, httpClient, true, build)
+	if err != nil {
+		c.logger.Error("Failed to send HTTP request",
+			zap.String("endpoint", endpoint),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to send HTTP request: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, c.responseError(resp)
+	}
+	return resp.Body, nil
+}
@@ -1,5 +1,12 @@
 package chats
 
+import (
+	"strings"
+	"time"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai/retry"
+)
+
 // Role constants for chat messages.
 const (
 	RoleSystem    = "system"
@@ -9,8 +16,9 @@ const (
 
 // Config holds the configuration for the local LLM client.
 type Config struct {
-	Host  string // e.g. "http://localhost:11434" (Ollama default)
-	Model string // e.g. "llama3:8b"
+	Host  string        // e.g. "http://localhost:11434" (Ollama default)
+	Model string        // e.g. "llama3:8b"
+	Retry *retry.Policy // Optional: nil uses retry.DefaultPolicy()
 }
 
 // IsValid returns true if the configuration has the minimum required fields.
@@ -20,8 +28,9 @@ func (c *Config) IsValid() bool {
 
 // Message represents a single chat message.
 type Message struct {
-	Role    string `json:"role"`    // "system", "user", or "assistant"
-	Content string `json:"content"` // The message content
+	Role    string   `json:"role"`             // "system", "user", or "assistant"
+	Content string   `json:"content"`          // The message content
+	Images  []string `json:"images,omitempty"` // Base64-encoded images for vision models
 }
 
 // CompletionRequest is the payload sent to the local LLM for a chat completion.
@@ -34,10 +43,11 @@ type CompletionRequest struct {
 
 // Options are optional model-level parameters.
 type Options struct {
-	Temperature float64 `json:"temperature,omitempty"`
-	TopP        float64 `json:"top_p,omitempty"`
-	TopK        int     `json:"top_k,omitempty"`
-	MaxTokens   int     `json:"num_predict,omitempty"` // Ollama uses "num_predict"
+	Model       string   `json:"-"` // Overrides the configured model; sent as the top-level "model"
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        float64  `json:"top_p,omitempty"`
+	TopK        int      `json:"top_k,omitempty"`
+	MaxTokens   int      `json:"num_predict,omitempty"` // Ollama uses "num_predict"
 	Stop        []string `json:"stop,omitempty"`
 }
 
@@ -75,3 +85,88 @@ type StreamChunk struct {
 	EvalDuration       int64 `json:"eval_duration,omitempty"`
 }
 
+// ModelDetails describes the architecture of a local model.
+type ModelDetails struct {
+	Format            string   `json:"format"`
+	Family            string   `json:"family"`
+	Families          []string `json:"families"`
+	ParameterSize     string   `json:"parameter_size"`
+	QuantizationLevel string   `json:"quantization_level"`
+}
+
+// Model is a single locally available model as returned by /api/tags.
+type Model struct {
+	Name       string       `json:"name"`
+	Model      string       `json:"model"`
+	ModifiedAt string       `json:"modified_at"`
+	Size       int64        `json:"size"`
+	Digest     string       `json:"digest"`
+	Details    ModelDetails `json:"details"`
+}
+
+// ModelList is the response of GET /api/tags.
+type ModelList struct {
+	Models []Model `json:"models"`
+}
+
+// PullRequest is the payload of POST /api/pull.
+type PullRequest struct {
+	Model  string `json:"model"`
+	Stream bool   `json:"stream"`
+}
+
+// PullProgress is one line of a streamed pull. Total and Completed are only
+// set while a layer is downloading.
+type PullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ModelRequest names a model for /api/show and /api/delete.
+type ModelRequest struct {
+	Model string `json:"model"`
+}
+
+// ShowResponse is the response of POST /api/show.
+type ShowResponse struct {
+	Modelfile    string         `json:"modelfile"`
+	Parameters   string         `json:"parameters"`
+	Template     string         `json:"template"`
+	License      string         `json:"license"`
+	ModifiedAt   string         `json:"modified_at"`
+	Details      ModelDetails   `json:"details"`
+	ModelInfo    map[string]any `json:"model_info"`
+	Capabilities []string       `json:"capabilities"`
+}
+
+// ContextLength returns the trained context length reported in ModelInfo,
+// stored under "<architecture>.context_length", or 0 if absent.
+func (r *ShowResponse) ContextLength() int {
+	for key, value := range r.ModelInfo {
+		if strings.HasSuffix(key, ".context_length") {
+			if n, ok := value.(float64); ok {
+				return int(n)
+			}
+		}
+	}
+	return 0
+}
+
+// RunningModel is a model currently loaded into memory, as returned by /api/ps.
+type RunningModel struct {
+	Name      string       `json:"name"`
+	Model     string       `json:"model"`
+	Size      int64        `json:"size"`
+	SizeVRAM  int64        `json:"size_vram"`
+	Digest    string       `json:"digest"`
+	Details   ModelDetails `json:"details"`
+	ExpiresAt time.Time    `json:"expires_at"`
+}
+
+// RunningModelList is the response of GET /api/ps.
+type RunningModelList struct {
+	Models []RunningModel `json:"models"`
+}
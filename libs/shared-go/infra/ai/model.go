@@ -1,5 +1,7 @@
 package ai
 
+import "time"
+
 type ProviderType string
 
 const (
@@ -7,28 +9,44 @@ const (
 	ProviderLocal  ProviderType = "local"
 )
 
+// Modalities a model can accept as input.
+const (
+	ModalityText      = "text"
+	ModalityImage     = "image"
+	ModalityEmbedding = "embedding"
+)
+
 const (
 	RoleSystem    = "system"
 	RoleUser      = "user"
 	RoleAssistant = "assistant"
 )
 
+// Message is a chat message. Text-only messages use Content; multimodal
+// messages add Parts. In JSON, "content" is a string or an array of parts.
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string
+	Content string
+	Parts   []ContentPart
 }
 
 type ChatOptions struct {
-	Temperature float64  `json:"temperature,omitempty"`
+	Model       string   `json:"model,omitempty"`       // empty uses the provider's configured model
+	Temperature *float64 `json:"temperature,omitempty"` // nil uses the provider default
 	TopP        float64  `json:"top_p,omitempty"`
 	MaxTokens   int      `json:"max_tokens,omitempty"`
 	Stop        []string `json:"stop,omitempty"`
+
+	// Cache opts a request into response caching even when it isn't
+	// deterministic (temperature 0 requests are cached automatically).
+	Cache bool `json:"cache,omitempty"`
 }
 
 type ChatResponse struct {
-	Model   string    `json:"model"`
-	Content string    `json:"content"`
-	Usage   ChatUsage `json:"usage"`
+	Model   string     `json:"model"`
+	Content string     `json:"content"`
+	Usage   ChatUsage  `json:"usage"`
+	Prompt  *PromptRef `json:"prompt,omitempty"` // Set by callers that used a prompt template
 }
 
 type ChatUsage struct {
@@ -38,7 +56,53 @@ type ChatUsage struct {
 }
 
 type ChatStreamDelta struct {
-	Content      string `json:"content"`
-	Done         bool   `json:"done"`
-	FinishReason string `json:"finish_reason,omitempty"`
+	Content      string     `json:"content"`
+	Done         bool       `json:"done"`
+	FinishReason string     `json:"finish_reason,omitempty"`
+	Prompt       *PromptRef `json:"prompt,omitempty"` // Set on the final delta, like ChatResponse.Prompt
+}
+
+// ModelInfo describes a model offered by a provider.
+type ModelInfo struct {
+	ID            string   `json:"id"`
+	Provider      string   `json:"provider"`                 // Provider name (the provider type unless renamed)
+	ContextWindow int      `json:"context_window,omitempty"` // In tokens, 0 if unknown
+	Modalities    []string `json:"modalities,omitempty"`     // Input modalities, e.g. text and image
+	SupportsTools bool     `json:"supports_tools"`
+	OwnedBy       string   `json:"owned_by,omitempty"`
+	Size          int64    `json:"size,omitempty"` // Bytes on disk, local models only
+}
+
+// PullProgress reports the progress of a model download.
+type PullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`     // Bytes in the current layer
+	Completed int64  `json:"completed,omitempty"` // Bytes downloaded of the current layer
+}
+
+// ModelDetail is the full description of an installed model.
+type ModelDetail struct {
+	ModelInfo
+	Family            string `json:"family,omitempty"`
+	ParameterSize     string `json:"parameter_size,omitempty"`
+	QuantizationLevel string `json:"quantization_level,omitempty"`
+	Parameters        string `json:"parameters,omitempty"`
+	Template          string `json:"template,omitempty"`
+	ModifiedAt        string `json:"modified_at,omitempty"`
+}
+
+// RunningModel is a model loaded into memory.
+type RunningModel struct {
+	ID        string    `json:"id"`
+	Size      int64     `json:"size"`
+	SizeVRAM  int64     `json:"size_vram"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PromptRef identifies the prompt template version a response was generated
+// with.
+type PromptRef struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
 }
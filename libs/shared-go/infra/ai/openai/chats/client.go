@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai/retry"
 	"go.uber.org/zap"
 )
 
@@ -19,12 +20,14 @@ const (
 	defaultModel   = "gpt-4o-mini"
 	defaultTimeout = 2 * time.Minute
 	chatAPIURL     = "https://api.openai.com/v1/chat/completions"
+	modelsAPIURL   = "https://api.openai.com/v1/models"
 )
 
 type Client struct {
 	apiKey     string
 	model      string
 	httpClient *http.Client
+	retrier    *retry.Retrier
 	logger     *zap.Logger
 	enabled    bool
 }
@@ -48,6 +51,7 @@ func NewClient(cfg *Config, logger *zap.Logger) (*Client, error) {
 		httpClient: &http.Client{
 			Timeout: defaultTimeout,
 		},
+		retrier: retry.New(cfg.Retry, logger),
 		logger:  logger,
 		enabled: true,
 	}
@@ -69,7 +73,7 @@ func (c *Client) Completion(ctx context.Context, messages []Message, opts *Optio
 	reqBody := c.buildRequest(messages, false, opts)
 
 	c.logger.Debug("Sending completion request",
-		zap.String("model", c.model),
+		zap.String("model", reqBody.Model),
 		zap.Int("message_count", len(messages)))
 
 	body, err := c.doRequest(ctx, reqBody)
@@ -116,7 +120,7 @@ func (c *Client) CompletionStream(ctx context.Context, messages []Message, opts
 	reqBody := c.buildRequest(messages, true, opts)
 
 	c.logger.Debug("Sending streaming completion request",
-		zap.String("model", c.model),
+		zap.String("model", reqBody.Model),
 		zap.Int("message_count", len(messages)))
 
 	body, err := c.doRequest(ctx, reqBody)
@@ -171,7 +175,7 @@ func (c *Client) Health(ctx context.Context) error {
 		return errors.New("OpenAI chat client is not enabled")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.openai.com/v1/models", nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, modelsAPIURL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create health check request: %w", err)
 	}
@@ -210,8 +214,11 @@ func (c *Client) buildRequest(messages []Message, stream bool, opts *Options) Co
 	}
 
 	if opts != nil {
-		if opts.Temperature != 0 {
-			req.Temperature = &opts.Temperature
+		if opts.Model != "" {
+			req.Model = opts.Model
+		}
+		if opts.Temperature != nil {
+			req.Temperature = opts.Temperature
 		}
 		if opts.TopP != 0 {
 			req.TopP = &opts.TopP
@@ -236,13 +243,16 @@ func (c *Client) doRequest(ctx context.Context, reqBody CompletionRequest) (io.R
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, chatAPIURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		c.logger.Error("Failed to create HTTP request", zap.Error(err))
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	build := func() (*http.Request, error) {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, chatAPIURL, bytes.NewReader(jsonData))
+		if err != nil {
+			c.logger.Error("Failed to create HTTP request", zap.Error(err))
+			return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+		return httpReq, nil
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
 
 	// For streaming requests, use a client without a timeout
 	// so the connection stays open for the duration of generation.
@@ -251,30 +261,76 @@ func (c *Client) doRequest(ctx context.Context, reqBody CompletionRequest) (io.R
 		httpClient = &http.Client{} // no timeout for streaming
 	}
 
-	resp, err := httpClient.Do(httpReq)
+	// Streams are not idempotent: the retrier only resends them when the
+	// server rejected the request outright, never after output started.
+	resp, err := c.retrier.Do(ctx, httpClient, !reqBody.Stream, build)
 	if err != nil {
 		c.logger.Error("Failed to send HTTP request", zap.Error(err))
 		return nil, fmt.Errorf("failed to send HTTP request: %w", err)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		defer resp.Body.Close()
-		body, _ := io.ReadAll(resp.Body)
-
-		var apiErr APIError
-		if jsonErr := json.Unmarshal(body, &apiErr); jsonErr == nil && apiErr.Error.Message != "" {
-			c.logger.Error("OpenAI API error",
-				zap.Int("status", resp.StatusCode),
-				zap.String("type", apiErr.Error.Type),
-				zap.String("message", apiErr.Error.Message))
-			return nil, fmt.Errorf("OpenAI API error (status %d): %s", resp.StatusCode, apiErr.Error.Message)
+		return nil, c.responseError(resp)
+	}
+
+	return resp.Body, nil
+}
+
+// ListModels returns the models available to the configured API key.
+func (c *Client) ListModels(ctx context.Context) ([]Model, error) {
+	if !c.enabled {
+		return nil, errors.New("OpenAI chat client is not enabled")
+	}
+
+	build := func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, modelsAPIURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 		}
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+		return req, nil
+	}
+
+	resp, err := c.retrier.Do(ctx, c.httpClient, true, build)
+	if err != nil {
+		c.logger.Error("Failed to list models", zap.Error(err))
+		return nil, fmt.Errorf("failed to send HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, c.responseError(resp)
+	}
 
+	var list ModelList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		c.logger.Error("Failed to unmarshal model list", zap.Error(err))
+		return nil, fmt.Errorf("failed to unmarshal model list: %w", err)
+	}
+	return list.Data, nil
+}
+
+// responseError consumes a non-2xx response and returns it as a *RequestError.
+func (c *Client) responseError(resp *http.Response) error {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	var apiErr APIError
+	if jsonErr := json.Unmarshal(body, &apiErr); jsonErr == nil && apiErr.Error.Message != "" {
 		c.logger.Error("OpenAI API error",
 			zap.Int("status", resp.StatusCode),
-			zap.String("body", string(body)))
-		return nil, fmt.Errorf("OpenAI API error (status %d): %s", resp.StatusCode, string(body))
+			zap.String("type", apiErr.Error.Type),
+			zap.String("message", apiErr.Error.Message))
+		return &RequestError{
+			StatusCode: resp.StatusCode,
+			Type:       apiErr.Error.Type,
+			Code:       apiErr.Error.Code,
+			Message:    apiErr.Error.Message,
+		}
 	}
 
-	return resp.Body, nil
+	c.logger.Error("OpenAI API error",
+		zap.Int("status", resp.StatusCode),
+		zap.String("body", string(body)))
+	return &RequestError{StatusCode: resp.StatusCode, Message: string(body)}
 }
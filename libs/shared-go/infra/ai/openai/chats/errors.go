@@ -0,0 +1,29 @@
+package chats
+
+import "fmt"
+
+// RequestError is returned when the OpenAI API answers with a non-2xx status.
+type RequestError struct {
+	StatusCode int
+	Type       string // e.g. "invalid_request_error"
+	Code       string // e.g. "context_length_exceeded"; may be empty
+	Message    string
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("OpenAI API error (status %d): %s", e.StatusCode, e.Message)
+}
+
+// HTTPStatus returns the HTTP status code of the failed response.
+func (e *RequestError) HTTPStatus() int {
+	return e.StatusCode
+}
+
+// ErrorCode returns the machine-readable OpenAI error code, falling back to
+// the error type when the API didn't send one.
+func (e *RequestError) ErrorCode() string {
+	if e.Code != "" {
+		return e.Code
+	}
+	return e.Type
+}
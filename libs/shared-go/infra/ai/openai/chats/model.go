@@ -1,5 +1,11 @@
 package chats
 
+import (
+	"encoding/json"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai/retry"
+)
+
 // Role constants for chat messages.
 const (
 	RoleSystem    = "system"
@@ -9,8 +15,9 @@ const (
 
 // Config holds the configuration for the OpenAI chat completion client.
 type Config struct {
-	APIKey string // Required: OpenAI API key
-	Model  string // e.g. "gpt-4o", "gpt-4o-mini", "gpt-3.5-turbo"
+	APIKey string        // Required: OpenAI API key
+	Model  string        // e.g. "gpt-4o", "gpt-4o-mini", "gpt-3.5-turbo"
+	Retry  *retry.Policy // Optional: nil uses retry.DefaultPolicy()
 }
 
 // IsValid returns true if the configuration has the minimum required fields.
@@ -20,8 +27,34 @@ func (c *Config) IsValid() bool {
 
 // Message represents a single chat message.
 type Message struct {
-	Role    string `json:"role"`    // "system", "user", or "assistant"
-	Content string `json:"content"` // The message content
+	Role    string        `json:"role"`    // "system", "user", or "assistant"
+	Content string        `json:"content"` // The message content
+	Parts   []ContentPart `json:"-"`       // Multimodal content; sent in place of Content when set
+}
+
+// ContentPart is a text or image_url entry of a multimodal message.
+type ContentPart struct {
+	Type     string    `json:"type"` // "text" or "image_url"
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+}
+
+// ImageURL is an http(s) or base64 data: URL image.
+type ImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// MarshalJSON sends Parts as the content array when present.
+func (m Message) MarshalJSON() ([]byte, error) {
+	if len(m.Parts) == 0 {
+		type plain Message
+		return json.Marshal(plain(m))
+	}
+	return json.Marshal(struct {
+		Role    string        `json:"role"`
+		Content []ContentPart `json:"content"`
+	}{m.Role, m.Parts})
 }
 
 // CompletionRequest is the payload sent to the OpenAI chat completion API.
@@ -38,7 +71,8 @@ type CompletionRequest struct {
 
 // Options are optional model-level parameters.
 type Options struct {
-	Temperature float64  `json:"temperature,omitempty"`
+	Model       string   `json:"model,omitempty"` // Overrides the configured model
+	Temperature *float64 `json:"temperature,omitempty"`
 	TopP        float64  `json:"top_p,omitempty"`
 	MaxTokens   int      `json:"max_tokens,omitempty"`
 	Stop        []string `json:"stop,omitempty"`
@@ -100,3 +134,16 @@ type APIError struct {
 	} `json:"error"`
 }
 
+// Model is a single entry returned by the models endpoint.
+type Model struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+// ModelList is the response of GET /v1/models.
+type ModelList struct {
+	Object string  `json:"object"`
+	Data   []Model `json:"data"`
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai/retry"
 	"go.uber.org/zap"
 )
 
@@ -18,6 +19,7 @@ const embeddingsAPIURL = "https://api.openai.com/v1/embeddings"
 type Config struct {
 	APIKey string
 	Model  string
+	Retry  *retry.Policy // nil uses retry.DefaultPolicy()
 }
 
 func (c *Config) IsValid() bool {
@@ -28,6 +30,7 @@ type Client struct {
 	apiKey     string
 	model      string
 	httpClient *http.Client
+	retrier    *retry.Retrier
 	logger     *zap.Logger
 	enabled    bool
 }
@@ -76,6 +79,7 @@ func NewClient(config *Config, logger *zap.Logger) (*Client, error) {
 		httpClient: &http.Client{
 			Timeout: 60 * time.Second,
 		},
+		retrier: retry.New(config.Retry, logger),
 		logger:  logger,
 		enabled: true,
 	}
@@ -86,7 +90,8 @@ func NewClient(config *Config, logger *zap.Logger) (*Client, error) {
 	return client, nil
 }
 
-func (c *Client) CreateEmbeddings(ctx context.Context, input []string) ([]float32, error) {
+// CreateEmbeddings returns one embedding per input, in input order.
+func (c *Client) CreateEmbeddings(ctx context.Context, input []string) ([][]float32, error) {
 	if len(input) == 0 {
 		c.logger.Error("Input cannot be empty")
 		return nil, errors.New("input cannot be empty")
@@ -112,17 +117,21 @@ func (c *Client) CreateEmbeddings(ctx context.Context, input []string) ([]float3
 		return nil, fmt.Errorf("failed to marshal embedding request: %w", err)
 	}
 
-	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, embeddingsAPIURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		c.logger.Error("Failed to create HTTP request",
-			zap.Error(err))
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
+	build := func() (*http.Request, error) {
+		httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, embeddingsAPIURL, bytes.NewReader(jsonData))
+		if err != nil {
+			c.logger.Error("Failed to create HTTP request",
+				zap.Error(err))
+			return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+		}
 
-	httpRequest.Header.Set("Content-Type", "application/json")
-	httpRequest.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+		httpRequest.Header.Set("Content-Type", "application/json")
+		httpRequest.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+		return httpRequest, nil
+	}
 
-	response, err := c.httpClient.Do(httpRequest)
+	// Embedding requests have no side effects, so every transient failure is retried.
+	response, err := c.retrier.Do(ctx, c.httpClient, true, build)
 	if err != nil {
 		c.logger.Error("Failed to send HTTP request",
 			zap.Error(err))
@@ -135,8 +144,22 @@ func (c *Client) CreateEmbeddings(ctx context.Context, input []string) ([]float3
 		apiError := APIError{}
 		if err := json.NewDecoder(response.Body).Decode(&apiError); err != nil {
 			c.logger.Error("Failed to decode API error response",
+				zap.Int("status", response.StatusCode),
 				zap.Error(err))
-			return nil, fmt.Errorf("failed to decode API error response: %w", err)
+			return nil, &RequestError{
+				StatusCode: response.StatusCode,
+				Message:    fmt.Sprintf("failed to decode API error response: %v", err),
+			}
+		}
+		c.logger.Error("OpenAI API error",
+			zap.Int("status", response.StatusCode),
+			zap.String("type", apiError.Error.Type),
+			zap.String("message", apiError.Error.Message))
+		return nil, &RequestError{
+			StatusCode: response.StatusCode,
+			Type:       apiError.Error.Type,
+			Code:       apiError.Error.Code,
+			Message:    apiError.Error.Message,
 		}
 	}
 
@@ -154,25 +177,38 @@ func (c *Client) CreateEmbeddings(ctx context.Context, input []string) ([]float3
 		return nil, fmt.Errorf("failed to unmarshal embedding response: %w", err)
 	}
 
-	if len(embeddingResponse.Data) == 0 {
-		c.logger.Error("No embedding data returned")
-		return nil, fmt.Errorf("no embedding data returned from open ai api")
+	if len(embeddingResponse.Data) != len(input) {
+		c.logger.Error("Unexpected embedding count",
+			zap.Int("expected", len(input)),
+			zap.Int("received", len(embeddingResponse.Data)))
+		return nil, fmt.Errorf("expected %d embeddings from open ai api, got %d", len(input), len(embeddingResponse.Data))
 	}
 
-	embedding := embeddingResponse.Data[0].Embedding
-	if len(embedding) == 0 {
-		c.logger.Error("No embedding returned")
-		return nil, fmt.Errorf("no embedding returned from open ai api")
+	embeddings := make([][]float32, len(input))
+	for _, d := range embeddingResponse.Data {
+		if d.Index < 0 || d.Index >= len(input) || len(d.Embedding) == 0 {
+			c.logger.Error("Invalid embedding returned", zap.Int("index", d.Index))
+			return nil, fmt.Errorf("invalid embedding at index %d returned from open ai api", d.Index)
+		}
+		embeddings[d.Index] = d.Embedding
 	}
 
-	return embedding, nil
+	return embeddings, nil
 }
 
 func (c *Client) CreateEmbedding(ctx context.Context, input string) ([]float32, error) {
-	return c.CreateEmbeddings(ctx, []string{input})
+	embeddings, err := c.CreateEmbeddings(ctx, []string{input})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// GetModel returns the configured embedding model name.
+func (c *Client) GetModel() string {
+	return c.model
 }
 
 func (c *Client) IsEnabled() bool {
 	return c.enabled
 }
-
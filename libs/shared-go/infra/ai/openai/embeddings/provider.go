@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/Joepolymath/DaVinci/libs/shared-go/embedding"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
 )
 
 type EmbeddingProvider struct {
@@ -15,11 +16,17 @@ func NewEmbeddingProvider(client *Client) embedding.Provider {
 }
 
 func (p *EmbeddingProvider) CreateEmbedding(ctx context.Context, text string) ([]float32, error) {
-	return p.client.CreateEmbedding(ctx, text)
+	vector, err := p.client.CreateEmbedding(ctx, text)
+	return vector, ai.Classify(ai.ProviderOpenAI, err)
 }
 
-func (p *EmbeddingProvider) CreateEmbeddings(ctx context.Context, texts []string) ([]float32, error) {
-	return p.client.CreateEmbeddings(ctx, texts)
+func (p *EmbeddingProvider) CreateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings, err := p.client.CreateEmbeddings(ctx, texts)
+	return embeddings, ai.Classify(ai.ProviderOpenAI, err)
+}
+
+func (p *EmbeddingProvider) GetModel() string {
+	return p.client.GetModel()
 }
 
 func (p *EmbeddingProvider) IsEnabled() bool {
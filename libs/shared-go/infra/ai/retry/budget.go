@@ -0,0 +1,57 @@
+package retry
+
+import "sync"
+
+// Budget limits retries across every request that shares it, so a provider
+// outage doesn't multiply our traffic by MaxAttempts.
+//
+// It follows the gRPC retry throttling scheme: each failed attempt spends one
+// token, each success earns back ratio tokens, and retries are only allowed
+// while more than half of the tokens remain.
+type Budget struct {
+	mu        sync.Mutex
+	tokens    float64
+	maxTokens float64
+	ratio     float64
+}
+
+// NewBudget creates a budget holding maxTokens tokens that refills by ratio
+// tokens per successful request.
+func NewBudget(maxTokens, ratio float64) *Budget {
+	if maxTokens <= 0 {
+		maxTokens = defaultBudgetTokens
+	}
+	if ratio <= 0 {
+		ratio = defaultBudgetRatio
+	}
+	return &Budget{
+		tokens:    maxTokens,
+		maxTokens: maxTokens,
+		ratio:     ratio,
+	}
+}
+
+// allowRetry reports whether another retry may be attempted.
+func (b *Budget) allowRetry() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens > b.maxTokens/2
+}
+
+func (b *Budget) onSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens += b.ratio
+	if b.tokens > b.maxTokens {
+		b.tokens = b.maxTokens
+	}
+}
+
+func (b *Budget) onFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens--
+	if b.tokens < 0 {
+		b.tokens = 0
+	}
+}
@@ -0,0 +1,53 @@
+package retry
+
+import "time"
+
+const (
+	defaultMaxAttempts   = 4
+	defaultBaseDelay     = 500 * time.Millisecond
+	defaultMaxDelay      = 30 * time.Second
+	defaultMaxRetryAfter = 60 * time.Second
+
+	defaultBudgetTokens = 10
+	defaultBudgetRatio  = 0.1
+)
+
+// Policy configures how failed HTTP calls to an AI provider are retried.
+// Zero values fall back to the package defaults.
+type Policy struct {
+	MaxAttempts int           // Total attempts including the first one (default 4)
+	BaseDelay   time.Duration // Backoff before the first retry, doubled on each attempt (default 500ms)
+	MaxDelay    time.Duration // Upper bound for a single computed backoff (default 30s)
+
+	// MaxRetryAfter caps how long a server hint (Retry-After, x-ratelimit-reset-*)
+	// is honoured. Hints beyond it are treated as "give up now" (default 60s).
+	MaxRetryAfter time.Duration
+
+	// Budget is shared by every request made with this policy. When nil a
+	// per-retrier budget is created.
+	Budget *Budget
+}
+
+// DefaultPolicy returns the policy used when a client is configured without one.
+func DefaultPolicy() *Policy {
+	return &Policy{}
+}
+
+func (p Policy) withDefaults() Policy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaultMaxAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = defaultBaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = defaultMaxDelay
+	}
+	if p.MaxRetryAfter <= 0 {
+		p.MaxRetryAfter = defaultMaxRetryAfter
+	}
+	if p.Budget == nil {
+		p.Budget = NewBudget(defaultBudgetTokens, defaultBudgetRatio)
+	}
+	return p
+}
@@ -0,0 +1,227 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Retrier sends HTTP requests to an AI provider, retrying transient failures
+// with exponential backoff and full jitter.
+//
+// Retries only happen before a response is handed back to the caller. Once Do
+// returns a 2xx response the caller owns the body, so a stream that breaks
+// after partial output is never replayed.
+type Retrier struct {
+	policy Policy
+	logger *zap.Logger
+}
+
+// New creates a Retrier. A nil policy uses DefaultPolicy.
+func New(policy *Policy, logger *zap.Logger) *Retrier {
+	if policy == nil {
+		policy = DefaultPolicy()
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Retrier{
+		policy: policy.withDefaults(),
+		logger: logger,
+	}
+}
+
+// Do builds and sends a request, retrying on transient failures.
+//
+// build is called once per attempt so the request body can be replayed.
+// idempotent marks requests that can safely be resent after the server may
+// have started processing them (timeouts, connection resets, 5xx). Other
+// requests are only retried when the server demonstrably rejected them: a
+// failed dial, 429 or 503. A 429 for exhausted quota is never retried.
+//
+// The final response is returned as-is, including non-2xx responses, so the
+// caller keeps its own error decoding. The caller must close the body.
+func (r *Retrier) Do(ctx context.Context, client *http.Client, idempotent bool, build func() (*http.Request, error)) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		req, err := build()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(req)
+		if err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			r.policy.Budget.onSuccess()
+			return resp, nil
+		}
+
+		if !r.retryable(ctx, resp, err, idempotent) {
+			if err == nil && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+				// The server processed the request and rejected it on its merits.
+				r.policy.Budget.onSuccess()
+			}
+			return resp, err
+		}
+
+		r.policy.Budget.onFailure()
+		if attempt >= r.policy.MaxAttempts || !r.policy.Budget.allowRetry() {
+			return resp, err
+		}
+
+		delay, ok := r.delay(attempt, resp)
+		if !ok || !fitsDeadline(ctx, delay) {
+			return resp, err
+		}
+
+		fields := []zap.Field{
+			zap.String("url", req.URL.Redacted()),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+		}
+		if err != nil {
+			fields = append(fields, zap.Error(err))
+		} else {
+			fields = append(fields, zap.Int("status", resp.StatusCode))
+			drain(resp)
+		}
+		r.logger.Warn("Retrying AI provider request", fields...)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryable reports whether the outcome of an attempt is worth retrying.
+func (r *Retrier) retryable(ctx context.Context, resp *http.Response, err error, idempotent bool) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		if isDialError(err) {
+			return true
+		}
+		return idempotent
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return !quotaExceeded(resp)
+	case http.StatusServiceUnavailable:
+		return true
+	case http.StatusRequestTimeout, http.StatusTooEarly,
+		http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent
+	default:
+		return false
+	}
+}
+
+// delay returns how long to wait before the next attempt. ok is false when the
+// server asked us to wait longer than the policy allows.
+func (r *Retrier) delay(attempt int, resp *http.Response) (time.Duration, bool) {
+	if resp != nil {
+		if hint, found := serverDelay(resp, time.Now()); found {
+			if hint > r.policy.MaxRetryAfter {
+				return 0, false
+			}
+			// Spread clients that were told the same reset time.
+			return hint + rand.N(hint/10+time.Millisecond), true
+		}
+	}
+
+	backoff := r.policy.BaseDelay << (attempt - 1)
+	if backoff <= 0 || backoff > r.policy.MaxDelay {
+		backoff = r.policy.MaxDelay
+	}
+	return rand.N(backoff) + time.Millisecond, true
+}
+
+// serverDelay extracts a wait hint from Retry-After or retry-after-ms, or,
+// for a 429, the OpenAI x-ratelimit-reset-* headers. Those describe the rate
+// limit windows and say nothing about when a failing server recovers.
+func serverDelay(resp *http.Response, now time.Time) (time.Duration, bool) {
+	h := resp.Header
+	if v := h.Get("retry-after-ms"); v != "" {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil && ms >= 0 {
+			return time.Duration(ms * float64(time.Millisecond)), true
+		}
+	}
+
+	if v := h.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			if d := t.Sub(now); d > 0 {
+				return d, true
+			}
+			return 0, true
+		}
+	}
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	// OpenAI reports separate reset windows for requests and tokens. Wait for
+	// whichever limit is exhausted, or the longest one if that's unclear.
+	var (
+		longest time.Duration
+		found   bool
+	)
+	for _, kind := range []string{"requests", "tokens"} {
+		reset, err := time.ParseDuration(h.Get("x-ratelimit-reset-" + kind))
+		if err != nil {
+			continue
+		}
+		if h.Get("x-ratelimit-remaining-"+kind) == "0" {
+			return reset, true
+		}
+		if reset > longest {
+			longest = reset
+		}
+		found = true
+	}
+	return longest, found
+}
+
+func fitsDeadline(ctx context.Context, delay time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Until(deadline) > delay
+}
+
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// drain discards a response body so the underlying connection can be reused.
+// quotaExceeded reports whether a 429 response says the account is out of
+// credit (OpenAI's insufficient_quota), which no wait will fix. The body is
+// put back for the caller to decode.
+func quotaExceeded(resp *http.Response) bool {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+	return err == nil && bytes.Contains(body, []byte(`"insufficient_quota"`))
+}
+
+func drain(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+}
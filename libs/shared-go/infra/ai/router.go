@@ -0,0 +1,210 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ProviderSpec names a configured provider instance, e.g. "cheap" backed by
+// ProviderLocal.
+type ProviderSpec struct {
+	Name string
+	Type ProviderType
+}
+
+// Route sends requests whose model matches Pattern (a path.Match glob such as
+// "gpt-*") to the provider called Provider.
+type Route struct {
+	Pattern  string
+	Provider string
+}
+
+// Router is a ChatProvider that dispatches each request to one of several
+// named providers, chosen by matching ChatOptions.Model against its routes.
+// Requests without a model, or whose model matches no route, go to the
+// default provider.
+type Router struct {
+	providers   map[string]ChatProvider
+	routes      []Route
+	defaultName string
+}
+
+// NewRouter creates a Router. Every route and the default must name one of
+// providers.
+func NewRouter(providers map[string]ChatProvider, routes []Route, defaultProvider string) (*Router, error) {
+	if len(providers) == 0 {
+		return nil, errors.New("at least one chat provider is required")
+	}
+	if _, ok := providers[defaultProvider]; !ok {
+		return nil, fmt.Errorf("default chat provider %q is not configured", defaultProvider)
+	}
+	for _, route := range routes {
+		if _, ok := providers[route.Provider]; !ok {
+			return nil, fmt.Errorf("model route %q targets unknown provider %q", route.Pattern, route.Provider)
+		}
+		if _, err := path.Match(route.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid model route pattern %q: %w", route.Pattern, err)
+		}
+	}
+
+	return &Router{
+		providers:   providers,
+		routes:      routes,
+		defaultName: defaultProvider,
+	}, nil
+}
+
+// Resolve returns the name and provider that serve model.
+func (r *Router) Resolve(model string) (string, ChatProvider) {
+	if model != "" {
+		for _, route := range r.routes {
+			if ok, _ := path.Match(route.Pattern, model); ok {
+				return route.Provider, r.providers[route.Provider]
+			}
+		}
+	}
+	return r.defaultName, r.providers[r.defaultName]
+}
+
+// Provider returns the provider registered under name.
+func (r *Router) Provider(name string) (ChatProvider, bool) {
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+// Names returns the configured provider names in sorted order.
+func (r *Router) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultProvider returns the name of the provider used when no route matches.
+func (r *Router) DefaultProvider() string {
+	return r.defaultName
+}
+
+func (r *Router) Completion(ctx context.Context, messages []Message, opts *ChatOptions) (*ChatResponse, error) {
+	_, p := r.Resolve(modelOf(opts))
+	return p.Completion(ctx, messages, opts)
+}
+
+func (r *Router) CompletionStream(ctx context.Context, messages []Message, opts *ChatOptions, onDelta func(delta ChatStreamDelta) error) error {
+	_, p := r.Resolve(modelOf(opts))
+	return p.CompletionStream(ctx, messages, opts, onDelta)
+}
+
+// Health checks every provider and reports all failures.
+func (r *Router) Health(ctx context.Context) error {
+	var errs []error
+	for _, name := range r.Names() {
+		if err := r.providers[name].Health(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ListModels lists the models of every provider, tagged with the provider
+// name. Providers that fail are skipped; their errors are joined and
+// returned alongside the models that could be listed.
+func (r *Router) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	var (
+		models []ModelInfo
+		errs   []error
+	)
+	for _, name := range r.Names() {
+		list, err := r.providers[name].ListModels(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		for _, m := range list {
+			m.Provider = name
+			models = append(models, m)
+		}
+	}
+	return models, errors.Join(errs...)
+}
+
+// IsEnabled returns true if any provider is enabled.
+func (r *Router) IsEnabled() bool {
+	for _, p := range r.providers {
+		if p.IsEnabled() {
+			return true
+		}
+	}
+	return false
+}
+
+// GetModel returns the default provider's model.
+func (r *Router) GetModel() string {
+	return r.providers[r.defaultName].GetModel()
+}
+
+func modelOf(opts *ChatOptions) string {
+	if opts == nil {
+		return ""
+	}
+	return opts.Model
+}
+
+// ParseProviderSpecs parses a comma-separated provider list such as
+// "openai,cheap=local". A bare entry is both the name and the type.
+func ParseProviderSpecs(spec string) ([]ProviderSpec, error) {
+	var specs []ProviderSpec
+	seen := make(map[string]bool)
+	for _, item := range splitList(spec) {
+		name, typ, found := strings.Cut(item, "=")
+		if !found {
+			typ = name
+		}
+		name, typ = strings.TrimSpace(name), strings.TrimSpace(typ)
+
+		switch ProviderType(typ) {
+		case ProviderOpenAI, ProviderLocal:
+		default:
+			return nil, fmt.Errorf("provider %q has unsupported type %q (supported: %q, %q)", name, typ, ProviderOpenAI, ProviderLocal)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("provider %q is configured twice", name)
+		}
+		seen[name] = true
+		specs = append(specs, ProviderSpec{Name: name, Type: ProviderType(typ)})
+	}
+	return specs, nil
+}
+
+// ParseRoutes parses comma-separated model routes such as
+// "gpt-*=openai,llama*=local". Routes are tried in order.
+func ParseRoutes(spec string) ([]Route, error) {
+	var routes []Route
+	for _, item := range splitList(spec) {
+		pattern, provider, found := strings.Cut(item, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid model route %q: expected pattern=provider", item)
+		}
+		routes = append(routes, Route{
+			Pattern:  strings.TrimSpace(pattern),
+			Provider: strings.TrimSpace(provider),
+		})
+	}
+	return routes, nil
+}
+
+func splitList(spec string) []string {
+	var items []string
+	for _, item := range strings.Split(spec, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,307 @@
+package window
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/tokenizer"
+)
+
+// Per-message and per-prompt overhead of the chat format, in tokens.
+const (
+	messageOverhead = 4
+	promptOverhead  = 3
+	chunkOverhead   = 2 // The separator between rendered chunks
+)
+
+// Input is the material for one completion, before budgeting.
+type Input struct {
+	System  string
+	Chunks  []Chunk      // Retrieved context, any order; ranked by Score
+	History []ai.Message // Earlier turns, oldest first
+	Current ai.Message   // The turn being answered; never trimmed
+}
+
+// Summarizer condenses turns dropped from the history.
+type Summarizer interface {
+	Summarize(ctx context.Context, turns []ai.Message, maxTokens int) (string, error)
+}
+
+// Builder fits a system prompt, retrieved chunks and conversation history
+// into a model's context window, leaving room for the completion. Trimming is
+// deterministic: the same input always yields the same prompt.
+type Builder struct {
+	cfg        Config
+	summarizer Summarizer
+}
+
+// NewBuilder creates a Builder. summarizer may be nil, in which case dropped
+// turns are discarded.
+func NewBuilder(cfg Config, summarizer Summarizer) *Builder {
+	return &Builder{cfg: cfg.withDefaults(), summarizer: summarizer}
+}
+
+// Build returns the messages to send to model and a report of what was
+// trimmed. maxOutput is the request's MaxTokens; zero reserves the configured
+// default. It fails with ai.ErrContextLengthExceeded only if the current turn
+// alone doesn't fit.
+func (b *Builder) Build(ctx context.Context, model string, in Input, maxOutput int) ([]ai.Message, *Report, error) {
+	p, err := b.plan(model, in, maxOutput)
+	if err != nil {
+		return nil, nil, err
+	}
+	tok, report := p.tok, p.report
+
+	kept, dropped := fitTurns(p.turns, p.turnCosts, p.historyBudget)
+	var summary string
+	if len(dropped) > 0 && b.summarizer != nil {
+		summary, kept, dropped = b.summarize(ctx, tok, p.turns, p.turnCosts, p.historyBudget, report)
+	}
+	report.DroppedTurns = len(dropped)
+
+	var messages []ai.Message
+	if content := renderSystem(p.system, p.chunks); content != "" {
+		messages = append(messages, ai.Message{Role: ai.RoleSystem, Content: content})
+	}
+	if summary != "" {
+		messages = append(messages, ai.Message{Role: ai.RoleSystem, Content: SummaryPrefix + summary})
+	}
+	for _, turn := range kept {
+		messages = append(messages, turn...)
+	}
+	messages = append(messages, in.Current)
+
+	report.PromptTokens = promptOverhead
+	for _, m := range messages {
+		report.PromptTokens += messageTokens(tok, m)
+	}
+	return messages, report, nil
+}
+
+// FitChunks budgets in.Chunks the way Build does and returns the ones that
+// fit, best first and possibly with the last one truncated, for callers that
+// render chunks into a prompt of their own. in.System and in.Current are the
+// prompt without the chunks; the history isn't trimmed, only counted against
+// the chunks' share of the window.
+func (b *Builder) FitChunks(model string, in Input, maxOutput int) ([]Chunk, *Report, error) {
+	p, err := b.plan(model, in, maxOutput)
+	if err != nil {
+		return nil, nil, err
+	}
+	p.report.PromptTokens = p.report.ContextWindow - p.report.ReservedOutput - p.historyBudget
+	for _, cost := range p.turnCosts {
+		p.report.PromptTokens += cost
+	}
+	return p.chunks, p.report, nil
+}
+
+// SummaryPrefix introduces the summary of turns dropped from a conversation.
+const SummaryPrefix = "Summary of the earlier conversation:\n"
+
+// plan is the division of the window between the parts of an Input.
+type plan struct {
+	tok    tokenizer.Tokenizer
+	report *Report
+	system string  // possibly truncated
+	chunks []Chunk // the ones that fit, best first
+
+	turns         [][]ai.Message
+	turnCosts     []int
+	historyBudget int // left for the history and its summary
+}
+
+// plan fits the system prompt and the chunks, leaving the history budget.
+func (b *Builder) plan(model string, in Input, maxOutput int) (*plan, error) {
+	tok := tokenizer.ForModel(model)
+
+	report := &Report{
+		ContextWindow:  b.contextWindow(model),
+		ReservedOutput: b.cfg.ReservedOutput,
+	}
+	if maxOutput > 0 {
+		report.ReservedOutput = maxOutput
+	}
+	available := report.ContextWindow - report.ReservedOutput - promptOverhead
+
+	currentCost := messageTokens(tok, in.Current)
+	if currentCost > available {
+		return nil, fmt.Errorf("%w: message needs %d tokens, %d available in %s's context window",
+			ai.ErrContextLengthExceeded, currentCost, available, model)
+	}
+	available -= currentCost
+
+	system := in.System
+	if system != "" && tok.Count(system)+messageOverhead > available {
+		system = tok.Truncate(system, available-messageOverhead)
+		report.SystemTruncated = true
+	}
+	if system != "" {
+		available -= tok.Count(system) + messageOverhead
+	}
+
+	chunks := rankChunks(in.Chunks)
+	chunkCosts := make([]int, len(chunks))
+	chunkTotal := 0
+	for i, c := range chunks {
+		chunkCosts[i] = tok.Count(formatChunk(i, c)) + chunkOverhead
+		chunkTotal += chunkCosts[i]
+	}
+	if system == "" && len(chunks) > 0 {
+		// The chunks need a system message of their own.
+		available -= messageOverhead
+	}
+
+	turns := splitTurns(in.History)
+	turnCosts := make([]int, len(turns))
+	historyTotal := 0
+	for i, turn := range turns {
+		for _, m := range turn {
+			turnCosts[i] += messageTokens(tok, m)
+		}
+		historyTotal += turnCosts[i]
+	}
+
+	// When both don't fit, chunks may claim ChunkShare of the space, or more
+	// if the history doesn't need it; history gets whatever chunks leave.
+	chunkBudget := chunkTotal
+	if chunkTotal+historyTotal > available {
+		chunkBudget = min(chunkTotal, max(int(float64(available)*b.cfg.ChunkShare), available-historyTotal))
+	}
+	chunks, chunkUsed := b.fitChunks(tok, chunks, chunkCosts, chunkBudget, report)
+
+	return &plan{
+		tok:           tok,
+		report:        report,
+		system:        system,
+		chunks:        chunks,
+		turns:         turns,
+		turnCosts:     turnCosts,
+		historyBudget: available - chunkUsed,
+	}, nil
+}
+
+func (b *Builder) contextWindow(model string) int {
+	if b.cfg.ContextWindow > 0 {
+		return b.cfg.ContextWindow
+	}
+	if n := ai.ContextWindow(model); n > 0 {
+		return n
+	}
+	return defaultContextWindow
+}
+
+// fitChunks keeps the highest-ranked chunks within budget. The first chunk
+// that doesn't fit is truncated if a useful part of it fits; it and every
+// lower-ranked chunk are otherwise dropped.
+func (b *Builder) fitChunks(tok tokenizer.Tokenizer, chunks []Chunk, costs []int, budget int, report *Report) ([]Chunk, int) {
+	used := 0
+	for i, c := range chunks {
+		if used+costs[i] <= budget {
+			used += costs[i]
+			continue
+		}
+
+		kept := chunks[:i]
+		left := budget - used - (costs[i] - tok.Count(c.Text)) // room for the text itself
+		if left >= b.cfg.MinChunkTokens {
+			c.Text = tok.Truncate(c.Text, left)
+			kept = append(kept, c)
+			used += tok.Count(formatChunk(i, c)) + chunkOverhead
+			report.TruncatedChunks = append(report.TruncatedChunks, c.ID)
+			i++
+		}
+		for _, d := range chunks[i:] {
+			report.DroppedChunks = append(report.DroppedChunks, d.ID)
+		}
+		return kept, used
+	}
+	return chunks, used
+}
+
+// summarize drops enough turns to make room for a summary of them. If the
+// summarizer fails the dropped turns are discarded.
+func (b *Builder) summarize(ctx context.Context, tok tokenizer.Tokenizer, turns [][]ai.Message, costs []int, budget int, report *Report) (string, [][]ai.Message, [][]ai.Message) {
+	summaryBudget := int(float64(budget) * b.cfg.SummaryShare)
+	kept, dropped := fitTurns(turns, costs, budget-summaryBudget)
+	maxTokens := summaryBudget - messageOverhead - tok.Count(SummaryPrefix)
+	if maxTokens <= 0 {
+		return "", kept, dropped
+	}
+
+	var messages []ai.Message
+	for _, turn := range dropped {
+		messages = append(messages, turn...)
+	}
+	summary, err := b.summarizer.Summarize(ctx, messages, maxTokens)
+	if err != nil {
+		report.SummaryError = err.Error()
+		return "", kept, dropped
+	}
+
+	report.Summarized = true
+	return tok.Truncate(strings.TrimSpace(summary), maxTokens), kept, dropped
+}
+
+// fitTurns keeps the newest turns that fit in budget.
+func fitTurns(turns [][]ai.Message, costs []int, budget int) (kept, dropped [][]ai.Message) {
+	used, cut := 0, len(turns)
+	for cut > 0 && used+costs[cut-1] <= budget {
+		cut--
+		used += costs[cut]
+	}
+	return turns[cut:], turns[:cut]
+}
+
+// splitTurns groups history into turns, each starting at a user message, so
+// a question is never kept without the answer that followed it.
+func splitTurns(history []ai.Message) [][]ai.Message {
+	var turns [][]ai.Message
+	for _, m := range history {
+		if m.Role == ai.RoleUser || len(turns) == 0 {
+			turns = append(turns, nil)
+		}
+		turns[len(turns)-1] = append(turns[len(turns)-1], m)
+	}
+	return turns
+}
+
+// rankChunks orders chunks by descending score, keeping input order for ties.
+func rankChunks(chunks []Chunk) []Chunk {
+	ranked := append([]Chunk(nil), chunks...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+	return ranked
+}
+
+func renderSystem(system string, chunks []Chunk) string {
+	if len(chunks) == 0 {
+		return system
+	}
+
+	var sb strings.Builder
+	if system != "" {
+		sb.WriteString(system)
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString("Context:")
+	for i, c := range chunks {
+		sb.WriteString("\n\n")
+		sb.WriteString(formatChunk(i, c))
+	}
+	return sb.String()
+}
+
+func formatChunk(i int, c Chunk) string {
+	if c.Source != "" {
+		return fmt.Sprintf("[%d] (%s) %s", i+1, c.Source, c.Text)
+	}
+	return fmt.Sprintf("[%d] %s", i+1, c.Text)
+}
+
+func messageTokens(tok tokenizer.Tokenizer, m ai.Message) int {
+	return messageOverhead + tok.Count(m.Text()) + ai.ImageTokens*len(m.Images())
+}
@@ -0,0 +1,83 @@
+package window
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+)
+
+// chatProvider wraps an ai.ChatProvider so every request is fitted into the
+// model's context window first.
+type chatProvider struct {
+	ai.ChatProvider
+	builder *Builder
+	onTrim  func(model string, report *Report)
+}
+
+// NewChatProvider returns provider with requests trimmed by b. Leading system
+// messages are the system prompt, the last message is the current turn and
+// everything between is history. onTrim, if set, is called whenever a request
+// had to be trimmed.
+func NewChatProvider(provider ai.ChatProvider, b *Builder, onTrim func(model string, report *Report)) ai.ChatProvider {
+	return &chatProvider{ChatProvider: provider, builder: b, onTrim: onTrim}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *chatProvider) Unwrap() ai.ChatProvider {
+	return p.ChatProvider
+}
+
+func (p *chatProvider) Completion(ctx context.Context, messages []ai.Message, opts *ai.ChatOptions) (*ai.ChatResponse, error) {
+	messages, err := p.fit(ctx, messages, opts)
+	if err != nil {
+		return nil, err
+	}
+	return p.ChatProvider.Completion(ctx, messages, opts)
+}
+
+func (p *chatProvider) CompletionStream(ctx context.Context, messages []ai.Message, opts *ai.ChatOptions, onDelta func(delta ai.ChatStreamDelta) error) error {
+	messages, err := p.fit(ctx, messages, opts)
+	if err != nil {
+		return err
+	}
+	return p.ChatProvider.CompletionStream(ctx, messages, opts, onDelta)
+}
+
+func (p *chatProvider) fit(ctx context.Context, messages []ai.Message, opts *ai.ChatOptions) ([]ai.Message, error) {
+	if len(messages) == 0 {
+		return messages, nil
+	}
+
+	model, maxOutput := p.GetModel(), 0
+	if opts != nil {
+		if opts.Model != "" {
+			model = opts.Model
+		}
+		maxOutput = opts.MaxTokens
+	}
+
+	var system []string
+	i := 0
+	for ; i < len(messages)-1 && messages[i].Role == ai.RoleSystem; i++ {
+		system = append(system, messages[i].Text())
+	}
+
+	fitted, report, err := p.builder.Build(ctx, model, Input{
+		System:  strings.Join(system, "\n\n"),
+		History: messages[i : len(messages)-1],
+		Current: messages[len(messages)-1],
+	}, maxOutput)
+	if err != nil {
+		return nil, err
+	}
+	if !report.Trimmed() {
+		// Keep the caller's messages untouched, including separate system
+		// messages.
+		return messages, nil
+	}
+	if p.onTrim != nil {
+		p.onTrim(model, report)
+	}
+	return fitted, nil
+}
@@ -0,0 +1,76 @@
+package window
+
+const (
+	defaultContextWindow  = 8192
+	defaultReservedOutput = 1024
+	defaultChunkShare     = 0.5
+	defaultMinChunkTokens = 64
+	defaultSummaryShare   = 0.25
+)
+
+// Config sets how a Builder divides the context window.
+type Config struct {
+	// ContextWindow overrides the model's context window in tokens. Zero uses
+	// the catalog value for the model, or 8192 for unknown models.
+	ContextWindow int
+
+	// ReservedOutput is kept free for the completion when the request doesn't
+	// set MaxTokens (default 1024).
+	ReservedOutput int
+
+	// ChunkShare is the fraction (0-1) of the space left after the system
+	// prompt and the current turn that retrieved chunks may claim when
+	// chunks and history don't both fit (default 0.5). Space either side
+	// doesn't need goes to the other.
+	ChunkShare float64
+
+	// MinChunkTokens is the smallest truncated chunk worth keeping (default 64).
+	MinChunkTokens int
+
+	// SummaryShare caps the summary of dropped turns at this fraction of the
+	// history budget (default 0.25).
+	SummaryShare float64
+}
+
+func (c Config) withDefaults() Config {
+	if c.ReservedOutput <= 0 {
+		c.ReservedOutput = defaultReservedOutput
+	}
+	if c.ChunkShare <= 0 || c.ChunkShare > 1 {
+		c.ChunkShare = defaultChunkShare
+	}
+	if c.MinChunkTokens <= 0 {
+		c.MinChunkTokens = defaultMinChunkTokens
+	}
+	if c.SummaryShare <= 0 || c.SummaryShare > 1 {
+		c.SummaryShare = defaultSummaryShare
+	}
+	return c
+}
+
+// Chunk is a retrieved passage competing for space in the prompt.
+type Chunk struct {
+	ID     string
+	Text   string
+	Score  float64 // Higher ranks first
+	Source string
+}
+
+// Report describes what a Build call trimmed to fit the window.
+type Report struct {
+	ContextWindow  int `json:"context_window"`
+	ReservedOutput int `json:"reserved_output"`
+	PromptTokens   int `json:"prompt_tokens"` // Estimated size of the built prompt
+
+	DroppedTurns    int      `json:"dropped_turns,omitempty"`
+	Summarized      bool     `json:"summarized,omitempty"`    // Dropped turns were replaced by a summary
+	SummaryError    string   `json:"summary_error,omitempty"` // Why summarizing failed, if it did
+	DroppedChunks   []string `json:"dropped_chunks,omitempty"`
+	TruncatedChunks []string `json:"truncated_chunks,omitempty"`
+	SystemTruncated bool     `json:"system_truncated,omitempty"`
+}
+
+// Trimmed reports whether anything was removed or shortened.
+func (r *Report) Trimmed() bool {
+	return r.DroppedTurns > 0 || len(r.DroppedChunks) > 0 || len(r.TruncatedChunks) > 0 || r.SystemTruncated
+}
@@ -0,0 +1,82 @@
+package window
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/tokenizer"
+)
+
+// Dropped history can be long; only its most recent part is summarized.
+const maxTranscriptTokens = 6000
+
+// ChatSummarizer summarizes dropped turns with a chat completion.
+type ChatSummarizer struct {
+	provider ai.ChatProvider
+	model    string
+	prompt   func() (string, error)
+}
+
+// NewSummarizer returns a Summarizer backed by provider. prompt returns the
+// system prompt and is called per summary so prompt edits take effect. model
+// may be empty to use the provider's default; a small, cheap model is usually
+// enough.
+func NewSummarizer(provider ai.ChatProvider, model string, prompt func() (string, error)) *ChatSummarizer {
+	return &ChatSummarizer{provider: provider, model: model, prompt: prompt}
+}
+
+func (s *ChatSummarizer) Summarize(ctx context.Context, turns []ai.Message, maxTokens int) (string, error) {
+	var transcript strings.Builder
+	for _, m := range turns {
+		fmt.Fprintf(&transcript, "%s: %s\n\n", m.Role, m.Text())
+	}
+
+	model := s.model
+	if model == "" {
+		model = s.provider.GetModel()
+	}
+	tok := tokenizer.ForModel(model)
+	text := transcript.String()
+	if tok.Count(text) > maxTranscriptTokens {
+		text = tailTokens(tok, text, maxTranscriptTokens)
+	}
+
+	system, err := s.prompt()
+	if err != nil {
+		return "", fmt.Errorf("failed to render summary prompt: %w", err)
+	}
+
+	temperature := 0.0
+	resp, err := s.provider.Completion(ctx, []ai.Message{
+		{Role: ai.RoleSystem, Content: system},
+		{Role: ai.RoleUser, Content: text},
+	}, &ai.ChatOptions{
+		Model:       s.model,
+		Temperature: &temperature,
+		MaxTokens:   maxTokens,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize history: %w", err)
+	}
+	return resp.Content, nil
+}
+
+// tailTokens returns the longest suffix of text within maxTokens.
+func tailTokens(tok tokenizer.Tokenizer, text string, maxTokens int) string {
+	lo, hi := 0, len(text)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if tok.Count(text[mid:]) <= maxTokens {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	for lo < len(text) && !utf8.RuneStart(text[lo]) {
+		lo++
+	}
+	return text[lo:]
+}
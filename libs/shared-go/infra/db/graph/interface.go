@@ -0,0 +1,29 @@
+package graph
+
+import "context"
+
+// Store is a knowledge graph of entities and the relations between them,
+// each linked to the chunks they were extracted from. Chunk IDs are the IDs of
+// the chunks' points in the vector store.
+type Store interface {
+	// AddChunk records the entities and relations extracted from a chunk.
+	// Entities are merged by ID; adding a chunk again replaces what it
+	// contributed before.
+	AddChunk(ctx context.Context, chunkID string, x *Extraction) error
+
+	// RemoveChunk forgets a chunk's mentions and relations. Entities no
+	// longer mentioned by any chunk are deleted.
+	RemoveChunk(ctx context.Context, chunkID string) error
+
+	// IndexedChunks returns the chunks among ids that were added, including
+	// those in which nothing was found.
+	IndexedChunks(ctx context.Context, ids []string) ([]string, error)
+
+	// FindEntities returns the entities among ids that exist.
+	FindEntities(ctx context.Context, ids []string) ([]Entity, error)
+
+	// Expand walks up to hops relations, in either direction, from the seed
+	// entities and returns the subgraph reached with at most limit of the
+	// chunks mentioning it, nearest first.
+	Expand(ctx context.Context, seeds []string, hops, limit int) (*Subgraph, error)
+}
@@ -0,0 +1,206 @@
+package graph
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// memoryStore is an in-process Store. It is lost on restart, so it suits
+// development and small corpora that are re-ingested at startup.
+type memoryStore struct {
+	mu       sync.RWMutex
+	entities map[string]Entity
+	mentions map[string]map[string]struct{} // entity ID -> chunk IDs
+	chunks   map[string]*Extraction         // chunk ID -> what it contributed
+	edges    map[string]map[string]int      // entity ID -> neighbour ID -> relation count, both directions
+}
+
+// NewMemoryStore returns an empty in-memory Store.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		entities: make(map[string]Entity),
+		mentions: make(map[string]map[string]struct{}),
+		chunks:   make(map[string]*Extraction),
+		edges:    make(map[string]map[string]int),
+	}
+}
+
+func (s *memoryStore) AddChunk(ctx context.Context, chunkID string, x *Extraction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.removeLocked(chunkID)
+	if x == nil || len(x.Entities) == 0 {
+		// Remembered so IndexedChunks doesn't report it missing.
+		s.chunks[chunkID] = &Extraction{}
+		return nil
+	}
+
+	stored := &Extraction{
+		Entities:  append([]Entity(nil), x.Entities...),
+		Relations: append([]Relation(nil), x.Relations...),
+	}
+	for _, e := range stored.Entities {
+		if existing, ok := s.entities[e.ID]; !ok || (existing.Type == "" && e.Type != "") {
+			s.entities[e.ID] = e
+		}
+		if s.mentions[e.ID] == nil {
+			s.mentions[e.ID] = make(map[string]struct{})
+		}
+		s.mentions[e.ID][chunkID] = struct{}{}
+	}
+	for i := range stored.Relations {
+		r := &stored.Relations[i]
+		r.ChunkID = chunkID
+		s.link(r.Source, r.Target, 1)
+		s.link(r.Target, r.Source, 1)
+	}
+	s.chunks[chunkID] = stored
+	return nil
+}
+
+func (s *memoryStore) RemoveChunk(ctx context.Context, chunkID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.removeLocked(chunkID)
+	return nil
+}
+
+func (s *memoryStore) IndexedChunks(ctx context.Context, ids []string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found []string
+	for _, id := range ids {
+		if _, ok := s.chunks[id]; ok {
+			found = append(found, id)
+		}
+	}
+	return found, nil
+}
+
+func (s *memoryStore) FindEntities(ctx context.Context, ids []string) ([]Entity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found []Entity
+	for _, id := range ids {
+		if e, ok := s.entities[id]; ok {
+			found = append(found, e)
+		}
+	}
+	return found, nil
+}
+
+func (s *memoryStore) Expand(ctx context.Context, seeds []string, hops, limit int) (*Subgraph, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Breadth-first, so each entity is recorded at its smallest distance.
+	distance := make(map[string]int)
+	var frontier []string
+	for _, id := range seeds {
+		if _, ok := s.entities[id]; ok {
+			if _, seen := distance[id]; !seen {
+				distance[id] = 0
+				frontier = append(frontier, id)
+			}
+		}
+	}
+	for hop := 1; hop <= hops && len(frontier) > 0; hop++ {
+		var next []string
+		for _, id := range frontier {
+			for neighbour := range s.edges[id] {
+				if _, seen := distance[neighbour]; !seen {
+					distance[neighbour] = hop
+					next = append(next, neighbour)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	sub := &Subgraph{}
+	chunkHops := make(map[string]int)
+	for id, d := range distance {
+		sub.Entities = append(sub.Entities, s.entities[id])
+		for chunkID := range s.mentions[id] {
+			if h, ok := chunkHops[chunkID]; !ok || d < h {
+				chunkHops[chunkID] = d
+			}
+		}
+	}
+	sort.Slice(sub.Entities, func(i, j int) bool { return sub.Entities[i].ID < sub.Entities[j].ID })
+
+	for _, x := range s.chunks {
+		for _, r := range x.Relations {
+			_, okSource := distance[r.Source]
+			_, okTarget := distance[r.Target]
+			if okSource && okTarget {
+				sub.Relations = append(sub.Relations, r)
+			}
+		}
+	}
+	sort.Slice(sub.Relations, func(i, j int) bool {
+		a, b := sub.Relations[i], sub.Relations[j]
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		if a.Target != b.Target {
+			return a.Target < b.Target
+		}
+		return a.ChunkID < b.ChunkID
+	})
+
+	for chunkID, h := range chunkHops {
+		sub.Chunks = append(sub.Chunks, ChunkRef{ID: chunkID, Hops: h})
+	}
+	sort.Slice(sub.Chunks, func(i, j int) bool {
+		if sub.Chunks[i].Hops != sub.Chunks[j].Hops {
+			return sub.Chunks[i].Hops < sub.Chunks[j].Hops
+		}
+		return sub.Chunks[i].ID < sub.Chunks[j].ID
+	})
+	if limit > 0 && len(sub.Chunks) > limit {
+		sub.Chunks = sub.Chunks[:limit]
+	}
+	return sub, nil
+}
+
+// removeLocked undoes what chunkID contributed. s.mu must be held.
+func (s *memoryStore) removeLocked(chunkID string) {
+	x, ok := s.chunks[chunkID]
+	if !ok {
+		return
+	}
+	delete(s.chunks, chunkID)
+
+	for _, r := range x.Relations {
+		s.link(r.Source, r.Target, -1)
+		s.link(r.Target, r.Source, -1)
+	}
+	for _, e := range x.Entities {
+		delete(s.mentions[e.ID], chunkID)
+		if len(s.mentions[e.ID]) == 0 {
+			delete(s.mentions, e.ID)
+			delete(s.entities, e.ID)
+			delete(s.edges, e.ID)
+		}
+	}
+}
+
+// link adjusts the relation count from one entity to another.
+func (s *memoryStore) link(from, to string, delta int) {
+	if s.edges[from] == nil {
+		if delta < 0 {
+			return
+		}
+		s.edges[from] = make(map[string]int)
+	}
+	s.edges[from][to] += delta
+	if s.edges[from][to] <= 0 {
+		delete(s.edges[from], to)
+	}
+}
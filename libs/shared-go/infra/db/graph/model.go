@@ -0,0 +1,79 @@
+package graph
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Entity is a named thing mentioned in the documents: a service, component,
+// part, procedure, setting...
+type Entity struct {
+	ID   string `json:"id"` // EntityID of the name
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+// Relation is a typed, directed edge between two entities, as stated in one
+// chunk.
+type Relation struct {
+	Source  string `json:"source"` // Entity ID
+	Target  string `json:"target"` // Entity ID
+	Type    string `json:"type"`   // e.g. "depends_on", "part_of", "replaces"
+	ChunkID string `json:"chunk_id,omitempty"`
+}
+
+// Extraction is what was extracted from one chunk.
+type Extraction struct {
+	Entities  []Entity   `json:"entities"`
+	Relations []Relation `json:"relations"`
+}
+
+// ChunkRef is a chunk reached while expanding the graph.
+type ChunkRef struct {
+	ID   string `json:"id"`
+	Hops int    `json:"hops"` // Relations between the chunk's entity and the nearest seed
+}
+
+// Subgraph is the result of an expansion.
+type Subgraph struct {
+	Entities  []Entity   `json:"entities"`
+	Relations []Relation `json:"relations"`
+	Chunks    []ChunkRef `json:"chunks"` // Nearest first
+}
+
+// EntityID normalizes an entity name into its ID, so "Fuel Pump", "fuel
+// pump" and "fuel-pump" are the same entity.
+func EntityID(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, "_")
+}
+
+// Normalize fills in missing entity IDs, drops entities without a name and
+// relations whose ends are unknown, and stamps relations with chunkID.
+func (x *Extraction) Normalize(chunkID string) {
+	known := make(map[string]bool, len(x.Entities))
+	entities := x.Entities[:0]
+	for _, e := range x.Entities {
+		e.ID = EntityID(e.Name)
+		if e.ID == "" || known[e.ID] {
+			continue
+		}
+		known[e.ID] = true
+		entities = append(entities, e)
+	}
+	x.Entities = entities
+
+	relations := x.Relations[:0]
+	for _, r := range x.Relations {
+		r.Source, r.Target = EntityID(r.Source), EntityID(r.Target)
+		r.Type = EntityID(r.Type)
+		if !known[r.Source] || !known[r.Target] || r.Source == r.Target || r.Type == "" {
+			continue
+		}
+		r.ChunkID = chunkID
+		relations = append(relations, r)
+	}
+	x.Relations = relations
+}
@@ -1 +1,111 @@
 package neo4j
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultDatabase = "neo4j"
+	defaultTimeout  = 30 * time.Second
+)
+
+// Client runs Cypher over Neo4j's HTTP transactional endpoint, so no driver
+// dependency is needed. Each Run is one auto-committed transaction.
+type Client struct {
+	url        string
+	database   string
+	username   string
+	password   string
+	httpClient *http.Client
+	logger     *zap.Logger
+}
+
+func NewClient(cfg *Config, logger *zap.Logger) (*Client, error) {
+	if cfg == nil || !cfg.IsValid() {
+		return nil, errors.New("neo4j URL is required")
+	}
+
+	database := cfg.Database
+	if database == "" {
+		database = defaultDatabase
+	}
+
+	client := &Client{
+		url:      strings.TrimRight(cfg.URL, "/"),
+		database: database,
+		username: cfg.Username,
+		password: cfg.Password,
+		httpClient: &http.Client{
+			Timeout: defaultTimeout,
+		},
+		logger: logger,
+	}
+
+	logger.Info("Neo4j client initialized",
+		zap.String("url", client.url),
+		zap.String("database", database))
+
+	return client, nil
+}
+
+// Health checks that the server is reachable and the credentials work.
+func (c *Client) Health(ctx context.Context) error {
+	_, err := c.Run(ctx, Statement{Query: "RETURN 1"})
+	return err
+}
+
+// Run executes statements in a single transaction and returns one result per
+// statement.
+func (c *Client) Run(ctx context.Context, statements ...Statement) ([]Result, error) {
+	for i := range statements {
+		statements[i].ResultDataContents = []string{"row"}
+	}
+	body, err := json.Marshal(txRequest{Statements: statements})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal neo4j request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/db/%s/tx/commit", c.url, c.database)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create neo4j request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("neo4j request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read neo4j response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &RequestError{StatusCode: resp.StatusCode, Message: string(raw)}
+	}
+
+	var tx txResponse
+	if err := json.Unmarshal(raw, &tx); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal neo4j response: %w", err)
+	}
+	if len(tx.Errors) > 0 {
+		return nil, &QueryError{Errors: tx.Errors}
+	}
+	return tx.Results, nil
+}
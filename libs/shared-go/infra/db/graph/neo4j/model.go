@@ -0,0 +1,69 @@
+package neo4j
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Config struct {
+	URL      string // HTTP endpoint, e.g. http://localhost:7474
+	Username string
+	Password string
+	Database string // defaults to "neo4j"
+}
+
+func (c *Config) IsValid() bool {
+	return c.URL != ""
+}
+
+// Statement is one Cypher statement of a transaction.
+type Statement struct {
+	Query              string         `json:"statement"`
+	Parameters         map[string]any `json:"parameters,omitempty"`
+	ResultDataContents []string       `json:"resultDataContents,omitempty"`
+}
+
+type txRequest struct {
+	Statements []Statement `json:"statements"`
+}
+
+// Result holds the rows returned by one statement.
+type Result struct {
+	Columns []string `json:"columns"`
+	Data    []struct {
+		Row []any `json:"row"`
+	} `json:"data"`
+}
+
+type txResponse struct {
+	Results []Result  `json:"results"`
+	Errors  []txError `json:"errors"`
+}
+
+type txError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// QueryError is returned when Neo4j rejects a transaction.
+type QueryError struct {
+	Errors []txError
+}
+
+func (e *QueryError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = fmt.Sprintf("%s: %s", err.Code, err.Message)
+	}
+	return "neo4j: " + strings.Join(messages, "; ")
+}
+
+// RequestError is returned when the Neo4j server answers with a non-2xx status.
+type RequestError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("neo4j request failed (status %d): %s", e.StatusCode, e.Message)
+}
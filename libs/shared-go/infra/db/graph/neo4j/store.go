@@ -0,0 +1,218 @@
+package neo4j
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/db/graph"
+	"go.uber.org/zap"
+)
+
+// The graph is stored as
+//
+//	(:Chunk {id})-[:MENTIONS]->(:Entity {id, name, type})
+//	(:Entity)-[:RELATES {type, chunk}]->(:Entity)
+//
+// with one RELATES edge per relation and chunk, so a chunk's contribution can
+// be removed on its own.
+var schema = []Statement{
+	{Query: "CREATE CONSTRAINT entity_id IF NOT EXISTS FOR (e:Entity) REQUIRE e.id IS UNIQUE"},
+	{Query: "CREATE CONSTRAINT chunk_id IF NOT EXISTS FOR (c:Chunk) REQUIRE c.id IS UNIQUE"},
+}
+
+const (
+	deleteChunkRelations = `MATCH ()-[r:RELATES {chunk: $chunk}]->() DELETE r`
+
+	// Entities only this chunk mentioned go with its mentions.
+	deleteChunkMentions = `MATCH (c:Chunk {id: $chunk})-[m:MENTIONS]->(e:Entity)
+DELETE m
+WITH e WHERE NOT (e)<-[:MENTIONS]-()
+DETACH DELETE e`
+
+	deleteChunk = `MATCH (c:Chunk {id: $chunk}) DETACH DELETE c`
+
+	// A chunk in which nothing was found is kept, without mentions, so it
+	// counts as indexed.
+	mergeChunk = `MERGE (c:Chunk {id: $chunk})`
+
+	indexedChunks = `MATCH (c:Chunk) WHERE c.id IN $ids RETURN c.id`
+
+	mergeEntities = `MERGE (c:Chunk {id: $chunk})
+WITH c
+UNWIND $entities AS x
+MERGE (e:Entity {id: x.id})
+ON CREATE SET e.name = x.name, e.type = x.type
+ON MATCH SET e.type = CASE WHEN coalesce(e.type, '') = '' THEN x.type ELSE e.type END
+MERGE (c)-[:MENTIONS]->(e)`
+
+	createRelations = `UNWIND $relations AS x
+MATCH (a:Entity {id: x.source}), (b:Entity {id: x.target})
+CREATE (a)-[:RELATES {type: x.type, chunk: $chunk}]->(b)`
+
+	findEntities = `MATCH (e:Entity) WHERE e.id IN $ids RETURN e.id, e.name, e.type`
+
+	// The hop count can't be a parameter in a variable-length pattern.
+	expandEntities = `MATCH (s:Entity) WHERE s.id IN $seeds
+MATCH p = (s)-[:RELATES*0..%d]-(e:Entity)
+WITH e, min(length(p)) AS hops
+RETURN e.id, e.name, e.type, hops`
+
+	subgraphRelations = `MATCH (a:Entity)-[r:RELATES]->(b:Entity)
+WHERE a.id IN $ids AND b.id IN $ids
+RETURN a.id, b.id, r.type, r.chunk
+ORDER BY a.id, b.id, r.chunk`
+
+	subgraphChunks = `UNWIND $entities AS x
+MATCH (c:Chunk)-[:MENTIONS]->(:Entity {id: x.id})
+WITH c, min(x.hops) AS hops
+RETURN c.id, hops
+ORDER BY hops, c.id
+LIMIT $limit`
+)
+
+type store struct {
+	client *Client
+	logger *zap.Logger
+}
+
+// NewStore returns a graph.Store backed by Neo4j, creating the uniqueness
+// constraints it relies on if they are missing.
+func NewStore(ctx context.Context, client *Client, logger *zap.Logger) (graph.Store, error) {
+	if _, err := client.Run(ctx, schema...); err != nil {
+		return nil, fmt.Errorf("failed to create neo4j schema: %w", err)
+	}
+	return &store{client: client, logger: logger}, nil
+}
+
+func (s *store) AddChunk(ctx context.Context, chunkID string, x *graph.Extraction) error {
+	params := map[string]any{"chunk": chunkID}
+	statements := []Statement{
+		{Query: deleteChunkRelations, Parameters: params},
+		{Query: deleteChunkMentions, Parameters: params},
+	}
+
+	if x != nil && len(x.Entities) > 0 {
+		entities := make([]map[string]any, len(x.Entities))
+		for i, e := range x.Entities {
+			entities[i] = map[string]any{"id": e.ID, "name": e.Name, "type": e.Type}
+		}
+		relations := make([]map[string]any, len(x.Relations))
+		for i, r := range x.Relations {
+			relations[i] = map[string]any{"source": r.Source, "target": r.Target, "type": r.Type}
+		}
+		statements = append(statements,
+			Statement{Query: mergeEntities, Parameters: map[string]any{"chunk": chunkID, "entities": entities}},
+			Statement{Query: createRelations, Parameters: map[string]any{"chunk": chunkID, "relations": relations}},
+		)
+	} else {
+		statements = append(statements, Statement{Query: mergeChunk, Parameters: params})
+	}
+
+	if _, err := s.client.Run(ctx, statements...); err != nil {
+		return fmt.Errorf("failed to add chunk %s to the graph: %w", chunkID, err)
+	}
+	return nil
+}
+
+func (s *store) RemoveChunk(ctx context.Context, chunkID string) error {
+	params := map[string]any{"chunk": chunkID}
+	_, err := s.client.Run(ctx,
+		Statement{Query: deleteChunkRelations, Parameters: params},
+		Statement{Query: deleteChunkMentions, Parameters: params},
+		Statement{Query: deleteChunk, Parameters: params},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remove chunk %s from the graph: %w", chunkID, err)
+	}
+	return nil
+}
+
+func (s *store) IndexedChunks(ctx context.Context, ids []string) ([]string, error) {
+	results, err := s.client.Run(ctx, Statement{Query: indexedChunks, Parameters: map[string]any{"ids": ids}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up indexed chunks: %w", err)
+	}
+
+	found := make([]string, 0, len(results[0].Data))
+	for _, row := range results[0].Data {
+		found = append(found, str(row.Row[0]))
+	}
+	return found, nil
+}
+
+func (s *store) FindEntities(ctx context.Context, ids []string) ([]graph.Entity, error) {
+	results, err := s.client.Run(ctx, Statement{Query: findEntities, Parameters: map[string]any{"ids": ids}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find entities: %w", err)
+	}
+
+	var entities []graph.Entity
+	for _, row := range results[0].Data {
+		entities = append(entities, graph.Entity{
+			ID:   str(row.Row[0]),
+			Name: str(row.Row[1]),
+			Type: str(row.Row[2]),
+		})
+	}
+	return entities, nil
+}
+
+func (s *store) Expand(ctx context.Context, seeds []string, hops, limit int) (*graph.Subgraph, error) {
+	if hops < 0 {
+		hops = 0
+	}
+	results, err := s.client.Run(ctx, Statement{
+		Query:      fmt.Sprintf(expandEntities, hops),
+		Parameters: map[string]any{"seeds": seeds},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand graph: %w", err)
+	}
+
+	sub := &graph.Subgraph{}
+	ids := make([]string, 0, len(results[0].Data))
+	reached := make([]map[string]any, 0, len(results[0].Data))
+	for _, row := range results[0].Data {
+		e := graph.Entity{ID: str(row.Row[0]), Name: str(row.Row[1]), Type: str(row.Row[2])}
+		sub.Entities = append(sub.Entities, e)
+		ids = append(ids, e.ID)
+		reached = append(reached, map[string]any{"id": e.ID, "hops": num(row.Row[3])})
+	}
+	if len(ids) == 0 {
+		return sub, nil
+	}
+	if limit <= 0 {
+		limit = 1 << 20
+	}
+
+	results, err = s.client.Run(ctx,
+		Statement{Query: subgraphRelations, Parameters: map[string]any{"ids": ids}},
+		Statement{Query: subgraphChunks, Parameters: map[string]any{"entities": reached, "limit": limit}},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand graph: %w", err)
+	}
+	for _, row := range results[0].Data {
+		sub.Relations = append(sub.Relations, graph.Relation{
+			Source:  str(row.Row[0]),
+			Target:  str(row.Row[1]),
+			Type:    str(row.Row[2]),
+			ChunkID: str(row.Row[3]),
+		})
+	}
+	for _, row := range results[1].Data {
+		sub.Chunks = append(sub.Chunks, graph.ChunkRef{ID: str(row.Row[0]), Hops: num(row.Row[1])})
+	}
+	return sub, nil
+}
+
+func str(v any) string {
+	s, _ := v.(string)
+	return s
+}
+
+// num reads an integer column, which JSON decodes as float64.
+func num(v any) int {
+	f, _ := v.(float64)
+	return int(f)
+}
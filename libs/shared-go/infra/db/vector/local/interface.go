@@ -0,0 +1,18 @@
+package local
+
+import "context"
+
+// Service defines the high-level vector store operations for RAG:
+// create collection, upsert points, similarity search, delete, and get by IDs.
+// ListCollections, ListPoints and DeleteCollection have no equivalent in the
+// remote stores; they exist because an in-process store is cheap to scan.
+type Service interface {
+	CreateCollection(ctx context.Context, req *CreateCollectionRequest) error
+	ListCollections(ctx context.Context) (*ListCollectionsResponse, error)
+	DeleteCollection(ctx context.Context, collectionName string) error
+	UpsertPoints(ctx context.Context, req *UpsertPointsRequest) error
+	Search(ctx context.Context, req *SearchRequest) (*SearchResponse, error)
+	DeletePoints(ctx context.Context, req *DeletePointsRequest) error
+	GetPointsByIDs(ctx context.Context, req *GetPointsByIDsRequest) (*GetPointsByIDsResponse, error)
+	ListPoints(ctx context.Context, req *ListPointsRequest) (*ListPointsResponse, error)
+}
@@ -0,0 +1,109 @@
+package local
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	ErrCollectionNotFound    = errors.New("collection not found")
+	ErrDimensionMismatch     = errors.New("vector dimension does not match the collection")
+	ErrInvalidCollectionName = errors.New("collection names may only use letters, digits, '-' and '_'")
+	ErrDirLocked             = errors.New("vector directory is in use by another process")
+	ErrReadOnly              = errors.New("vector store is open read-only")
+)
+
+type Vector []float32
+
+type Payload map[string]interface{}
+
+type Point struct {
+	ID      string  `json:"id"`
+	Vector  Vector  `json:"vector"`
+	Payload Payload `json:"payload,omitempty"`
+}
+
+type CreateCollectionRequest struct {
+	CollectionName string `json:"collection_name" validate:"required"`
+	VectorSize     uint64 `json:"vector_size" validate:"required,min=1"`
+	Distance       string `json:"distance,omitempty"` // Cosine, Euclid, Dot
+}
+
+// CollectionInfo describes a collection without its points.
+type CollectionInfo struct {
+	Name       string `json:"name"`
+	VectorSize uint64 `json:"vector_size"`
+	Distance   string `json:"distance"`
+	Points     int    `json:"points"`
+}
+
+type ListCollectionsResponse struct {
+	Collections []CollectionInfo `json:"collections"` // Ordered by name
+}
+
+type UpsertPointsRequest struct {
+	CollectionName string  `json:"collection_name" validate:"required"`
+	Points         []Point `json:"points" validate:"required,min=1"`
+}
+
+type SearchRequest struct {
+	CollectionName string   `json:"collection_name" validate:"required"`
+	Vector         Vector   `json:"vector" validate:"required"`
+	Limit          uint64   `json:"limit,omitempty"`           // Number of results
+	ScoreThreshold float32  `json:"score_threshold,omitempty"` // Minimum similarity score
+	Filter         *Payload `json:"filter,omitempty"`          // Optional metadata filter; every key must match
+	WithPayload    bool     `json:"with_payload,omitempty"`    // Include payload in results
+	WithVector     bool     `json:"with_vector,omitempty"`     // Include vector in results
+}
+
+type SearchResult struct {
+	ID      string  `json:"id"`
+	Score   float32 `json:"score"`
+	Payload Payload `json:"payload,omitempty"`
+	Vector  *Vector `json:"vector,omitempty"`
+}
+
+type SearchResponse struct {
+	Results []SearchResult `json:"results"`
+}
+
+type DeletePointsRequest struct {
+	CollectionName string   `json:"collection_name" validate:"required"`
+	PointIDs       []string `json:"point_ids" validate:"required,min=1"`
+}
+
+type GetPointsByIDsRequest struct {
+	CollectionName string   `json:"collection_name" validate:"required"`
+	PointIDs       []string `json:"point_ids" validate:"required,min=1"`
+	WithPayload    bool     `json:"with_payload,omitempty"` // Include payload in results
+	WithVector     bool     `json:"with_vector,omitempty"`  // Include vector in results
+}
+
+type GetPointsByIDsResponse struct {
+	Points []Point `json:"points"`
+}
+
+type ListPointsRequest struct {
+	CollectionName string   `json:"collection_name" validate:"required"`
+	Filter         *Payload `json:"filter,omitempty"`      // Optional metadata filter; every key must match
+	WithVector     bool     `json:"with_vector,omitempty"` // Include vectors in results
+}
+
+type ListPointsResponse struct {
+	Points []Point `json:"points"` // Ordered by ID
+}
+
+// collection is the stored form of a collection, also its on-disk format.
+type collection struct {
+	Name     string           `json:"name"`
+	Size     uint64           `json:"vector_size"`
+	Distance string           `json:"distance"`
+	Points   map[string]Point `json:"points"`
+
+	// mu guards Points. writeMu serializes changes with the save that
+	// persists them, so a slow save only holds up writers to this
+	// collection; deleted is set under writeMu once the collection is gone.
+	mu      sync.RWMutex
+	writeMu sync.Mutex
+	deleted bool
+}
@@ -0,0 +1,533 @@
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// validName keeps collection names usable as file names.
+var validName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,200}$`)
+
+// ValidateCollectionName returns ErrInvalidCollectionName for names that
+// CreateCollection would refuse.
+func ValidateCollectionName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidCollectionName, name)
+	}
+	return nil
+}
+
+// localService is an in-process vector store with exact (brute force)
+// search. With a directory each collection is persisted to <dir>/<name>.json
+// and rewritten on every change, which suits collections of up to tens of
+// thousands of points.
+//
+// mu only guards the collections map; each collection has its own locks, so
+// rewriting one collection never blocks searches or writes on another.
+type localService struct {
+	mu          sync.RWMutex
+	collections map[string]*collection
+	dir         string
+	lock        *os.File // held for as long as the store is in use
+	logger      *zap.Logger
+}
+
+// NewService returns an in-process vector store. dir may be empty to keep
+// everything in memory; otherwise it is created if needed, locked against
+// other processes, which get ErrDirLocked, and the collections already in it
+// are loaded.
+func NewService(dir string, logger *zap.Logger) (Service, error) {
+	s := &localService{
+		collections: make(map[string]*collection),
+		dir:         dir,
+		logger:      logger,
+	}
+	if dir == "" {
+		return s, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create vector directory %q: %w", dir, err)
+	}
+	lock, err := lockDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	s.lock = lock
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewReadOnlyService returns a snapshot of the collections in dir, taken
+// without locking it, so it can be opened while another process writes to
+// dir. Collection files are replaced atomically, so each is read whole.
+// Every change fails with ErrReadOnly.
+func NewReadOnlyService(dir string, logger *zap.Logger) (Service, error) {
+	s := &localService{
+		collections: make(map[string]*collection),
+		dir:         dir,
+		logger:      logger,
+	}
+	if dir != "" {
+		if err := s.load(); err != nil {
+			return nil, err
+		}
+	}
+	return readOnlyService{s}, nil
+}
+
+// load reads the collections persisted in s.dir.
+func (s *localService) load() error {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("read collection %q: %w", file, err)
+		}
+		var c collection
+		if err := json.Unmarshal(data, &c); err != nil {
+			return fmt.Errorf("decode collection %q: %w", file, err)
+		}
+		if c.Points == nil {
+			c.Points = make(map[string]Point)
+		}
+		s.collections[c.Name] = &c
+	}
+	s.logger.Info("loaded vector collections", zap.String("dir", s.dir), zap.Int("count", len(s.collections)))
+	return nil
+}
+
+// readOnlyService refuses every change to the store it wraps.
+type readOnlyService struct {
+	*localService
+}
+
+func (readOnlyService) CreateCollection(ctx context.Context, req *CreateCollectionRequest) error {
+	return ErrReadOnly
+}
+
+func (readOnlyService) DeleteCollection(ctx context.Context, collectionName string) error {
+	return ErrReadOnly
+}
+
+func (readOnlyService) UpsertPoints(ctx context.Context, req *UpsertPointsRequest) error {
+	return ErrReadOnly
+}
+
+func (readOnlyService) DeletePoints(ctx context.Context, req *DeletePointsRequest) error {
+	return ErrReadOnly
+}
+
+// CreateCollection creates a collection. Creating one that already exists
+// with the same vector size is a no-op.
+func (s *localService) CreateCollection(ctx context.Context, req *CreateCollectionRequest) error {
+	if req == nil {
+		return errors.New("CreateCollectionRequest is required")
+	}
+	if err := ValidateCollectionName(req.CollectionName); err != nil {
+		return err
+	}
+	if req.VectorSize == 0 {
+		return errors.New("vector size is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.collections[req.CollectionName]; ok {
+		if c.Size != req.VectorSize {
+			return fmt.Errorf("collection %q: %w", req.CollectionName, ErrDimensionMismatch)
+		}
+		return nil
+	}
+
+	c := &collection{
+		Name:     req.CollectionName,
+		Size:     req.VectorSize,
+		Distance: normalizeDistance(req.Distance),
+		Points:   make(map[string]Point),
+	}
+	if err := s.save(c); err != nil {
+		return err
+	}
+	s.collections[c.Name] = c
+	s.logger.Info("created collection", zap.String("collection", c.Name))
+	return nil
+}
+
+func (s *localService) ListCollections(ctx context.Context) (*ListCollectionsResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	collections := make([]CollectionInfo, 0, len(s.collections))
+	for _, c := range s.collections {
+		c.mu.RLock()
+		collections = append(collections, CollectionInfo{
+			Name:       c.Name,
+			VectorSize: c.Size,
+			Distance:   c.Distance,
+			Points:     len(c.Points),
+		})
+		c.mu.RUnlock()
+	}
+	sort.Slice(collections, func(i, j int) bool { return collections[i].Name < collections[j].Name })
+	return &ListCollectionsResponse{Collections: collections}, nil
+}
+
+func (s *localService) DeleteCollection(ctx context.Context, collectionName string) error {
+	s.mu.RLock()
+	c, ok := s.collections[collectionName]
+	s.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	// Wait for an in-flight save so it can't recreate the file afterwards.
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.deleted {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dir != "" {
+		if err := os.Remove(s.path(collectionName)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("delete collection %q: %w", collectionName, err)
+		}
+	}
+	delete(s.collections, collectionName)
+	c.deleted = true
+	return nil
+}
+
+func (s *localService) UpsertPoints(ctx context.Context, req *UpsertPointsRequest) error {
+	if req == nil {
+		return errors.New("UpsertPointsRequest is required")
+	}
+	if len(req.Points) == 0 {
+		return errors.New("at least one point is required")
+	}
+
+	c, err := s.lockForWrite(req.CollectionName)
+	if err != nil {
+		return err
+	}
+	defer c.writeMu.Unlock()
+
+	for _, p := range req.Points {
+		if strings.TrimSpace(p.ID) == "" {
+			return errors.New("point ID is required")
+		}
+		if uint64(len(p.Vector)) != c.Size {
+			return fmt.Errorf("point %q: %w", p.ID, ErrDimensionMismatch)
+		}
+	}
+
+	previous := make(map[string]Point, len(req.Points))
+	c.mu.Lock()
+	for _, p := range req.Points {
+		if old, ok := c.Points[p.ID]; ok {
+			previous[p.ID] = old
+		}
+		c.Points[p.ID] = Point{ID: p.ID, Vector: slices.Clone(p.Vector), Payload: maps.Clone(p.Payload)}
+	}
+	c.mu.Unlock()
+
+	if err := s.save(c); err != nil {
+		// Roll back so memory doesn't drift from disk.
+		c.mu.Lock()
+		for _, p := range req.Points {
+			if old, ok := previous[p.ID]; ok {
+				c.Points[p.ID] = old
+			} else {
+				delete(c.Points, p.ID)
+			}
+		}
+		c.mu.Unlock()
+		return err
+	}
+	s.logger.Debug("upserted points", zap.String("collection", c.Name), zap.Int("count", len(req.Points)))
+	return nil
+}
+
+func (s *localService) Search(ctx context.Context, req *SearchRequest) (*SearchResponse, error) {
+	if req == nil {
+		return nil, errors.New("SearchRequest is required")
+	}
+	if len(req.Vector) == 0 {
+		return nil, errors.New("search vector is required")
+	}
+
+	c, err := s.collection(req.CollectionName)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(req.Vector)) != c.Size {
+		return nil, fmt.Errorf("search %q: %w", c.Name, ErrDimensionMismatch)
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = 10
+	}
+
+	c.mu.RLock()
+	results := make([]SearchResult, 0, len(c.Points))
+	for _, p := range c.Points {
+		if !matches(p.Payload, req.Filter) {
+			continue
+		}
+		score := similarity(c.Distance, req.Vector, p.Vector)
+		if score < req.ScoreThreshold {
+			continue
+		}
+		r := SearchResult{ID: p.ID, Score: score}
+		if req.WithPayload {
+			r.Payload = maps.Clone(p.Payload)
+		}
+		if req.WithVector {
+			v := slices.Clone(p.Vector)
+			r.Vector = &v
+		}
+		results = append(results, r)
+	}
+	c.mu.RUnlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return &SearchResponse{Results: results}, nil
+}
+
+func (s *localService) DeletePoints(ctx context.Context, req *DeletePointsRequest) error {
+	if req == nil {
+		return errors.New("DeletePointsRequest is required")
+	}
+	if len(req.PointIDs) == 0 {
+		return errors.New("at least one point id is required")
+	}
+
+	c, err := s.lockForWrite(req.CollectionName)
+	if err != nil {
+		return err
+	}
+	defer c.writeMu.Unlock()
+
+	removed := make(map[string]Point, len(req.PointIDs))
+	c.mu.Lock()
+	for _, id := range req.PointIDs {
+		if p, ok := c.Points[id]; ok {
+			removed[id] = p
+			delete(c.Points, id)
+		}
+	}
+	c.mu.Unlock()
+	if len(removed) == 0 {
+		return nil
+	}
+	if err := s.save(c); err != nil {
+		c.mu.Lock()
+		maps.Copy(c.Points, removed)
+		c.mu.Unlock()
+		return err
+	}
+	s.logger.Debug("deleted points", zap.String("collection", c.Name), zap.Int("count", len(removed)))
+	return nil
+}
+
+func (s *localService) GetPointsByIDs(ctx context.Context, req *GetPointsByIDsRequest) (*GetPointsByIDsResponse, error) {
+	if req == nil {
+		return nil, errors.New("GetPointsByIDsRequest is required")
+	}
+	if len(req.PointIDs) == 0 {
+		return nil, errors.New("at least one point id is required")
+	}
+
+	c, err := s.collection(req.CollectionName)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	points := make([]Point, 0, len(req.PointIDs))
+	for _, id := range req.PointIDs {
+		if p, ok := c.Points[id]; ok {
+			points = append(points, copyPoint(p, req.WithPayload, req.WithVector))
+		}
+	}
+	return &GetPointsByIDsResponse{Points: points}, nil
+}
+
+func (s *localService) ListPoints(ctx context.Context, req *ListPointsRequest) (*ListPointsResponse, error) {
+	if req == nil {
+		return nil, errors.New("ListPointsRequest is required")
+	}
+
+	c, err := s.collection(req.CollectionName)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.RLock()
+	points := make([]Point, 0, len(c.Points))
+	for _, p := range c.Points {
+		if matches(p.Payload, req.Filter) {
+			points = append(points, copyPoint(p, true, req.WithVector))
+		}
+	}
+	c.mu.RUnlock()
+	sort.Slice(points, func(i, j int) bool { return points[i].ID < points[j].ID })
+	return &ListPointsResponse{Points: points}, nil
+}
+
+func (s *localService) collection(name string) (*collection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.collections[name]
+	if !ok {
+		return nil, fmt.Errorf("collection %q: %w", name, ErrCollectionNotFound)
+	}
+	return c, nil
+}
+
+// lockForWrite returns the named collection with its writeMu held. The
+// caller must unlock it.
+func (s *localService) lockForWrite(name string) (*collection, error) {
+	c, err := s.collection(name)
+	if err != nil {
+		return nil, err
+	}
+	c.writeMu.Lock()
+	if c.deleted {
+		c.writeMu.Unlock()
+		return nil, fmt.Errorf("collection %q: %w", name, ErrCollectionNotFound)
+	}
+	return c, nil
+}
+
+// save writes c to disk atomically via a temporary file. It must be called
+// with c.writeMu held, and holds c.mu for reading only while encoding.
+func (s *localService) save(c *collection) error {
+	if s.dir == "" {
+		return nil
+	}
+
+	c.mu.RLock()
+	data, err := json.Marshal(c)
+	c.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("encode collection %q: %w", c.Name, err)
+	}
+	tmp, err := os.CreateTemp(s.dir, c.Name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("save collection %q: %w", c.Name, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("save collection %q: %w", c.Name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("save collection %q: %w", c.Name, err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(c.Name)); err != nil {
+		return fmt.Errorf("save collection %q: %w", c.Name, err)
+	}
+	return nil
+}
+
+func (s *localService) path(name string) string {
+	return filepath.Join(s.dir, name+".json")
+}
+
+func normalizeDistance(d string) string {
+	switch strings.ToLower(strings.TrimSpace(d)) {
+	case "cosine", "":
+		return "cosine"
+	case "euclid", "l2":
+		return "l2"
+	case "dot":
+		return "dot"
+	default:
+		return "cosine"
+	}
+}
+
+// similarity scores b against a so that higher is closer. Euclidean
+// distances are mapped onto (0, 1].
+func similarity(distance string, a, b Vector) float32 {
+	var dot, normA, normB, sq float64
+	for i := range a {
+		x, y := float64(a[i]), float64(b[i])
+		dot += x * y
+		normA += x * x
+		normB += y * y
+		sq += (x - y) * (x - y)
+	}
+
+	switch distance {
+	case "dot":
+		return float32(dot)
+	case "l2":
+		return float32(1 / (1 + math.Sqrt(sq)))
+	default:
+		if normA == 0 || normB == 0 {
+			return 0
+		}
+		return float32(dot / (math.Sqrt(normA) * math.Sqrt(normB)))
+	}
+}
+
+// matches reports whether payload has every key of filter with an equal
+// value. Values are compared by their printed form, so 3 matches 3.0 after a
+// round trip through JSON.
+func matches(payload Payload, filter *Payload) bool {
+	if filter == nil {
+		return true
+	}
+	for k, want := range *filter {
+		got, ok := payload[k]
+		if !ok || fmt.Sprint(got) != fmt.Sprint(want) {
+			return false
+		}
+	}
+	return true
+}
+
+func copyPoint(p Point, withPayload, withVector bool) Point {
+	out := Point{ID: p.ID}
+	if withPayload {
+		out.Payload = maps.Clone(p.Payload)
+	}
+	if withVector {
+		out.Vector = slices.Clone(p.Vector)
+	}
+	return out
+}
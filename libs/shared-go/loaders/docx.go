@@ -0,0 +1,325 @@
+package loaders
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Namespace of WordprocessingML elements.
+const wordNS = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+
+// maxZipEntry bounds what one file of a DOCX or EPUB may inflate to.
+const maxZipEntry = 64 << 20
+
+var headingStyle = regexp.MustCompile(`(?i)^heading\s*([1-9])$`)
+
+// Dublin Core properties of docProps/core.xml kept as document metadata.
+var coreProperties = map[string]string{
+	"creator":  "author",
+	"subject":  "subject",
+	"keywords": "keywords",
+	"language": "language",
+	"created":  "created",
+	"modified": "modified",
+}
+
+// DOCXLoader loads Word documents. Paragraphs styled as headings, or with an
+// outline level, start sections; tables become Markdown tables and list
+// paragraphs become list items.
+type DOCXLoader struct{}
+
+func (DOCXLoader) Load(ctx context.Context, data []byte, src Source) (*Document, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open DOCX: %w", err)
+	}
+
+	body, err := readZipFile(archive, "word/document.xml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DOCX: %w", err)
+	}
+	// Styles and properties are optional parts.
+	levels := docxHeadingStyles(archive)
+	doc := &Document{Format: FormatDOCX, Metadata: docxProperties(archive)}
+	doc.Title = doc.Metadata["title"]
+	delete(doc.Metadata, "title")
+
+	b := newSectionBuilder()
+	if err := (&docxReader{b: b, levels: levels}).read(body); err != nil {
+		return nil, fmt.Errorf("failed to parse DOCX: %w", err)
+	}
+	return finishDocument(doc, b, src)
+}
+
+// docxReader streams word/document.xml into a sectionBuilder.
+type docxReader struct {
+	b      *sectionBuilder
+	levels map[string]int // heading level by style ID
+
+	text    strings.Builder // paragraph being read
+	level   int             // heading level of the paragraph, 0 for body text
+	list    bool            // the paragraph is a list item
+	depth   int             // list nesting of the paragraph
+	inRun   bool            // inside w:t, whose character data is text
+	inProps bool            // inside w:pPr, whose tabs are tab stops
+	deleted int             // inside tracked deletions
+
+	tables []*docxTable // open tables, innermost last
+}
+
+type docxTable struct {
+	rows [][]string
+	row  []string
+	cell []string
+}
+
+func (r *docxReader) read(data []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space == wordNS {
+				r.start(t)
+			}
+		case xml.EndElement:
+			if t.Name.Space == wordNS {
+				r.end(t.Name.Local)
+			}
+		case xml.CharData:
+			if r.inRun && r.deleted == 0 {
+				r.text.Write(t)
+			}
+		}
+	}
+}
+
+func (r *docxReader) start(t xml.StartElement) {
+	switch t.Name.Local {
+	case "p":
+		r.text.Reset()
+		r.level, r.list, r.depth = 0, false, 0
+	case "pStyle":
+		if level := r.levels[wordAttr(t, "val")]; level > 0 {
+			r.level = level
+		} else if m := headingStyle.FindStringSubmatch(wordAttr(t, "val")); m != nil {
+			r.level, _ = strconv.Atoi(m[1])
+		}
+	case "outlineLvl":
+		// Level 9 is body text.
+		if level, err := strconv.Atoi(wordAttr(t, "val")); err == nil && level < 9 {
+			r.level = level + 1
+		}
+	case "pPr":
+		r.inProps = true
+	case "numPr":
+		r.list = true
+	case "ilvl":
+		r.depth, _ = strconv.Atoi(wordAttr(t, "val"))
+	case "t":
+		r.inRun = true
+	case "tab":
+		if !r.inProps {
+			r.text.WriteString("\t")
+		}
+	case "br", "cr":
+		r.text.WriteString("\n")
+	case "del":
+		r.deleted++
+	case "tbl":
+		r.tables = append(r.tables, &docxTable{})
+	case "tr":
+		if table := r.table(); table != nil {
+			table.row = nil
+		}
+	case "tc":
+		if table := r.table(); table != nil {
+			table.cell = nil
+		}
+	}
+}
+
+func (r *docxReader) end(name string) {
+	switch name {
+	case "t":
+		r.inRun = false
+	case "pPr":
+		r.inProps = false
+	case "del":
+		r.deleted--
+	case "p":
+		r.paragraph()
+	case "tc":
+		if table := r.table(); table != nil {
+			table.row = append(table.row, strings.Join(table.cell, " "))
+		}
+	case "tr":
+		if table := r.table(); table != nil && len(table.row) > 0 {
+			table.rows = append(table.rows, table.row)
+		}
+	case "tbl":
+		table := r.table()
+		if table == nil {
+			return
+		}
+		r.tables = r.tables[:len(r.tables)-1]
+		if len(table.rows) == 0 {
+			return
+		}
+		// A table nested in a cell is flattened into the outer cell.
+		if outer := r.table(); outer != nil {
+			for _, row := range table.rows {
+				outer.cell = append(outer.cell, strings.Join(row, " "))
+			}
+			return
+		}
+		r.b.block(markdownTable(table.rows))
+	}
+}
+
+// paragraph ends the paragraph being read, adding it to the open table cell
+// or to the document.
+func (r *docxReader) paragraph() {
+	text := r.text.String()
+	r.text.Reset()
+
+	if table := r.table(); table != nil {
+		if text = collapseSpace(text); text != "" {
+			table.cell = append(table.cell, text)
+		}
+		return
+	}
+
+	switch {
+	case r.level > 0:
+		r.b.heading(r.level, text, "")
+	case r.list:
+		r.b.item(strings.Repeat("  ", r.depth) + "- " + collapseSpace(text))
+	default:
+		lines := strings.Split(text, "\n")
+		for i, line := range lines {
+			lines[i] = collapseSpace(line)
+		}
+		r.b.block(strings.Join(lines, "\n"))
+	}
+}
+
+func (r *docxReader) table() *docxTable {
+	if len(r.tables) == 0 {
+		return nil
+	}
+	return r.tables[len(r.tables)-1]
+}
+
+// docxHeadingStyles maps the IDs of heading styles in word/styles.xml to
+// their level. Style IDs are localized ("berschrift1" in German Word), so
+// levels come from the outline level or the English style name.
+func docxHeadingStyles(archive *zip.Reader) map[string]int {
+	data, err := readZipFile(archive, "word/styles.xml")
+	if err != nil {
+		return nil
+	}
+
+	var styles struct {
+		Styles []struct {
+			ID   string `xml:"styleId,attr"`
+			Name struct {
+				Val string `xml:"val,attr"`
+			} `xml:"name"`
+			Outline *struct {
+				Val int `xml:"val,attr"`
+			} `xml:"pPr>outlineLvl"`
+		} `xml:"style"`
+	}
+	if err := xml.Unmarshal(data, &styles); err != nil {
+		return nil
+	}
+
+	levels := make(map[string]int)
+	for _, s := range styles.Styles {
+		switch {
+		case s.Outline != nil && s.Outline.Val < 9:
+			levels[s.ID] = s.Outline.Val + 1
+		case strings.EqualFold(s.Name.Val, "title"):
+			levels[s.ID] = 1
+		default:
+			if m := headingStyle.FindStringSubmatch(s.Name.Val); m != nil {
+				levels[s.ID], _ = strconv.Atoi(m[1])
+			}
+		}
+	}
+	return levels
+}
+
+// docxProperties reads the title, author and dates of docProps/core.xml.
+func docxProperties(archive *zip.Reader) map[string]string {
+	meta := make(map[string]string)
+	data, err := readZipFile(archive, "docProps/core.xml")
+	if err != nil {
+		return meta
+	}
+
+	var props struct {
+		Fields []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	}
+	if err := xml.Unmarshal(data, &props); err != nil {
+		return meta
+	}
+	for _, f := range props.Fields {
+		value := collapseSpace(f.Value)
+		if value == "" {
+			continue
+		}
+		if f.XMLName.Local == "title" {
+			meta["title"] = value
+		} else if key := coreProperties[f.XMLName.Local]; key != "" {
+			meta[key] = value
+		}
+	}
+	return meta
+}
+
+func wordAttr(t xml.StartElement, local string) string {
+	for _, a := range t.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// readZipFile returns the contents of the named file of archive, refusing
+// files that inflate beyond maxZipEntry.
+func readZipFile(archive *zip.Reader, name string) ([]byte, error) {
+	f, err := archive.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxZipEntry+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxZipEntry {
+		return nil, fmt.Errorf("%s is larger than %d bytes", name, maxZipEntry)
+	}
+	return data, nil
+}
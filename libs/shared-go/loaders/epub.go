@@ -0,0 +1,143 @@
+package loaders
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Dublin Core elements of the OPF package kept as document metadata.
+var epubMetadata = map[string]string{
+	"creator":   "author",
+	"language":  "language",
+	"publisher": "publisher",
+	"date":      "published",
+	"subject":   "subject",
+}
+
+// EPUBLoader loads EPUB books, reading their chapters in spine order.
+// Section anchors point into the chapter files, e.g. "ch02.xhtml#setup".
+type EPUBLoader struct{}
+
+type epubPackage struct {
+	Metadata struct {
+		Fields []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	} `xml:"metadata"`
+	Manifest []struct {
+		ID        string `xml:"id,attr"`
+		Href      string `xml:"href,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef  string `xml:"idref,attr"`
+		Linear string `xml:"linear,attr"`
+	} `xml:"spine>itemref"`
+}
+
+func (EPUBLoader) Load(ctx context.Context, data []byte, src Source) (*Document, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open EPUB: %w", err)
+	}
+
+	opfPath, err := epubRootFile(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read EPUB: %w", err)
+	}
+	opf, err := readZipFile(archive, opfPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read EPUB: %w", err)
+	}
+	var pkg epubPackage
+	if err := xml.Unmarshal(opf, &pkg); err != nil {
+		return nil, fmt.Errorf("failed to parse EPUB package: %w", err)
+	}
+
+	doc := &Document{Format: FormatEPUB, Metadata: make(map[string]string)}
+	for _, f := range pkg.Metadata.Fields {
+		value := collapseSpace(f.Value)
+		if value == "" {
+			continue
+		}
+		if f.XMLName.Local == "title" && doc.Title == "" {
+			doc.Title = value
+		} else if key := epubMetadata[f.XMLName.Local]; key != "" && doc.Metadata[key] == "" {
+			doc.Metadata[key] = value
+		}
+	}
+
+	hrefs := make(map[string]string, len(pkg.Manifest))
+	for _, item := range pkg.Manifest {
+		if item.MediaType == "application/xhtml+xml" || item.MediaType == "text/html" {
+			hrefs[item.ID] = item.Href
+		}
+	}
+
+	b := newSectionBuilder()
+	base := path.Dir(opfPath)
+	for _, ref := range pkg.Spine {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		href := hrefs[ref.IDRef]
+		if href == "" || ref.Linear == "no" {
+			continue
+		}
+
+		name := href
+		if unescaped, err := url.PathUnescape(href); err == nil {
+			name = unescaped
+		}
+		chapter, err := readZipFile(archive, path.Join(base, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read EPUB chapter %s: %w", href, err)
+		}
+		root, err := html.Parse(bytes.NewReader(chapter))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse EPUB chapter %s: %w", href, err)
+		}
+		body := findElement(root, func(n *html.Node) bool { return n.DataAtom == atom.Body })
+		if body == nil {
+			continue
+		}
+
+		b.anchor(href)
+		(&htmlConverter{b: b, anchorBase: href}).convert(body)
+	}
+	return finishDocument(doc, b, src)
+}
+
+// epubRootFile returns the path of the OPF package named by
+// META-INF/container.xml.
+func epubRootFile(archive *zip.Reader) (string, error) {
+	data, err := readZipFile(archive, "META-INF/container.xml")
+	if err != nil {
+		return "", err
+	}
+	var container struct {
+		RootFiles []struct {
+			FullPath  string `xml:"full-path,attr"`
+			MediaType string `xml:"media-type,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := xml.Unmarshal(data, &container); err != nil {
+		return "", err
+	}
+	for _, rf := range container.RootFiles {
+		if rf.MediaType == "" || strings.Contains(rf.MediaType, "oebps-package") {
+			return rf.FullPath, nil
+		}
+	}
+	return "", fmt.Errorf("no package in META-INF/container.xml")
+}
@@ -0,0 +1,186 @@
+package loaders
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+// Meta tags kept as document metadata, by name or property.
+var htmlMeta = map[string]string{
+	"description":            "description",
+	"author":                 "author",
+	"keywords":               "keywords",
+	"og:site_name":           "site",
+	"article:published_time": "published",
+	"article:modified_time":  "modified",
+}
+
+// HTMLLoader loads the main content of a web page, leaving out navigation,
+// sidebars, footers and similar furniture. Headings start sections anchored
+// at their id.
+type HTMLLoader struct{}
+
+func (HTMLLoader) Load(ctx context.Context, data []byte, src Source) (*Document, error) {
+	r, err := charset.NewReader(bytes.NewReader(data), src.MIMEType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode HTML: %w", err)
+	}
+	root, err := html.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	doc := &Document{Format: FormatHTML, Metadata: htmlMetadata(root)}
+	content := mainContent(root)
+
+	b := newSectionBuilder()
+	c := &htmlConverter{b: b, skipHeader: content.DataAtom == atom.Body || content.Type == html.DocumentNode}
+	c.convert(content)
+
+	// The page's own heading beats the <title>, which usually carries the
+	// site name too.
+	if b.title == "" {
+		doc.Title = doc.Metadata["title"]
+	}
+	delete(doc.Metadata, "title")
+	return finishDocument(doc, b, src)
+}
+
+// mainContent returns the element holding the page's content: <main> or the
+// main role, a lone <article>, or else the container with the most paragraph
+// text.
+func mainContent(root *html.Node) *html.Node {
+	if n := findElement(root, func(n *html.Node) bool {
+		return n.DataAtom == atom.Main || attr(n, "role") == "main"
+	}); n != nil {
+		return n
+	}
+
+	var articles []*html.Node
+	walkElements(root, func(n *html.Node) bool {
+		if n.DataAtom == atom.Article {
+			articles = append(articles, n)
+			return false
+		}
+		return !skippedElements[n.DataAtom] && !isFurniture(n)
+	})
+	if len(articles) == 1 {
+		return articles[0]
+	}
+
+	body := findElement(root, func(n *html.Node) bool { return n.DataAtom == atom.Body })
+	if body == nil {
+		return root
+	}
+
+	// Credit each paragraph's text to its parent, and half of it to the
+	// grandparent, so the container of the article body wins.
+	scores := make(map[*html.Node]int)
+	var candidates []*html.Node // in document order, so ties go to the first
+	credit := func(n *html.Node, length int) {
+		if _, ok := scores[n]; !ok {
+			candidates = append(candidates, n)
+		}
+		scores[n] += length
+	}
+	total := 0
+	walkElements(body, func(n *html.Node) bool {
+		if skippedElements[n.DataAtom] || isFurniture(n) {
+			return false
+		}
+		if n.DataAtom != atom.P && n.DataAtom != atom.Pre {
+			return true
+		}
+		length := len(inlineText(n))
+		total += length
+		if parent := n.Parent; parent != nil {
+			credit(parent, length)
+			if grand := parent.Parent; grand != nil {
+				credit(grand, length/2)
+			}
+		}
+		return false
+	})
+
+	best, bestScore := body, 0
+	for _, n := range candidates {
+		if scores[n] > bestScore {
+			best, bestScore = n, scores[n]
+		}
+	}
+	// Text spread over many containers is better read whole.
+	if bestScore*2 < total {
+		return body
+	}
+	return best
+}
+
+// htmlMetadata reads the title, language and meta tags of a page.
+func htmlMetadata(root *html.Node) map[string]string {
+	meta := make(map[string]string)
+	walkElements(root, func(n *html.Node) bool {
+		switch n.DataAtom {
+		case atom.Html:
+			if lang := attr(n, "lang"); lang != "" {
+				meta["language"] = lang
+			}
+		case atom.Title:
+			if meta["title"] == "" {
+				meta["title"] = collapseSpace(rawText(n))
+			}
+		case atom.Meta:
+			name := strings.ToLower(attr(n, "name"))
+			if name == "" {
+				name = strings.ToLower(attr(n, "property"))
+			}
+			content := collapseSpace(attr(n, "content"))
+			if name == "og:title" && content != "" {
+				meta["title"] = content
+			} else if key := htmlMeta[name]; key != "" && content != "" {
+				meta[key] = content
+			}
+		case atom.Link:
+			if strings.EqualFold(attr(n, "rel"), "canonical") && attr(n, "href") != "" {
+				meta["canonical_url"] = attr(n, "href")
+			}
+		case atom.Body:
+			return false
+		}
+		return true
+	})
+	return meta
+}
+
+// walkElements calls fn on every element under n in document order,
+// descending into an element only when fn returns true.
+func walkElements(n *html.Node, fn func(*html.Node) bool) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && !fn(child) {
+			continue
+		}
+		walkElements(child, fn)
+	}
+}
+
+// findElement returns the first element under n matching fn, outside of
+// skipped elements.
+func findElement(n *html.Node, fn func(*html.Node) bool) *html.Node {
+	var found *html.Node
+	walkElements(n, func(n *html.Node) bool {
+		if found != nil || skippedElements[n.DataAtom] {
+			return false
+		}
+		if fn(n) {
+			found = n
+			return false
+		}
+		return true
+	})
+	return found
+}
@@ -0,0 +1,353 @@
+package loaders
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Elements that never hold the text of a page.
+var skippedElements = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Math:     true,
+	atom.Canvas:   true,
+	atom.Object:   true,
+	atom.Iframe:   true,
+	atom.Nav:      true,
+	atom.Aside:    true,
+	atom.Footer:   true,
+	atom.Form:     true,
+	atom.Button:   true,
+	atom.Select:   true,
+	atom.Dialog:   true,
+}
+
+// Roles of page furniture rather than content.
+var skippedRoles = map[string]bool{
+	"navigation":    true,
+	"banner":        true,
+	"contentinfo":   true,
+	"complementary": true,
+	"search":        true,
+	"dialog":        true,
+}
+
+// boilerplate matches the class or id of page furniture.
+var boilerplate = regexp.MustCompile(`(?i)(^|[\s_-])(nav|navbar|navigation|sidebar|breadcrumbs?|cookies?|banner|advert|advertisement|social|share|comments?|related|footer|toc)([\s_-]|$)`)
+
+var headingLevels = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+// Elements that start and end a paragraph.
+var blockElements = map[atom.Atom]bool{
+	atom.P:          true,
+	atom.Div:        true,
+	atom.Section:    true,
+	atom.Article:    true,
+	atom.Main:       true,
+	atom.Header:     true,
+	atom.Figure:     true,
+	atom.Figcaption: true,
+	atom.Dl:         true,
+	atom.Dt:         true,
+	atom.Dd:         true,
+	atom.Address:    true,
+	atom.Details:    true,
+	atom.Summary:    true,
+	atom.Li:         true,
+	atom.Body:       true,
+}
+
+// htmlConverter writes the content of an HTML tree into a sectionBuilder,
+// with headings starting sections and lists, tables and preformatted text
+// rendered as Markdown.
+type htmlConverter struct {
+	b    *sectionBuilder
+	para strings.Builder
+
+	anchorBase string // prefixed to heading anchors, e.g. the chapter file of an EPUB
+	skipHeader bool   // drop <header>, which is page furniture outside an article
+}
+
+// convert writes the children of root.
+func (c *htmlConverter) convert(root *html.Node) {
+	for child := root.FirstChild; child != nil; child = child.NextSibling {
+		c.node(child)
+	}
+	c.flush()
+}
+
+func (c *htmlConverter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		c.text(n.Data)
+		return
+	case html.ElementNode:
+	case html.DocumentNode:
+		c.convert(n)
+		return
+	default:
+		return
+	}
+	if c.skip(n) {
+		return
+	}
+
+	if level, ok := headingLevels[n.DataAtom]; ok {
+		c.flush()
+		c.b.heading(level, inlineText(n), c.headingAnchor(n))
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		c.para.WriteString("\n")
+	case atom.Hr:
+		c.flush()
+	case atom.Pre:
+		c.flush()
+		c.b.block("```\n" + strings.Trim(rawText(n), "\n") + "\n```")
+	case atom.Table:
+		c.flush()
+		c.table(n)
+	case atom.Ul, atom.Ol:
+		c.flush()
+		c.b.endList()
+		c.list(n, 0)
+	case atom.Blockquote:
+		c.flush()
+		if text := inlineText(n); text != "" {
+			c.b.block("> " + text)
+		}
+	case atom.Code, atom.Kbd, atom.Samp:
+		if code := collapseSpace(rawText(n)); code != "" {
+			c.para.WriteString("`" + code + "`")
+		}
+	default:
+		block := blockElements[n.DataAtom]
+		if block {
+			c.flush()
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			c.node(child)
+		}
+		if block {
+			c.flush()
+		}
+	}
+}
+
+// text adds inline text, collapsing whitespace the way a browser would.
+func (c *htmlConverter) text(s string) {
+	if s == "" {
+		return
+	}
+	if strings.TrimSpace(s) == "" {
+		c.para.WriteString(" ")
+		return
+	}
+	if isSpace(s[0]) {
+		c.para.WriteString(" ")
+	}
+	c.para.WriteString(collapseSpace(s))
+	if isSpace(s[len(s)-1]) {
+		c.para.WriteString(" ")
+	}
+}
+
+// flush ends the paragraph being read, keeping its line breaks.
+func (c *htmlConverter) flush() {
+	lines := strings.Split(c.para.String(), "\n")
+	for i, line := range lines {
+		lines[i] = collapseSpace(line)
+	}
+	c.b.block(strings.Join(lines, "\n"))
+	c.para.Reset()
+}
+
+func (c *htmlConverter) skip(n *html.Node) bool {
+	if skippedElements[n.DataAtom] || (n.DataAtom == atom.Header && c.skipHeader) {
+		return true
+	}
+	return isFurniture(n)
+}
+
+func (c *htmlConverter) headingAnchor(n *html.Node) string {
+	id := attr(n, "id")
+	if id == "" {
+		// Older pages put the target in an <a name> inside the heading.
+		for child := n.FirstChild; child != nil && id == ""; child = child.NextSibling {
+			if child.DataAtom == atom.A {
+				id = attr(child, "id")
+				if id == "" {
+					id = attr(child, "name")
+				}
+			}
+		}
+	}
+	if id != "" {
+		return c.anchorBase + "#" + id
+	}
+	return c.anchorBase
+}
+
+// list renders a list as Markdown items, indenting nested lists.
+func (c *htmlConverter) list(n *html.Node, depth int) {
+	number := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil {
+		number = start
+	}
+
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.DataAtom != atom.Li || c.skip(li) {
+			continue
+		}
+
+		var text strings.Builder
+		var nested []*html.Node
+		for child := li.FirstChild; child != nil; child = child.NextSibling {
+			if child.DataAtom == atom.Ul || child.DataAtom == atom.Ol {
+				nested = append(nested, child)
+				continue
+			}
+			text.WriteString(" " + inlineText(child))
+		}
+
+		marker := "-"
+		if n.DataAtom == atom.Ol {
+			marker = strconv.Itoa(number) + "."
+			number++
+		}
+		if item := collapseSpace(text.String()); item != "" {
+			c.b.item(strings.Repeat("  ", depth) + marker + " " + item)
+		}
+		for _, list := range nested {
+			c.list(list, depth+1)
+		}
+	}
+}
+
+// table renders a table as Markdown, after its caption. Nested tables are
+// flattened into their cell.
+func (c *htmlConverter) table(n *html.Node) {
+	var rows [][]string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			switch child.DataAtom {
+			case atom.Caption:
+				c.b.block(inlineText(child))
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				walk(child)
+			case atom.Tr:
+				var row []string
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.DataAtom == atom.Td || cell.DataAtom == atom.Th {
+						row = append(row, inlineText(cell))
+					}
+				}
+				if len(row) > 0 {
+					rows = append(rows, row)
+				}
+			}
+		}
+	}
+	walk(n)
+
+	if len(rows) > 0 {
+		c.b.block(markdownTable(rows))
+	}
+}
+
+// inlineText returns the text of n on one line.
+func inlineText(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			sb.WriteString(n.Data)
+			return
+		case n.Type != html.ElementNode:
+			return
+		case skippedElements[n.DataAtom]:
+			return
+		case n.DataAtom == atom.Br:
+			sb.WriteString(" ")
+			return
+		case n.DataAtom == atom.Code:
+			if code := collapseSpace(rawText(n)); code != "" {
+				sb.WriteString("`" + code + "`")
+			}
+			return
+		}
+		if blockElements[n.DataAtom] {
+			sb.WriteString(" ")
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return collapseSpace(sb.String())
+}
+
+// rawText returns the text of n as is, with <br> as a line break.
+func rawText(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			sb.WriteString(n.Data)
+		case n.DataAtom == atom.Br:
+			sb.WriteString("\n")
+		default:
+			for child := n.FirstChild; child != nil; child = child.NextSibling {
+				walk(child)
+			}
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+// isFurniture reports whether n is hidden or looks like navigation, ads or
+// similar page furniture.
+func isFurniture(n *html.Node) bool {
+	if hasAttr(n, "hidden") || attr(n, "aria-hidden") == "true" || skippedRoles[attr(n, "role")] {
+		return true
+	}
+	return boilerplate.MatchString(attr(n, "class") + " " + attr(n, "id"))
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
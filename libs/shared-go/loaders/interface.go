@@ -0,0 +1,9 @@
+package loaders
+
+import "context"
+
+// Loader turns the contents of one file into a normalized Document. Loaders
+// are pure Go and safe for concurrent use.
+type Loader interface {
+	Load(ctx context.Context, data []byte, src Source) (*Document, error)
+}
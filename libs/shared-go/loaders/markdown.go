@@ -0,0 +1,133 @@
+package loaders
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+var (
+	atxHeading    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	setextLine    = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	fenceOpen     = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	fenceClose    = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})[ \t]*$")
+	headingID     = regexp.MustCompile(`[ \t]*\{#([^}\s]+)\}$`)
+	notParagraph  = regexp.MustCompile(`^ {0,3}([-*+]\s|\d+[.)]\s|>|\|)`)
+	markdownLink  = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	inlineMarkers = strings.NewReplacer("**", "", "__", "", "`", "", "*", "")
+)
+
+// MarkdownLoader loads Markdown, splitting it into sections at ATX and setext
+// headings. The text is kept as Markdown; YAML front matter becomes metadata.
+type MarkdownLoader struct{}
+
+func (MarkdownLoader) Load(ctx context.Context, data []byte, src Source) (*Document, error) {
+	doc := &Document{Format: FormatMarkdown, Metadata: make(map[string]string)}
+	text := frontMatter(normalizeText(string(data)), doc)
+
+	b := newSectionBuilder()
+	var (
+		para  []string
+		fence string // opening marker of the code block being read
+	)
+	flush := func() {
+		b.block(strings.Join(para, "\n"))
+		para = nil
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		if fence != "" {
+			para = append(para, line)
+			if m := fenceClose.FindStringSubmatch(line); m != nil && m[1][0] == fence[0] && len(m[1]) >= len(fence) {
+				fence = ""
+				flush()
+			}
+			continue
+		}
+
+		if m := fenceOpen.FindStringSubmatch(line); m != nil {
+			flush()
+			fence = m[1]
+			para = append(para, line)
+			continue
+		}
+		if m := atxHeading.FindStringSubmatch(line); m != nil {
+			flush()
+			heading, anchor := markdownHeading(m[2])
+			b.heading(len(m[1]), heading, anchor)
+			continue
+		}
+		if m := setextLine.FindStringSubmatch(line); m != nil {
+			if len(para) > 0 && !notParagraph.MatchString(para[0]) {
+				level := 2
+				if m[1][0] == '=' {
+					level = 1
+				}
+				heading, anchor := markdownHeading(strings.Join(para, " "))
+				para = nil
+				b.heading(level, heading, anchor)
+				continue
+			}
+			if m[1][0] == '-' && len(m[1]) >= 3 {
+				flush() // thematic break
+				continue
+			}
+		}
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		para = append(para, line)
+	}
+	flush()
+
+	return finishDocument(doc, b, src)
+}
+
+// markdownHeading strips inline markup from heading text and returns it with
+// the anchor of an explicit {#id}, if any.
+func markdownHeading(text string) (string, string) {
+	anchor := ""
+	if m := headingID.FindStringSubmatch(text); m != nil {
+		anchor = "#" + m[1]
+		text = text[:len(text)-len(m[0])]
+	}
+	text = markdownLink.ReplaceAllString(text, "$1")
+	return inlineMarkers.Replace(text), anchor
+}
+
+// frontMatter moves the top-level scalars of a leading YAML front matter
+// block into doc and returns the text after it.
+func frontMatter(text string, doc *Document) string {
+	if !strings.HasPrefix(text, "---\n") {
+		return text
+	}
+	end := strings.Index(text[4:], "\n---")
+	if end < 0 {
+		return text
+	}
+	block, rest := text[4:4+end], text[4+end+4:]
+	if i := strings.IndexByte(rest, '\n'); i >= 0 {
+		rest = rest[i+1:]
+	} else {
+		rest = ""
+	}
+
+	for _, line := range strings.Split(block, "\n") {
+		if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '#' || line[0] == '-' {
+			continue
+		}
+		key, value, found := strings.Cut(line, ":")
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		if !found || value == "" || value == "|" || value == ">" {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "title" {
+			doc.Title = value
+			continue
+		}
+		doc.Metadata[key] = value
+	}
+	return rest
+}
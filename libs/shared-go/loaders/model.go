@@ -0,0 +1,61 @@
+package loaders
+
+import (
+	"errors"
+	"strings"
+)
+
+// Formats of the built-in loaders.
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatText     = "text"
+	FormatDOCX     = "docx"
+	FormatEPUB     = "epub"
+	FormatPDF      = "pdf"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported document format")
+	ErrEmptyDocument     = errors.New("document has no text")
+)
+
+// Source describes the file being loaded.
+type Source struct {
+	Name     string // File name or URL; its extension helps pick a loader
+	MIMEType string // Declared content type, may be empty
+}
+
+// Document is a loaded file, split into sections at its headings or pages.
+type Document struct {
+	Title    string            `json:"title"`
+	Format   string            `json:"format"`
+	Source   string            `json:"source"`
+	Metadata map[string]string `json:"metadata,omitempty"` // author, language, dates... as found in the file
+	Sections []Section         `json:"sections"`
+}
+
+// Section is a run of text under one heading, or one page of a paged format.
+type Section struct {
+	Heading string   `json:"heading,omitempty"`
+	Path    []string `json:"path,omitempty"`   // Headings from the top of the document down to this one
+	Level   int      `json:"level,omitempty"`  // Heading level, 1 for the top; 0 before the first heading
+	Anchor  string   `json:"anchor,omitempty"` // "#slug" for a heading, "#page=N" for a page
+	Page    int      `json:"page,omitempty"`   // 1-based page number in paged formats
+	Text    string   `json:"text"`             // Paragraphs separated by blank lines; tables and lists as Markdown
+}
+
+// Text returns the text of every section, separated by blank lines.
+func (d *Document) Text() string {
+	parts := make([]string, len(d.Sections))
+	for i, s := range d.Sections {
+		parts[i] = s.Text
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// SectionPath joins the heading path of s for display, e.g.
+// "Installation > Linux".
+func (s *Section) SectionPath() string {
+	return strings.Join(s.Path, " > ")
+}
@@ -0,0 +1,119 @@
+package loaders
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Document information entries kept as metadata.
+var pdfInfo = map[string]string{
+	"Author":       "author",
+	"Subject":      "subject",
+	"Keywords":     "keywords",
+	"Creator":      "creator",
+	"Producer":     "producer",
+	"CreationDate": "created",
+	"ModDate":      "modified",
+}
+
+// maxOutlineEntries bounds the outline walk, which a malformed PDF could
+// make cyclic.
+const maxOutlineEntries = 5000
+
+// PDFLoader loads PDFs with their layout taken into account: text is read
+// column by column, running headers and footers are dropped, tables become
+// Markdown tables and headings, found by font size, weight, numbering and
+// the document outline, start sections. Each page starts a section too, so
+// every section knows both its page and its heading path.
+type PDFLoader struct{}
+
+func (PDFLoader) Load(ctx context.Context, data []byte, src Source) (*Document, error) {
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+
+	doc := &Document{Format: FormatPDF, Metadata: pdfMetadata(r)}
+	doc.Title = doc.Metadata["title"]
+	delete(doc.Metadata, "title")
+
+	pages := make([]*pdfPage, 0, r.NumPage())
+	for n := 1; n <= r.NumPage(); n++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		page, err := readPage(r, n)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read PDF page %d: %w", n, err)
+		}
+		pages = append(pages, page)
+	}
+	doc.Metadata["pages"] = strconv.Itoa(len(pages))
+
+	stripRunningLines(pages)
+	for _, page := range pages {
+		page.lines = readingOrder(page.lines)
+	}
+
+	b := newSectionBuilder()
+	w := newPDFWriter(b, pages, pdfOutline(r))
+	for _, page := range pages {
+		w.write(page)
+	}
+	return finishDocument(doc, b, src)
+}
+
+// pdfMetadata reads the document information dictionary.
+func pdfMetadata(r *pdf.Reader) (meta map[string]string) {
+	meta = make(map[string]string)
+	defer func() {
+		if recover() != nil {
+			meta = make(map[string]string)
+		}
+	}()
+
+	info := r.Trailer().Key("Info")
+	if info.IsNull() {
+		return meta
+	}
+	if title := collapseSpace(info.Key("Title").Text()); title != "" {
+		meta["title"] = title
+	}
+	for key, name := range pdfInfo {
+		if value := collapseSpace(info.Key(key).Text()); value != "" {
+			meta[name] = value
+		}
+	}
+	return meta
+}
+
+// pdfOutline returns the depth (1 for top-level entries) of every bookmark,
+// keyed by headingKey of its title.
+func pdfOutline(r *pdf.Reader) (levels map[string]int) {
+	levels = make(map[string]int)
+	defer func() {
+		if recover() != nil {
+			levels = make(map[string]int)
+		}
+	}()
+
+	seen := 0
+	var walk func(entry pdf.Value, depth int)
+	walk = func(entry pdf.Value, depth int) {
+		for ; entry.Kind() == pdf.Dict && seen < maxOutlineEntries && depth <= 6; entry = entry.Key("Next") {
+			seen++
+			if key := headingKey(entry.Key("Title").Text()); key != "" {
+				if _, ok := levels[key]; !ok {
+					levels[key] = depth
+				}
+			}
+			walk(entry.Key("First"), depth+1)
+		}
+	}
+	walk(r.Trailer().Key("Root").Key("Outlines").Key("First"), 1)
+	return levels
+}
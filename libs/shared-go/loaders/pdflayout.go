@@ -0,0 +1,426 @@
+package loaders
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Layout thresholds, in multiples of the font size.
+const (
+	wordGap    = 0.25 // horizontal gap read as a space
+	segmentGap = 1.2  // horizontal gap separating columns or table cells
+	rowCut     = 1.0  // vertical gap separating layout blocks
+	columnCut  = 1.0  // minimum width of a gutter between columns
+	edgeBand   = 0.12 // share of the page height, top and bottom, holding running headers and footers
+)
+
+var (
+	boldFont   = regexp.MustCompile(`(?i)bold|black|heavy|semibold|demi`)
+	pageNumber = regexp.MustCompile(`(?i)^(page\s*)?[-–—]?\s*(#+|[ivxlcdm]+)\s*[-–—]?(\s*(of|/)\s*#+)?$`)
+	digits     = regexp.MustCompile(`\d+`)
+)
+
+// pdfPage is the text of one page as lines, top to bottom until
+// readingOrder puts them in reading order.
+type pdfPage struct {
+	number        int
+	width, height float64
+	lines         []*pdfLine
+}
+
+// pdfSegment is a run of text on one baseline with no wide gap in it: a
+// line of a column or a table cell.
+type pdfSegment struct {
+	text   string
+	x0, x1 float64
+	y      float64 // baseline, increasing upwards
+	size   float64 // dominant font size
+	bold   bool    // most characters are set in a bold font
+}
+
+// pdfLine is the segments sharing a baseline, left to right.
+type pdfLine struct {
+	segments []pdfSegment
+	y        float64
+	size     float64
+	bold     bool
+	block    bool // first line of a layout block
+}
+
+func (l *pdfLine) text() string {
+	parts := make([]string, len(l.segments))
+	for i, s := range l.segments {
+		parts[i] = s.text
+	}
+	return strings.Join(parts, " ")
+}
+
+func (l *pdfLine) x0() float64 {
+	return l.segments[0].x0
+}
+
+// readPage reads the glyphs of a page into lines. The PDF reader panics on
+// malformed content streams, which is turned into an error.
+func readPage(r *pdf.Reader, n int) (page *pdfPage, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			page, err = nil, fmt.Errorf("malformed content: %v", v)
+		}
+	}()
+
+	page = &pdfPage{number: n, width: 612, height: 792} // US Letter unless the page says otherwise
+	p := r.Page(n)
+	if p.V.IsNull() {
+		return page, nil
+	}
+	if box := mediaBox(p); box.Len() == 4 {
+		if w, h := box.Index(2).Float64()-box.Index(0).Float64(), box.Index(3).Float64()-box.Index(1).Float64(); w > 0 && h > 0 {
+			page.width, page.height = w, h
+		}
+	}
+	page.lines = glyphLines(p.Content().Text)
+	return page, nil
+}
+
+// mediaBox returns the page size box, which pages may inherit from the page
+// tree above them.
+func mediaBox(p pdf.Page) pdf.Value {
+	for v := p.V; !v.IsNull(); v = v.Key("Parent") {
+		if box := v.Key("MediaBox"); !box.IsNull() {
+			return box
+		}
+	}
+	return pdf.Value{}
+}
+
+// glyphLines groups glyphs sharing a baseline into lines, top to bottom, and
+// splits each line into segments at wide gaps.
+func glyphLines(glyphs []pdf.Text) []*pdfLine {
+	var visible []pdf.Text
+	for _, g := range glyphs {
+		if g.S != "" {
+			visible = append(visible, g)
+		}
+	}
+	sort.SliceStable(visible, func(i, j int) bool { return visible[i].Y > visible[j].Y })
+
+	var lines []*pdfLine
+	for start := 0; start < len(visible); {
+		end := start + 1
+		for end < len(visible) && sameBaseline(visible[start].Y, visible[start].FontSize, visible[end].Y, visible[end].FontSize) {
+			end++
+		}
+		run := visible[start:end]
+		sort.SliceStable(run, func(i, j int) bool { return run[i].X < run[j].X })
+
+		var segments []pdfSegment
+		from := 0
+		for i := 1; i <= len(run); i++ {
+			if i == len(run) || gapAfter(run[i-1], run[i]) > segmentGap*math.Max(run[i].FontSize, 1) {
+				if s, ok := newSegment(run[from:i]); ok {
+					segments = append(segments, s)
+				}
+				from = i
+			}
+		}
+		if line := newLine(segments); line != nil {
+			lines = append(lines, line)
+		}
+		start = end
+	}
+	return lines
+}
+
+// newSegment builds a segment from glyphs on one baseline, left to right.
+func newSegment(glyphs []pdf.Text) (pdfSegment, bool) {
+	var sb strings.Builder
+	sizes := make(map[float64]int)
+	bold := 0
+	for i, g := range glyphs {
+		if i > 0 && gapAfter(glyphs[i-1], g) > wordGap*math.Max(g.FontSize, 1) {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(g.S)
+		sizes[math.Round(g.FontSize)] += len(g.S)
+		if boldFont.MatchString(g.Font) {
+			bold += len(g.S)
+		}
+	}
+
+	text := collapseSpace(sb.String())
+	last := glyphs[len(glyphs)-1]
+	return pdfSegment{
+		text: text,
+		x0:   glyphs[0].X,
+		x1:   last.X + glyphWidth(last),
+		y:    glyphs[0].Y,
+		size: dominantSize(sizes),
+		bold: bold*2 > sb.Len(),
+	}, text != ""
+}
+
+// newLine builds a line from segments on one baseline, or returns nil if
+// there are none.
+func newLine(segments []pdfSegment) *pdfLine {
+	if len(segments) == 0 {
+		return nil
+	}
+	sort.SliceStable(segments, func(i, j int) bool { return segments[i].x0 < segments[j].x0 })
+
+	sizes := make(map[float64]int)
+	chars, bold := 0, 0
+	for _, s := range segments {
+		sizes[s.size] += len(s.text)
+		chars += len(s.text)
+		if s.bold {
+			bold += len(s.text)
+		}
+	}
+	return &pdfLine{
+		segments: segments,
+		y:        segments[0].y,
+		size:     dominantSize(sizes),
+		bold:     bold*2 > chars,
+	}
+}
+
+// gapAfter returns the horizontal space between two glyphs.
+func gapAfter(prev, next pdf.Text) float64 {
+	return next.X - (prev.X + glyphWidth(prev))
+}
+
+// glyphWidth returns the advance of a glyph. Fonts without widths leave it
+// at 0; assume half an em.
+func glyphWidth(g pdf.Text) float64 {
+	if g.W > 0 {
+		return g.W
+	}
+	return 0.5 * g.FontSize
+}
+
+func sameBaseline(y1, size1, y2, size2 float64) bool {
+	return math.Abs(y1-y2) < 0.3*math.Max(math.Min(size1, size2), 1)
+}
+
+// dominantSize returns the font size covering the most characters.
+func dominantSize(sizes map[float64]int) float64 {
+	best, count := 0.0, 0
+	for size, n := range sizes {
+		if n > count || (n == count && size > best) {
+			best, count = size, n
+		}
+	}
+	return best
+}
+
+// stripRunningLines removes running headers and footers: lines at the top
+// or bottom of a page that repeat, page numbers aside, at the same height
+// on several pages. Bare page numbers there are removed too.
+func stripRunningLines(pages []*pdfPage) {
+	minRepeats := min(3, len(pages))
+	if minRepeats < 2 {
+		minRepeats = 2
+	}
+
+	key := func(line *pdfLine) string {
+		return fmt.Sprintf("%s|%d", runningText(line), int(math.Round(line.y/4)))
+	}
+	counts := make(map[string]int)
+	for _, page := range pages {
+		seen := make(map[string]bool)
+		for _, line := range edgeLines(page) {
+			if k := key(line); !seen[k] {
+				seen[k] = true
+				counts[k]++
+			}
+		}
+	}
+
+	for _, page := range pages {
+		drop := make(map[*pdfLine]bool)
+		for _, line := range edgeLines(page) {
+			if counts[key(line)] >= minRepeats || pageNumber.MatchString(runningText(line)) {
+				drop[line] = true
+			}
+		}
+		if len(drop) == 0 {
+			continue
+		}
+		kept := page.lines[:0]
+		for _, line := range page.lines {
+			if !drop[line] {
+				kept = append(kept, line)
+			}
+		}
+		page.lines = kept
+	}
+}
+
+// edgeLines returns up to two lines at the top and at the bottom of a page,
+// within the bands where running headers and footers sit.
+func edgeLines(page *pdfPage) []*pdfLine {
+	var edges []*pdfLine
+	for i := 0; i < len(page.lines) && i < 2 && page.lines[i].y >= page.height*(1-edgeBand); i++ {
+		edges = append(edges, page.lines[i])
+	}
+	for i := len(page.lines) - 1; i >= len(edges) && i >= len(page.lines)-2 && page.lines[i].y <= page.height*edgeBand; i-- {
+		edges = append(edges, page.lines[i])
+	}
+	return edges
+}
+
+// runningText normalizes a line for comparison across pages: numbers, which
+// change from page to page, become "#".
+func runningText(line *pdfLine) string {
+	return digits.ReplaceAllString(strings.ToLower(line.text()), "#")
+}
+
+// readingOrder orders the lines of a page for reading by recursively
+// cutting it into blocks (XY-cut): first at wide horizontal gaps, then at
+// vertical gutters between columns. Blocks are read top to bottom, columns
+// left to right.
+func readingOrder(lines []*pdfLine) []*pdfLine {
+	var segments []pdfSegment
+	for _, line := range lines {
+		segments = append(segments, line.segments...)
+	}
+
+	var blocks [][]pdfSegment
+	xyCut(segments, &blocks, 0)
+
+	ordered := make([]*pdfLine, 0, len(lines))
+	for _, block := range blocks {
+		blockLines := segmentLines(block)
+		if len(blockLines) > 0 {
+			blockLines[0].block = true
+		}
+		ordered = append(ordered, blockLines...)
+	}
+	return ordered
+}
+
+func xyCut(segments []pdfSegment, blocks *[][]pdfSegment, depth int) {
+	if len(segments) > 1 && depth < 32 {
+		size := medianSize(segments)
+		parts := cutRows(segments, size)
+		if len(parts) < 2 {
+			parts = cutColumns(segments, size)
+		}
+		if len(parts) > 1 {
+			for _, part := range parts {
+				xyCut(part, blocks, depth+1)
+			}
+			return
+		}
+	}
+	*blocks = append(*blocks, segments)
+}
+
+// cutRows splits segments at horizontal gaps wider than rowCut lines,
+// top to bottom.
+func cutRows(segments []pdfSegment, size float64) [][]pdfSegment {
+	sorted := append([]pdfSegment(nil), segments...)
+	sort.SliceStable(sorted, func(i, j int) bool { return top(sorted[i]) > top(sorted[j]) })
+
+	var parts [][]pdfSegment
+	from := 0
+	bottom := math.Inf(1)
+	for i, s := range sorted {
+		if i > 0 && bottom-top(s) > rowCut*size {
+			parts = append(parts, sorted[from:i])
+			from = i
+		}
+		bottom = math.Min(bottom, s.y-0.25*s.size)
+	}
+	return append(parts, sorted[from:])
+}
+
+// cutColumns splits segments at vertical gutters, left to right. A cut is
+// only kept when every column holds several lines of full-width text;
+// narrow parts are table columns, which are read row by row instead.
+func cutColumns(segments []pdfSegment, size float64) [][]pdfSegment {
+	sorted := append([]pdfSegment(nil), segments...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].x0 < sorted[j].x0 })
+
+	var parts [][]pdfSegment
+	from := 0
+	right := math.Inf(-1)
+	for i, s := range sorted {
+		if i > 0 && s.x0-right > columnCut*size {
+			parts = append(parts, sorted[from:i])
+			from = i
+		}
+		right = math.Max(right, s.x1)
+	}
+	parts = append(parts, sorted[from:])
+	if len(parts) < 2 {
+		return parts
+	}
+
+	width := right - sorted[0].x0
+	for _, part := range parts {
+		if len(segmentLines(part)) < 2 || meanWidth(part) < 0.25*width {
+			return nil
+		}
+	}
+	return parts
+}
+
+// segmentLines groups segments sharing a baseline into lines, top to bottom.
+func segmentLines(segments []pdfSegment) []*pdfLine {
+	sorted := append([]pdfSegment(nil), segments...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].y > sorted[j].y })
+
+	var lines []*pdfLine
+	for start := 0; start < len(sorted); {
+		end := start + 1
+		for end < len(sorted) && sameBaseline(sorted[start].y, sorted[start].size, sorted[end].y, sorted[end].size) {
+			end++
+		}
+		if line := newLine(append([]pdfSegment(nil), sorted[start:end]...)); line != nil {
+			lines = append(lines, line)
+		}
+		start = end
+	}
+	return lines
+}
+
+func top(s pdfSegment) float64 {
+	return s.y + 0.85*s.size
+}
+
+func medianSize(segments []pdfSegment) float64 {
+	sizes := make([]float64, len(segments))
+	for i, s := range segments {
+		sizes[i] = s.size
+	}
+	sort.Float64s(sizes)
+	return math.Max(sizes[len(sizes)/2], 1)
+}
+
+func meanWidth(segments []pdfSegment) float64 {
+	total := 0.0
+	for _, s := range segments {
+		total += s.x1 - s.x0
+	}
+	return total / float64(len(segments))
+}
+
+// hasLetters reports whether s has at least two letters.
+func hasLetters(s string) bool {
+	n := 0
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			if n++; n == 2 {
+				return true
+			}
+		}
+	}
+	return false
+}
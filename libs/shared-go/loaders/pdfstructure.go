@@ -0,0 +1,328 @@
+package loaders
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	headingScale = 1.15 // font size, relative to body text, from which a line is a heading
+	paragraphGap = 1.6  // line spacing, in font sizes, that separates paragraphs
+	maxHeading   = 150  // longest heading, in bytes
+	maxLevels    = 6
+)
+
+var (
+	headingNumber = regexp.MustCompile(`^(\d+(?:\.\d+)*)\.?\s+\S`)
+	caption       = regexp.MustCompile(`(?i)^(figure|fig\.|table|listing|chart)\s*\d`)
+	bullet        = regexp.MustCompile(`^[•◦▪▫■□●○‣⁃–\-*·]\s+`)
+)
+
+// pdfWriter turns the lines of pages in reading order into sections:
+// headings, paragraphs, list items and tables.
+type pdfWriter struct {
+	b         *sectionBuilder
+	outline   map[string]int
+	bodySize  float64
+	levels    map[float64]int // heading level by font size
+	boldLevel int             // level of bold headings set at body size
+
+	para  []string
+	item  bool    // para is a list item
+	itemX float64 // left edge of the item's bullet
+	prev  *pdfLine
+}
+
+// newPDFWriter takes the body font size as the size of most text in the
+// document and ranks larger sizes used by headings into levels.
+func newPDFWriter(b *sectionBuilder, pages []*pdfPage, outline map[string]int) *pdfWriter {
+	sizes := make(map[float64]int)
+	for _, page := range pages {
+		for _, line := range page.lines {
+			for _, s := range line.segments {
+				sizes[math.Round(s.size)] += len(s.text)
+			}
+		}
+	}
+	w := &pdfWriter{b: b, outline: outline, bodySize: math.Max(dominantSize(sizes), 1), levels: make(map[float64]int)}
+
+	var larger []float64
+	for size := range sizes {
+		if size >= w.bodySize*headingScale {
+			larger = append(larger, size)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(larger)))
+	for i, size := range larger {
+		w.levels[size] = min(i+1, maxLevels)
+	}
+	w.boldLevel = min(len(larger)+1, maxLevels)
+	return w
+}
+
+// write adds a page, starting a section for it.
+func (w *pdfWriter) write(page *pdfPage) {
+	w.flush()
+	w.b.page(page.number)
+	w.prev = nil
+
+	lines := page.lines
+	for i := 0; i < len(lines); {
+		if rows, n := pdfTable(lines[i:]); n > 0 {
+			w.flush()
+			w.b.block(markdownTable(rows))
+			w.prev = nil
+			i += n
+			continue
+		}
+		if level, n := w.heading(lines[i:]); n > 0 {
+			w.flush()
+			texts := make([]string, n)
+			for j, line := range lines[i : i+n] {
+				texts[j] = line.text()
+			}
+			w.b.heading(level, joinLines(texts), "")
+			w.prev = nil
+			i += n
+			continue
+		}
+		w.text(lines[i])
+		i++
+	}
+	w.flush()
+}
+
+// heading reports whether lines start with a heading, returning its level
+// and how many lines it spans. A line is a heading when it is listed in the
+// outline, set larger than body text, or set in bold on a line of its own.
+func (w *pdfWriter) heading(lines []*pdfLine) (level, n int) {
+	line := lines[0]
+	text := line.text()
+	if len(text) > maxHeading || !hasLetters(text) || caption.MatchString(text) || bullet.MatchString(text) {
+		return 0, 0
+	}
+	if level, ok := w.outline[headingKey(text)]; ok {
+		return min(level, maxLevels), 1
+	}
+
+	large := line.size >= w.bodySize*headingScale
+	if !large && !w.boldHeading(lines) {
+		return 0, 0
+	}
+	if large {
+		level = w.levels[math.Round(line.size)]
+	}
+	if level == 0 {
+		level = w.boldLevel
+	}
+	if m := headingNumber.FindStringSubmatch(text); m != nil {
+		level = min(strings.Count(m[1], ".")+1, maxLevels)
+	}
+
+	// Long headings wrap onto lines set the same way.
+	n = 1
+	for n < len(lines) && n < 3 && !lines[n].block &&
+		math.Abs(lines[n].size-line.size) < 0.5 && lines[n].bold == line.bold &&
+		line.y-lines[n].y <= paragraphGap*line.size {
+		n++
+	}
+	return level, n
+}
+
+// boldHeading reports whether the first line is a short bold line standing
+// apart from the text around it rather than bold text in a paragraph.
+func (w *pdfWriter) boldHeading(lines []*pdfLine) bool {
+	line := lines[0]
+	text := line.text()
+	if !line.bold || line.size < w.bodySize*0.95 || len(text) > 80 || len(line.segments) > 1 ||
+		strings.HasSuffix(text, ".") || strings.HasSuffix(text, ",") {
+		return false
+	}
+	if w.prev != nil && !line.block && w.prev.y-line.y <= paragraphGap*line.size {
+		return false
+	}
+	return len(lines) == 1 || lines[1].block || !lines[1].bold
+}
+
+// text adds a line to the paragraph or list item being built, or starts a
+// new one.
+func (w *pdfWriter) text(line *pdfLine) {
+	text := line.text()
+	if loc := bullet.FindStringIndex(text); loc != nil {
+		w.flush()
+		w.para = []string{"- " + text[loc[1]:]}
+		w.item = true
+		w.itemX = line.x0()
+		w.prev = line
+		return
+	}
+
+	switch {
+	case w.prev == nil, line.block,
+		w.prev.y-line.y > paragraphGap*math.Max(w.prev.size, line.size),
+		math.Abs(w.prev.size-line.size) > 1.5,
+		w.item && line.x0() <= w.itemX+1:
+		w.flush()
+	}
+	w.para = append(w.para, text)
+	w.prev = line
+}
+
+func (w *pdfWriter) flush() {
+	if len(w.para) > 0 {
+		if w.item {
+			w.b.item(joinLines(w.para))
+		} else {
+			w.b.block(joinLines(w.para))
+		}
+	}
+	w.para = nil
+	w.item = false
+}
+
+// pdfTable reports whether lines start with a table: two or more lines split
+// into segments that line up in columns. It returns the rows and how many
+// lines they span. Single-segment lines between rows are cells wrapped onto
+// another line.
+func pdfTable(lines []*pdfLine) ([][]string, int) {
+	end, multi := 0, 0
+	for i, line := range lines {
+		if i > 0 && (line.block || lines[i-1].y-line.y > paragraphGap*line.size*1.5) {
+			break
+		}
+		if len(line.segments) >= 2 {
+			end, multi = i+1, multi+1
+		} else if multi == 0 {
+			break
+		}
+	}
+	if multi < 2 {
+		return nil, 0
+	}
+	run := lines[:end]
+
+	columns := tableColumns(run, (multi+1)/2)
+	if len(columns) < 2 {
+		return nil, 0
+	}
+
+	var rows [][]string
+	full := 0
+	for _, line := range run {
+		cells := make([]string, len(columns))
+		for _, s := range line.segments {
+			col := columnOf(columns, s.x0, line.size)
+			if cells[col] != "" {
+				cells[col] += " "
+			}
+			cells[col] += s.text
+		}
+		if len(line.segments) == 1 && len(rows) > 0 && cells[0] == "" {
+			// A wrapped cell: continue the row above.
+			prev := rows[len(rows)-1]
+			for col, cell := range cells {
+				if cell != "" {
+					prev[col] = strings.TrimSpace(prev[col] + " " + cell)
+				}
+			}
+			continue
+		}
+		filled := 0
+		for _, cell := range cells {
+			if cell != "" {
+				filled++
+			}
+		}
+		if filled >= 2 {
+			full++
+		}
+		rows = append(rows, cells)
+	}
+	if full < 2 {
+		return nil, 0
+	}
+	return rows, end
+}
+
+// tableColumns clusters the left edges of segments into column starts,
+// keeping those shared by at least support lines.
+func tableColumns(lines []*pdfLine, support int) []float64 {
+	var edges []float64
+	size := 0.0
+	for _, line := range lines {
+		if len(line.segments) < 2 {
+			continue
+		}
+		for _, s := range line.segments {
+			edges = append(edges, s.x0)
+		}
+		size = math.Max(size, line.size)
+	}
+	sort.Float64s(edges)
+
+	var columns []float64
+	for start := 0; start < len(edges); {
+		end := start + 1
+		for end < len(edges) && edges[end]-edges[end-1] <= size {
+			end++
+		}
+		if end-start >= support {
+			columns = append(columns, edges[start])
+		}
+		start = end
+	}
+	return columns
+}
+
+// columnOf returns the column a segment starting at x falls in.
+func columnOf(columns []float64, x, size float64) int {
+	col := 0
+	for i, start := range columns {
+		if x+size >= start {
+			col = i
+		}
+	}
+	return col
+}
+
+// headingKey normalizes heading text for matching against outline titles.
+func headingKey(text string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && sb.Len() > 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteRune(r)
+			space = false
+		case unicode.IsSpace(r):
+			space = true
+		}
+	}
+	return sb.String()
+}
+
+// joinLines joins the lines of a paragraph, mending words hyphenated across
+// lines.
+func joinLines(lines []string) string {
+	var sb strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			prev := lines[i-1]
+			if strings.HasSuffix(prev, "-") && len(prev) > 1 && line != "" && unicode.IsLower(rune(line[0])) {
+				s := sb.String()
+				sb.Reset()
+				sb.WriteString(s[:len(s)-1])
+			} else {
+				sb.WriteByte(' ')
+			}
+		}
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
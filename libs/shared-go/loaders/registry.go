@@ -0,0 +1,99 @@
+package loaders
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Content types that say nothing about the format, so the extension is
+// trusted over them.
+var genericTypes = map[string]bool{
+	"":                         true,
+	"application/octet-stream": true,
+	"application/zip":          true,
+	"binary/octet-stream":      true,
+	"text/plain":               true,
+}
+
+// Registry picks a loader for a file by MIME type and extension.
+type Registry struct {
+	loaders    map[string]Loader // by format
+	byMIMEType map[string]string
+	byExt      map[string]string
+}
+
+// NewRegistry returns a registry of the built-in loaders.
+func NewRegistry() *Registry {
+	r := &Registry{
+		loaders:    make(map[string]Loader),
+		byMIMEType: make(map[string]string),
+		byExt:      make(map[string]string),
+	}
+	r.Register(FormatMarkdown, MarkdownLoader{}, []string{"text/markdown", "text/x-markdown"}, []string{".md", ".markdown", ".mdx"})
+	r.Register(FormatHTML, HTMLLoader{}, []string{"text/html", "application/xhtml+xml"}, []string{".html", ".htm", ".xhtml"})
+	r.Register(FormatText, TextLoader{}, []string{"text/plain"}, []string{".txt", ".text", ".log", ".rst", ".adoc"})
+	r.Register(FormatDOCX, DOCXLoader{},
+		[]string{"application/vnd.openxmlformats-officedocument.wordprocessingml.document"}, []string{".docx"})
+	r.Register(FormatEPUB, EPUBLoader{}, []string{"application/epub+zip"}, []string{".epub"})
+	r.Register(FormatPDF, PDFLoader{}, []string{"application/pdf", "application/x-pdf"}, []string{".pdf"})
+	return r
+}
+
+// Register adds or replaces the loader of format, selected for the given
+// MIME types and extensions (with their leading dot).
+func (r *Registry) Register(format string, loader Loader, mimeTypes, exts []string) {
+	r.loaders[format] = loader
+	for _, t := range mimeTypes {
+		r.byMIMEType[strings.ToLower(t)] = format
+	}
+	for _, ext := range exts {
+		r.byExt[strings.ToLower(ext)] = format
+	}
+}
+
+// Select returns the loader for src and its format. A specific MIME type
+// wins, then the extension, then a generic MIME type such as text/plain, and
+// last the format sniffed from data.
+func (r *Registry) Select(src Source, data []byte) (Loader, string, error) {
+	mimeType, _, _ := mime.ParseMediaType(src.MIMEType)
+	mimeType = strings.ToLower(mimeType)
+
+	ext := strings.ToLower(path.Ext(stripQuery(src.Name)))
+	candidates := []string{r.byExt[ext], r.byMIMEType[mimeType]}
+	if !genericTypes[mimeType] {
+		candidates[0], candidates[1] = candidates[1], candidates[0]
+	}
+	if len(data) > 0 {
+		sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+		candidates = append(candidates, r.byMIMEType[sniffed])
+	}
+
+	for _, format := range candidates {
+		if loader := r.loaders[format]; loader != nil {
+			return loader, format, nil
+		}
+	}
+	return nil, "", fmt.Errorf("%w: %q (%s)", ErrUnsupportedFormat, src.Name, src.MIMEType)
+}
+
+// Formats returns the registered formats, sorted.
+func (r *Registry) Formats() []string {
+	formats := make([]string, 0, len(r.loaders))
+	for format := range r.loaders {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// stripQuery drops the query and fragment of a URL-like name.
+func stripQuery(name string) string {
+	if i := strings.IndexAny(name, "?#"); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
@@ -0,0 +1,217 @@
+package loaders
+
+import (
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// sectionBuilder collects blocks of text into sections, starting a new one at
+// every heading or page and tracking the path of enclosing headings.
+type sectionBuilder struct {
+	sections []Section
+	current  Section
+	blocks   []string
+	listItem bool // the last block is a list item, so the next one continues the list
+
+	path  []string // heading text by level
+	slugs map[string]int
+	title string // first top-level heading
+}
+
+func newSectionBuilder() *sectionBuilder {
+	return &sectionBuilder{slugs: make(map[string]int)}
+}
+
+// heading starts a section under a heading of level (1 is the top). anchor
+// is used as is when set, otherwise one is made from the heading text.
+func (b *sectionBuilder) heading(level int, text, anchor string) {
+	text = collapseSpace(text)
+	if text == "" {
+		return
+	}
+	if level < 1 {
+		level = 1
+	}
+	b.flush()
+
+	if len(b.path) >= level {
+		b.path = b.path[:level-1]
+	}
+	for len(b.path) < level-1 {
+		b.path = append(b.path, "")
+	}
+	b.path = append(b.path, text)
+	if level == 1 && b.title == "" {
+		b.title = text
+	}
+
+	if anchor == "" {
+		anchor = "#" + b.slug(text)
+	}
+	b.current = Section{
+		Heading: text,
+		Path:    compactPath(b.path),
+		Level:   level,
+		Anchor:  anchor,
+		Page:    b.current.Page,
+	}
+}
+
+// page starts a section for page n, keeping the enclosing headings.
+func (b *sectionBuilder) page(n int) {
+	b.flush()
+	b.current = Section{
+		Heading: b.current.Heading,
+		Path:    b.current.Path,
+		Level:   b.current.Level,
+		Anchor:  "#page=" + strconv.Itoa(n),
+		Page:    n,
+	}
+}
+
+// anchor overrides the anchor of the current section, e.g. with the chapter
+// file of an EPUB before its first heading.
+func (b *sectionBuilder) anchor(anchor string) {
+	b.flush()
+	b.current.Anchor = anchor
+}
+
+// block adds a paragraph, table or code block to the current section.
+func (b *sectionBuilder) block(text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	b.blocks = append(b.blocks, text)
+	b.listItem = false
+}
+
+// item adds a list item, kept on the line after the previous item.
+func (b *sectionBuilder) item(text string) {
+	text = strings.TrimRight(text, " \t\n")
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	if b.listItem {
+		b.blocks[len(b.blocks)-1] += "\n" + text
+		return
+	}
+	b.blocks = append(b.blocks, text)
+	b.listItem = true
+}
+
+// endList makes the next item start a new list.
+func (b *sectionBuilder) endList() {
+	b.listItem = false
+}
+
+func (b *sectionBuilder) flush() {
+	b.listItem = false
+	if len(b.blocks) == 0 {
+		return
+	}
+	b.current.Text = strings.Join(b.blocks, "\n\n")
+	b.sections = append(b.sections, b.current)
+	b.blocks = nil
+}
+
+// finish returns the sections, skipping headings with no text under them.
+func (b *sectionBuilder) finish() []Section {
+	b.flush()
+	return b.sections
+}
+
+// slug makes a GitHub style anchor from heading text, numbering repeats.
+func (b *sectionBuilder) slug(text string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			sb.WriteRune(r)
+		case r == ' ':
+			sb.WriteByte('-')
+		}
+	}
+	slug := sb.String()
+	n := b.slugs[slug]
+	b.slugs[slug] = n + 1
+	if n > 0 {
+		slug += "-" + strconv.Itoa(n)
+	}
+	return slug
+}
+
+// compactPath copies path without the levels a document skipped.
+func compactPath(path []string) []string {
+	out := make([]string, 0, len(path))
+	for _, p := range path {
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// collapseSpace replaces every run of whitespace with one space.
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// normalizeText cleans up extracted text: line endings are unified, trailing
+// space is dropped and runs of blank lines become one.
+func normalizeText(s string) string {
+	s = strings.ToValidUTF8(s, "\uFFFD")
+	s = strings.TrimPrefix(s, "\uFEFF")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	s = strings.ReplaceAll(s, "\u00A0", " ")
+
+	lines := strings.Split(s, "\n")
+	out := lines[:0]
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			if blank || len(out) == 0 {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		out = append(out, line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+// titleFromName returns a file name without its directory and extension, the
+// title of documents that don't declare one.
+func titleFromName(name string) string {
+	base := path.Base(strings.ReplaceAll(stripQuery(name), "\\", "/"))
+	if base == "." || base == "/" {
+		return ""
+	}
+	return strings.TrimSuffix(base, path.Ext(base))
+}
+
+// finishDocument fills in the title, from the first top-level heading or
+// else the file name, and rejects documents without text.
+func finishDocument(doc *Document, b *sectionBuilder, src Source) (*Document, error) {
+	doc.Sections = b.finish()
+	if len(doc.Sections) == 0 {
+		return nil, ErrEmptyDocument
+	}
+	doc.Source = src.Name
+	if doc.Title == "" {
+		doc.Title = b.title
+	}
+	if doc.Title == "" {
+		doc.Title = titleFromName(src.Name)
+	}
+	if len(doc.Metadata) == 0 {
+		doc.Metadata = nil
+	}
+	return doc, nil
+}
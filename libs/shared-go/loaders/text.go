@@ -0,0 +1,24 @@
+package loaders
+
+import (
+	"context"
+	"strings"
+)
+
+// TextLoader loads plain text. Form feeds, as left by many text exports of
+// paged documents, start a new page.
+type TextLoader struct{}
+
+func (TextLoader) Load(ctx context.Context, data []byte, src Source) (*Document, error) {
+	b := newSectionBuilder()
+	pages := strings.Split(string(data), "\f")
+	for i, page := range pages {
+		if len(pages) > 1 {
+			b.page(i + 1)
+		}
+		for _, para := range strings.Split(normalizeText(page), "\n\n") {
+			b.block(para)
+		}
+	}
+	return finishDocument(&Document{Format: FormatText}, b, src)
+}
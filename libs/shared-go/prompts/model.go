@@ -0,0 +1,58 @@
+package prompts
+
+import (
+	"errors"
+	"text/template"
+)
+
+// Names of the built-in templates.
+const (
+	ChatSystem         = "chat_system"
+	SummarizeHistory   = "summarize_history"
+	RollingSummary     = "rolling_summary"
+	ExtractFacts       = "extract_facts"
+	ExtractGraph       = "extract_graph"
+	AnswerQuestion     = "answer_question"
+	RerankPassages     = "rerank_passages"
+	ExpandQuery        = "expand_query"
+	HypotheticalAnswer = "hypothetical_answer"
+	StepBackQuestion   = "step_back_question"
+)
+
+// Template sources.
+const (
+	SourceEmbedded = "embedded"
+	SourceOverride = "override"
+)
+
+var (
+	ErrNotFound        = errors.New("prompt template not found")
+	ErrInvalidVariable = errors.New("invalid prompt variable")
+)
+
+// Var is a variable a template expects. Type is one of string, int, float,
+// bool, []string or any.
+type Var struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+}
+
+// Template is a named, versioned prompt.
+type Template struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+	Vars        []Var  `json:"vars"`
+	Source      string `json:"source"` // SourceEmbedded or SourceOverride
+	Text        string `json:"text"`
+
+	tmpl *template.Template
+}
+
+// frontMatter is the YAML header of a template file.
+type frontMatter struct {
+	Version     string            `yaml:"version"`
+	Description string            `yaml:"description"`
+	Vars        map[string]string `yaml:"vars"` // name: type, with a trailing "?" for optional
+}
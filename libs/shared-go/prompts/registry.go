@@ -0,0 +1,182 @@
+package prompts
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"go.uber.org/zap"
+)
+
+const fileExt = ".tmpl"
+
+//go:embed templates/*.tmpl
+var embedded embed.FS
+
+// Registry holds the prompt templates: those embedded in the binary, replaced
+// or extended by files in an optional override directory. Override files are
+// re-read when they change, so prompts can be edited without a rebuild.
+type Registry struct {
+	mu        sync.RWMutex
+	dir       string
+	templates map[string]*Template
+	modTimes  map[string]int64 // Override file modification times, by name
+	logger    *zap.Logger
+}
+
+// NewRegistry loads the embedded templates and any <name>.tmpl files in dir.
+// dir may be empty.
+func NewRegistry(dir string, logger *zap.Logger) (*Registry, error) {
+	r := &Registry{dir: dir, logger: logger}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload re-reads every template. On error the previous set is kept.
+func (r *Registry) Reload() error {
+	templates := make(map[string]*Template)
+	modTimes := make(map[string]int64)
+
+	entries, err := fs.ReadDir(embedded, "templates")
+	if err != nil {
+		return fmt.Errorf("failed to read embedded prompts: %w", err)
+	}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), fileExt)
+		data, err := fs.ReadFile(embedded, "templates/"+entry.Name())
+		if err != nil {
+			return fmt.Errorf("failed to read embedded prompt %q: %w", name, err)
+		}
+		t, err := parse(name, SourceEmbedded, data)
+		if err != nil {
+			return err
+		}
+		templates[name] = t
+	}
+
+	if r.dir != "" {
+		files, err := filepath.Glob(filepath.Join(r.dir, "*"+fileExt))
+		if err != nil {
+			return fmt.Errorf("failed to list prompt overrides: %w", err)
+		}
+		for _, file := range files {
+			t, modTime, err := loadFile(file)
+			if err != nil {
+				return err
+			}
+			templates[t.Name] = t
+			modTimes[t.Name] = modTime
+		}
+	}
+
+	r.mu.Lock()
+	r.templates = templates
+	r.modTimes = modTimes
+	r.mu.Unlock()
+
+	r.logger.Info("Prompt templates loaded",
+		zap.Int("count", len(templates)),
+		zap.Int("overrides", len(modTimes)),
+		zap.String("dir", r.dir))
+	return nil
+}
+
+// Get returns the named template, re-reading its override file if it changed.
+func (r *Registry) Get(name string) (*Template, error) {
+	r.refresh(name)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, name)
+	}
+	return t, nil
+}
+
+// Render renders the named template and returns the reference to record with
+// the response.
+func (r *Registry) Render(name string, vars map[string]any) (string, *ai.PromptRef, error) {
+	t, err := r.Get(name)
+	if err != nil {
+		return "", nil, err
+	}
+	text, err := t.Render(vars)
+	if err != nil {
+		return "", nil, err
+	}
+	return text, t.Ref(), nil
+}
+
+// List returns every template sorted by name.
+func (r *Registry) List() []*Template {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]*Template, 0, len(r.templates))
+	for _, t := range r.templates {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// refresh reloads name's override file if it was added or modified. A file
+// that fails to parse is logged and the loaded version kept.
+func (r *Registry) refresh(name string) {
+	if r.dir == "" {
+		return
+	}
+	file := filepath.Join(r.dir, name+fileExt)
+	info, err := os.Stat(file)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			r.logger.Warn("Failed to stat prompt override", zap.String("file", file), zap.Error(err))
+		}
+		return
+	}
+
+	r.mu.RLock()
+	loaded, ok := r.modTimes[name]
+	r.mu.RUnlock()
+	if ok && loaded == info.ModTime().UnixNano() {
+		return
+	}
+
+	t, modTime, err := loadFile(file)
+	if err != nil {
+		r.logger.Warn("Keeping previous prompt, override is invalid", zap.String("file", file), zap.Error(err))
+		return
+	}
+
+	r.mu.Lock()
+	r.templates[name] = t
+	r.modTimes[name] = modTime
+	r.mu.Unlock()
+	r.logger.Info("Prompt override reloaded", zap.String("name", name), zap.String("version", t.Version))
+}
+
+func loadFile(file string) (*Template, int64, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read prompt override: %w", err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read prompt override: %w", err)
+	}
+	t, err := parse(strings.TrimSuffix(filepath.Base(file), fileExt), SourceOverride, data)
+	if err != nil {
+		return nil, 0, err
+	}
+	return t, info.ModTime().UnixNano(), nil
+}
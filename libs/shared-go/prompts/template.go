@@ -0,0 +1,184 @@
+package prompts
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"gopkg.in/yaml.v3"
+)
+
+const frontMatterDelim = "---"
+
+var varTypes = map[string]bool{
+	"string":   true,
+	"int":      true,
+	"float":    true,
+	"bool":     true,
+	"[]string": true,
+	"any":      true,
+}
+
+// parse reads a template file: a YAML front matter block between "---" lines
+// followed by the text/template body.
+func parse(name, source string, data []byte) (*Template, error) {
+	header, body, err := splitFrontMatter(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("prompt %q: %w", name, err)
+	}
+
+	var meta frontMatter
+	if err := yaml.Unmarshal([]byte(header), &meta); err != nil {
+		return nil, fmt.Errorf("prompt %q: invalid front matter: %w", name, err)
+	}
+	if meta.Version == "" {
+		return nil, fmt.Errorf("prompt %q: version is required", name)
+	}
+
+	vars := make([]Var, 0, len(meta.Vars))
+	for varName, typ := range meta.Vars {
+		v := Var{Name: varName, Type: strings.TrimSuffix(typ, "?"), Required: !strings.HasSuffix(typ, "?")}
+		if !varTypes[v.Type] {
+			return nil, fmt.Errorf("prompt %q: variable %q has unsupported type %q", name, varName, typ)
+		}
+		vars = append(vars, v)
+	}
+	sort.Slice(vars, func(i, j int) bool { return vars[i].Name < vars[j].Name })
+
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(funcs).Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("prompt %q: %w", name, err)
+	}
+
+	return &Template{
+		Name:        name,
+		Version:     meta.Version,
+		Description: meta.Description,
+		Vars:        vars,
+		Source:      source,
+		Text:        body,
+		tmpl:        tmpl,
+	}, nil
+}
+
+func splitFrontMatter(data string) (string, string, error) {
+	data = strings.ReplaceAll(strings.TrimPrefix(data, "\ufeff"), "\r\n", "\n")
+	rest, found := strings.CutPrefix(data, frontMatterDelim+"\n")
+	if !found {
+		return "", "", fmt.Errorf("missing %q front matter", frontMatterDelim)
+	}
+	header, body, found := strings.Cut(rest, "\n"+frontMatterDelim+"\n")
+	if !found {
+		return "", "", fmt.Errorf("unterminated front matter")
+	}
+	return header, strings.TrimSpace(body), nil
+}
+
+// Render executes the template with vars, checking them against the declared
+// variables first.
+func (t *Template) Render(vars map[string]any) (string, error) {
+	if err := t.check(vars); err != nil {
+		return "", err
+	}
+
+	// Give unset optional variables their zero value so templates can test
+	// them with {{if}} instead of printing "<no value>".
+	data := make(map[string]any, len(t.Vars))
+	for _, v := range t.Vars {
+		data[v.Name] = zeroValue(v.Type)
+	}
+	for name, value := range vars {
+		if value != nil {
+			data[name] = value
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt %q: %w", t.Name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// Ref identifies this version of the template.
+func (t *Template) Ref() *ai.PromptRef {
+	return &ai.PromptRef{Name: t.Name, Version: t.Version}
+}
+
+func (t *Template) check(vars map[string]any) error {
+	declared := make(map[string]bool, len(t.Vars))
+	for _, v := range t.Vars {
+		declared[v.Name] = true
+		value, ok := vars[v.Name]
+		if !ok || value == nil {
+			if v.Required {
+				return fmt.Errorf("%w: prompt %q requires %q", ErrInvalidVariable, t.Name, v.Name)
+			}
+			continue
+		}
+		if !hasType(value, v.Type) {
+			return fmt.Errorf("%w: prompt %q expects %q to be %s, got %T", ErrInvalidVariable, t.Name, v.Name, v.Type, value)
+		}
+	}
+	for name := range vars {
+		if !declared[name] {
+			return fmt.Errorf("%w: prompt %q has no variable %q", ErrInvalidVariable, t.Name, name)
+		}
+	}
+	return nil
+}
+
+func hasType(value any, typ string) bool {
+	switch typ {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "int":
+		switch value.(type) {
+		case int, int32, int64:
+			return true
+		}
+		return false
+	case "float":
+		switch value.(type) {
+		case float32, float64, int, int32, int64:
+			return true
+		}
+		return false
+	case "bool":
+		_, ok := value.(bool)
+		return ok
+	case "[]string":
+		_, ok := value.([]string)
+		return ok
+	}
+	return true
+}
+
+func zeroValue(typ string) any {
+	switch typ {
+	case "string":
+		return ""
+	case "int":
+		return 0
+	case "float":
+		return 0.0
+	case "bool":
+		return false
+	case "[]string":
+		return []string(nil)
+	}
+	return nil
+}
+
+// funcs are available in every template.
+var funcs = template.FuncMap{
+	"join":  strings.Join,
+	"trim":  strings.TrimSpace,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"inc":   func(i int) int { return i + 1 },
+}
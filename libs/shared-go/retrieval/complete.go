@@ -0,0 +1,49 @@
+package retrieval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/prompts"
+)
+
+// complete renders the prompt name with vars and returns the reply of a
+// deterministic completion. model may be empty to use the provider's
+// default.
+func complete(ctx context.Context, provider ai.ChatProvider, registry *prompts.Registry, name string, vars map[string]any, model string, maxTokens int) (string, error) {
+	prompt, _, err := registry.Render(name, vars)
+	if err != nil {
+		return "", err
+	}
+
+	temperature := 0.0
+	resp, err := provider.Completion(ctx, []ai.Message{
+		{Role: ai.RoleUser, Content: prompt},
+	}, &ai.ChatOptions{
+		Model:       model,
+		Temperature: &temperature,
+		MaxTokens:   maxTokens,
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
+
+// completeJSON is complete for prompts answered with a JSON object, which
+// is decoded into out. Code fences or prose around the object are
+// tolerated.
+func completeJSON(ctx context.Context, provider ai.ChatProvider, registry *prompts.Registry, name string, vars map[string]any, model string, maxTokens int, out any) error {
+	reply, err := complete(ctx, provider, registry, name, vars, model, maxTokens)
+	if err != nil {
+		return err
+	}
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return errors.New("no JSON object in reply")
+	}
+	return json.Unmarshal([]byte(reply[start:end+1]), out)
+}
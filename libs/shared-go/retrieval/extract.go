@@ -0,0 +1,34 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/db/graph"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/prompts"
+)
+
+const extractMaxTokens = 1024
+
+// LLMGraphExtractor extracts entities and relations with a chat completion
+// using the extract_graph prompt.
+type LLMGraphExtractor struct {
+	provider ai.ChatProvider
+	model    string
+	prompts  *prompts.Registry
+}
+
+// NewLLMGraphExtractor returns a GraphExtractor backed by provider. model may
+// be empty to use the provider's default.
+func NewLLMGraphExtractor(provider ai.ChatProvider, model string, registry *prompts.Registry) *LLMGraphExtractor {
+	return &LLMGraphExtractor{provider: provider, model: model, prompts: registry}
+}
+
+func (e *LLMGraphExtractor) Extract(ctx context.Context, text string) (*graph.Extraction, error) {
+	var x graph.Extraction
+	if err := completeJSON(ctx, e.provider, e.prompts, prompts.ExtractGraph, map[string]any{"text": text}, e.model, extractMaxTokens, &x); err != nil {
+		return nil, fmt.Errorf("failed to extract graph: %w", err)
+	}
+	return &x, nil
+}
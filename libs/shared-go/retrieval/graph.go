@@ -0,0 +1,150 @@
+package retrieval
+
+import (
+	"context"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/db/graph"
+	"go.uber.org/zap"
+)
+
+// GraphRetriever expands the results of another retriever with chunks
+// connected, through the knowledge graph, to the entities in the question.
+// This answers multi-hop questions whose pieces sit in chunks that share no
+// wording with the question.
+type GraphRetriever struct {
+	base       Retriever
+	vectors    VectorIndex
+	collection string
+	store      graph.Store
+	extractor  GraphExtractor
+	cfg        GraphConfig
+	logger     *zap.Logger
+}
+
+// NewGraphRetriever wraps base. Graph chunks are read from vectors, in
+// collection when a request doesn't name one.
+func NewGraphRetriever(base Retriever, vectors VectorIndex, collection string, store graph.Store, extractor GraphExtractor, cfg GraphConfig, logger *zap.Logger) *GraphRetriever {
+	return &GraphRetriever{
+		base:       base,
+		vectors:    vectors,
+		collection: collection,
+		store:      store,
+		extractor:  extractor,
+		cfg:        cfg.withDefaults(),
+		logger:     logger,
+	}
+}
+
+// Retrieve returns the base results followed by graph-only chunks, nearest
+// first. The graph is best effort: if it fails the base results are returned
+// alone.
+func (r *GraphRetriever) Retrieve(ctx context.Context, req *Request) ([]Chunk, error) {
+	type expansion struct {
+		sub *graph.Subgraph
+		err error
+	}
+	expanded := make(chan expansion, 1)
+	go func() {
+		sub, err := r.expand(ctx, req)
+		expanded <- expansion{sub, err}
+	}()
+
+	chunks, err := r.base.Retrieve(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	x := <-expanded
+	if x.err != nil {
+		r.logger.Warn("Graph expansion failed", zap.Error(x.err))
+		return chunks, nil
+	}
+	if x.sub == nil || len(x.sub.Chunks) == 0 {
+		return chunks, nil
+	}
+
+	graphChunks, err := r.fetch(ctx, req, x.sub.Chunks, chunks)
+	if err != nil {
+		r.logger.Warn("Failed to read graph chunks", zap.Error(err))
+		return chunks, nil
+	}
+	return append(chunks, graphChunks...), nil
+}
+
+// expand finds the question's entities in the graph and walks out from them.
+func (r *GraphRetriever) expand(ctx context.Context, req *Request) (*graph.Subgraph, error) {
+	x, err := r.extractor.Extract(ctx, req.Query)
+	if err != nil {
+		return nil, err
+	}
+	x.Normalize("")
+	if len(x.Entities) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, len(x.Entities))
+	for i, e := range x.Entities {
+		ids[i] = e.ID
+	}
+	seeds, err := r.store.FindEntities(ctx, ids)
+	if err != nil || len(seeds) == 0 {
+		return nil, err
+	}
+	for i, e := range seeds {
+		ids[i] = e.ID
+	}
+
+	r.logger.Debug("Expanding graph from question entities", zap.Strings("entities", ids[:len(seeds)]))
+	// Over-fetch: some of the chunks will already be among the base results.
+	return r.store.Expand(ctx, ids[:len(seeds)], r.cfg.Hops, 3*r.maxChunks(req))
+}
+
+// fetch reads the graph chunks that aren't already in have, up to the
+// configured maximum.
+func (r *GraphRetriever) fetch(ctx context.Context, req *Request, refs []graph.ChunkRef, have []Chunk) ([]Chunk, error) {
+	seen := make(map[string]bool, len(have))
+	for _, c := range have {
+		seen[c.ID] = true
+	}
+
+	var ids []string
+	hops := make(map[string]int)
+	for _, ref := range refs {
+		if !seen[ref.ID] {
+			ids = append(ids, ref.ID)
+			hops[ref.ID] = ref.Hops
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	collection := req.Collection
+	if collection == "" {
+		collection = r.collection
+	}
+	points, err := r.vectors.Get(ctx, collection, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// Points come back in the order asked for, i.e. nearest first. Chunks of
+	// other collections are simply not found.
+	var chunks []Chunk
+	for _, p := range points {
+		if len(chunks) == r.maxChunks(req) {
+			break
+		}
+		c := newChunk(p.ID, p.Payload, 1/float32(1+hops[p.ID]), ViaGraph)
+		c.Hops = hops[p.ID]
+		chunks = append(chunks, c)
+	}
+	return chunks, nil
+}
+
+func (r *GraphRetriever) maxChunks(req *Request) int {
+	if r.cfg.MaxChunks > 0 {
+		return r.cfg.MaxChunks
+	}
+	return topK(req)
+}
@@ -0,0 +1,127 @@
+package retrieval
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/db/vector/local"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/db/vector/pinecone"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/db/vector/weaviate"
+)
+
+// LocalIndex reads from the in-process vector store.
+type LocalIndex struct {
+	Vectors local.Service
+}
+
+func (x LocalIndex) Search(ctx context.Context, collection string, vector []float32, limit int) ([]Hit, error) {
+	resp, err := x.Vectors.Search(ctx, &local.SearchRequest{
+		CollectionName: collection,
+		Vector:         vector,
+		Limit:          uint64(limit),
+		WithPayload:    true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]Hit, len(resp.Results))
+	for i, r := range resp.Results {
+		hits[i] = Hit{ID: r.ID, Score: r.Score, Payload: r.Payload}
+	}
+	return hits, nil
+}
+
+func (x LocalIndex) Get(ctx context.Context, collection string, ids []string) ([]Hit, error) {
+	resp, err := x.Vectors.GetPointsByIDs(ctx, &local.GetPointsByIDsRequest{
+		CollectionName: collection,
+		PointIDs:       ids,
+		WithPayload:    true,
+	})
+	if errors.Is(err, local.ErrCollectionNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]Hit, len(resp.Points))
+	for i, p := range resp.Points {
+		hits[i] = Hit{ID: p.ID, Payload: p.Payload}
+	}
+	return hits, nil
+}
+
+// PineconeIndex reads from a Pinecone index.
+type PineconeIndex struct {
+	Vectors pinecone.Service
+}
+
+func (x PineconeIndex) Search(ctx context.Context, collection string, vector []float32, limit int) ([]Hit, error) {
+	resp, err := x.Vectors.Search(ctx, &pinecone.SearchRequest{
+		CollectionName: collection,
+		Vector:         vector,
+		Limit:          uint64(limit),
+		WithPayload:    true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]Hit, len(resp.Results))
+	for i, r := range resp.Results {
+		hits[i] = Hit{ID: r.ID, Score: r.Score, Payload: r.Payload}
+	}
+	return hits, nil
+}
+
+func (x PineconeIndex) Get(ctx context.Context, collection string, ids []string) ([]Hit, error) {
+	resp, err := x.Vectors.GetPointsByIDs(ctx, &pinecone.GetPointsByIDsRequest{
+		CollectionName: collection,
+		PointIDs:       ids,
+		WithPayload:    true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]Hit, len(resp.Points))
+	for i, p := range resp.Points {
+		hits[i] = Hit{ID: p.ID, Payload: p.Payload}
+	}
+	return hits, nil
+}
+
+// WeaviateIndex reads from a Weaviate class.
+type WeaviateIndex struct {
+	Vectors weaviate.Service
+}
+
+func (x WeaviateIndex) Search(ctx context.Context, collection string, vector []float32, limit int) ([]Hit, error) {
+	resp, err := x.Vectors.Search(ctx, &weaviate.SearchRequest{
+		CollectionName: collection,
+		Vector:         vector,
+		Limit:          uint64(limit),
+		WithPayload:    true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]Hit, len(resp.Results))
+	for i, r := range resp.Results {
+		hits[i] = Hit{ID: r.ID, Score: r.Score, Payload: r.Payload}
+	}
+	return hits, nil
+}
+
+func (x WeaviateIndex) Get(ctx context.Context, collection string, ids []string) ([]Hit, error) {
+	resp, err := x.Vectors.GetPointsByIDs(ctx, &weaviate.GetPointsByIDsRequest{
+		CollectionName: collection,
+		PointIDs:       ids,
+		WithPayload:    true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]Hit, len(resp.Points))
+	for i, p := range resp.Points {
+		hits[i] = Hit{ID: p.ID, Payload: p.Payload}
+	}
+	return hits, nil
+}
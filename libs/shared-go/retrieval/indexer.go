@@ -0,0 +1,57 @@
+package retrieval
+
+import (
+	"context"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/db/graph"
+)
+
+// GraphIndexer adds ingested chunks to the knowledge graph.
+type GraphIndexer struct {
+	store     graph.Store
+	extractor GraphExtractor
+}
+
+// NewGraphIndexer returns an indexer extracting with extractor into store.
+func NewGraphIndexer(store graph.Store, extractor GraphExtractor) *GraphIndexer {
+	return &GraphIndexer{store: store, extractor: extractor}
+}
+
+// Index extracts the entities and relations of a chunk and links them to its
+// ID, replacing what an earlier version of the chunk contributed.
+func (i *GraphIndexer) Index(ctx context.Context, chunkID, text string) (*graph.Extraction, error) {
+	x, err := i.extractor.Extract(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	x.Normalize(chunkID)
+	if err := i.store.AddChunk(ctx, chunkID, x); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// Remove unlinks a deleted chunk from the graph.
+func (i *GraphIndexer) Remove(ctx context.Context, chunkID string) error {
+	return i.store.RemoveChunk(ctx, chunkID)
+}
+
+// Missing returns the chunks among ids the graph has no record of, such as
+// those ingested before it was enabled, or while it was down.
+func (i *GraphIndexer) Missing(ctx context.Context, ids []string) ([]string, error) {
+	indexed, err := i.store.IndexedChunks(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool, len(indexed))
+	for _, id := range indexed {
+		found[id] = true
+	}
+	var missing []string
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,50 @@
+package retrieval
+
+import (
+	"context"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/db/graph"
+)
+
+// Retriever finds the chunks of a collection relevant to a query.
+type Retriever interface {
+	Retrieve(ctx context.Context, req *Request) ([]Chunk, error)
+}
+
+// VectorIndex is the part of a vector store that retrieval reads: nearest
+// neighbours and points by ID. It keeps the retrievers independent of the
+// backend; see LocalIndex, PineconeIndex and WeaviateIndex.
+type VectorIndex interface {
+	// Search returns up to limit points of collection nearest to vector,
+	// nearest first.
+	Search(ctx context.Context, collection string, vector []float32, limit int) ([]Hit, error)
+	// Get returns the points of collection found among ids, in the order
+	// asked for; unknown ids, or a collection that doesn't exist, find
+	// nothing.
+	Get(ctx context.Context, collection string, ids []string) ([]Hit, error)
+}
+
+// Reranker scores passages by their relevance to a query, more precisely
+// than the similarity of their embeddings.
+type Reranker interface {
+	// Rerank returns one score per passage, in the same order; higher is
+	// more relevant.
+	Rerank(ctx context.Context, query string, passages []string) ([]float32, error)
+}
+
+// GraphExtractor extracts entities and relations from a chunk or a question.
+type GraphExtractor interface {
+	Extract(ctx context.Context, text string) (*graph.Extraction, error)
+}
+
+// ParentStore keeps parent sections by collection, outside the vector store
+// since they are never searched.
+type ParentStore interface {
+	SaveParents(ctx context.Context, collection string, parents []Parent) error
+	// LoadParents returns the parents found among ids, in no particular
+	// order; unknown ids are skipped.
+	LoadParents(ctx context.Context, collection string, ids []string) ([]Parent, error)
+	DeleteParents(ctx context.Context, collection string, ids []string) error
+	// DeleteCollection removes every parent of collection.
+	DeleteCollection(ctx context.Context, collection string) error
+}
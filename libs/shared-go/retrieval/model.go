@@ -0,0 +1,136 @@
+package retrieval
+
+import "errors"
+
+// PayloadText is the payload key holding a chunk's text in the vector store.
+const PayloadText = "text"
+
+// PayloadParentID links a child chunk to the parent section it was cut
+// from, kept in a ParentStore.
+const PayloadParentID = "parent_id"
+
+// How a chunk was found.
+const (
+	ViaVector = "vector"
+	ViaGraph  = "graph"
+)
+
+// Modes of query transformation, applied before retrieval.
+const (
+	ModeStandard   = "standard"    // the question is searched as asked
+	ModeMultiQuery = "multi_query" // it and paraphrases of it are searched, results fused
+	ModeHyDE       = "hyde"        // a hypothetical answer is embedded in its place
+	ModeStepBack   = "step_back"   // it and a more general question behind it are searched, results fused
+)
+
+const defaultTopK = 5
+
+var (
+	ErrEmptyQuery  = errors.New("query is required")
+	ErrNoEmbedder  = errors.New("no embedding provider is configured")
+	ErrUnknownMode = errors.New("mode must be standard, multi_query, hyde or step_back")
+)
+
+// Request is a retrieval query against one collection.
+type Request struct {
+	Query      string `json:"query"`
+	Collection string `json:"collection,omitempty"` // Empty uses the retriever's default
+	TopK       int    `json:"top_k,omitempty"`      // Chunks returned by vector search; 0 uses the default
+	Mode       string `json:"mode,omitempty"`       // Query transformation; empty uses the configured default
+
+	// Embed is embedded for vector search in place of Query, which still
+	// drives graph expansion and reranking.
+	Embed string `json:"-"`
+
+	// Queries, when set, are searched by vector in place of Query and their
+	// results fused, before graph expansion and reranking see Query once.
+	Queries []string `json:"-"`
+}
+
+// Hit is a point read from a VectorIndex.
+type Hit struct {
+	ID      string
+	Score   float32 // Similarity to the query vector; zero from Get
+	Payload map[string]any
+}
+
+// Chunk is a retrieved passage.
+type Chunk struct {
+	ID      string         `json:"id"`
+	Text    string         `json:"text"`
+	Score   float32        `json:"score"` // Similarity for vector hits, 1/(1+hops) for graph hits
+	Payload map[string]any `json:"payload,omitempty"`
+	Via     string         `json:"via"`            // ViaVector or ViaGraph
+	Hops    int            `json:"hops,omitempty"` // Graph distance from the question's entities
+
+	// RerankScore is the reranker's relevance score for a chunk it ranked,
+	// higher is better; Score is kept as retrieval found it.
+	RerankScore *float32 `json:"rerank_score,omitempty"`
+
+	// Children are the IDs of the child chunks matched when the chunk is a
+	// parent section returned in their place, best first.
+	Children []string `json:"children,omitempty"`
+}
+
+// Parent is a section small chunks were cut from. Only the chunks are
+// embedded and searched; the parent is returned in their place, giving the
+// model the context around what matched.
+type Parent struct {
+	ID      string         `json:"id"`
+	Text    string         `json:"text"`
+	Payload map[string]any `json:"payload,omitempty"` // Overrides the payload of its chunks, e.g. offsets
+}
+
+// GraphConfig tunes graph-augmented retrieval. Zero values use the defaults.
+type GraphConfig struct {
+	Hops      int // Relations followed from the question's entities; default 2
+	MaxChunks int // Graph-only chunks added to the vector hits; default TopK
+}
+
+func (c GraphConfig) withDefaults() GraphConfig {
+	if c.Hops <= 0 {
+		c.Hops = 2
+	}
+	return c
+}
+
+// TransformConfig tunes query transformation. Zero values use the defaults.
+type TransformConfig struct {
+	Mode        string // Used when a request doesn't name one; default ModeStandard
+	Model       string // Chat model rewriting queries; empty uses the provider's default
+	Paraphrases int    // Written in multi_query mode; default 3
+}
+
+func (c TransformConfig) withDefaults() TransformConfig {
+	if c.Mode == "" {
+		c.Mode = ModeStandard
+	}
+	if c.Paraphrases <= 0 {
+		c.Paraphrases = 3
+	}
+	return c
+}
+
+// Transformation is how a query was rewritten for retrieval.
+type Transformation struct {
+	Mode         string   `json:"mode"`
+	Queries      []string `json:"queries"`                // Searched, results fused when more than one
+	Hypothetical string   `json:"hypothetical,omitempty"` // Embedded in place of the question in hyde mode
+}
+
+// RerankConfig tunes reranking. Zero values use the defaults.
+type RerankConfig struct {
+	OverFetch int     // Candidates fetched per chunk asked for; default 4
+	Depth     int     // Most candidates reranked, best retrieved first; default 40
+	MinScore  float32 // Reranked chunks scoring below are dropped; 0 keeps them all
+}
+
+func (c RerankConfig) withDefaults() RerankConfig {
+	if c.OverFetch <= 0 {
+		c.OverFetch = 4
+	}
+	if c.Depth <= 0 {
+		c.Depth = 40
+	}
+	return c
+}
@@ -0,0 +1,92 @@
+package retrieval
+
+import (
+	"context"
+	"maps"
+
+	"go.uber.org/zap"
+)
+
+// childrenPerParent is how many chunks are fetched per result asked for,
+// since several may share a parent.
+const childrenPerParent = 3
+
+// ParentRetriever matches on small chunks and returns the larger parent
+// sections they were cut from, each once and ranked by its best chunk.
+// Chunks without a parent, or whose parent can't be read, are returned as
+// they are.
+type ParentRetriever struct {
+	base       Retriever
+	store      ParentStore
+	collection string
+	logger     *zap.Logger
+}
+
+// NewParentRetriever wraps base, reading parents from store, in collection
+// when a request doesn't name one.
+func NewParentRetriever(base Retriever, store ParentStore, collection string, logger *zap.Logger) *ParentRetriever {
+	return &ParentRetriever{base: base, store: store, collection: collection, logger: logger}
+}
+
+func (r *ParentRetriever) Retrieve(ctx context.Context, req *Request) ([]Chunk, error) {
+	wide := *req
+	wide.TopK = childrenPerParent * topK(req)
+	chunks, err := r.base.Retrieve(ctx, &wide)
+	if err != nil {
+		return nil, err
+	}
+
+	// Group the chunks under their parents, keeping the order in which each
+	// parent is first reached.
+	var (
+		results   []Chunk
+		parentIDs []string
+		byParent  = make(map[string]int) // index in results
+	)
+	for _, c := range chunks {
+		parentID, _ := c.Payload[PayloadParentID].(string)
+		if parentID == "" {
+			if len(results) < topK(req) {
+				results = append(results, c)
+			}
+			continue
+		}
+		if i, ok := byParent[parentID]; ok {
+			results[i].Children = append(results[i].Children, c.ID)
+			continue
+		}
+		if len(results) == topK(req) {
+			continue
+		}
+		byParent[parentID] = len(results)
+		parentIDs = append(parentIDs, parentID)
+		c.Children = []string{c.ID}
+		results = append(results, c)
+	}
+	if len(parentIDs) == 0 {
+		return results, nil
+	}
+
+	collection := req.Collection
+	if collection == "" {
+		collection = r.collection
+	}
+	parents, err := r.store.LoadParents(ctx, collection, parentIDs)
+	if err != nil {
+		r.logger.Warn("Failed to read parent sections", zap.Error(err))
+		parents = nil
+	}
+	for _, p := range parents {
+		c := &results[byParent[p.ID]]
+		c.ID = p.ID
+		c.Text = p.Text
+		c.Payload = maps.Clone(c.Payload)
+		maps.Copy(c.Payload, p.Payload)
+		delete(byParent, p.ID)
+	}
+	// Parents not read are stood in for by their best chunk.
+	for _, i := range byParent {
+		results[i].Children = nil
+	}
+	return results, nil
+}
@@ -0,0 +1,179 @@
+package retrieval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/db/vector/local"
+)
+
+// InMemoryParentStore keeps parents in process memory; they are lost on
+// restart, after which chunks are returned without them.
+type InMemoryParentStore struct {
+	mu      sync.RWMutex
+	parents map[string]map[string]Parent // by collection, then ID
+}
+
+func NewInMemoryParentStore() *InMemoryParentStore {
+	return &InMemoryParentStore{parents: make(map[string]map[string]Parent)}
+}
+
+func (s *InMemoryParentStore) SaveParents(ctx context.Context, collection string, parents []Parent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := s.parents[collection]
+	if stored == nil {
+		stored = make(map[string]Parent)
+		s.parents[collection] = stored
+	}
+	for _, p := range parents {
+		stored[p.ID] = p
+	}
+	return nil
+}
+
+func (s *InMemoryParentStore) LoadParents(ctx context.Context, collection string, ids []string) ([]Parent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var parents []Parent
+	for _, id := range ids {
+		if p, ok := s.parents[collection][id]; ok {
+			parents = append(parents, p)
+		}
+	}
+	return parents, nil
+}
+
+func (s *InMemoryParentStore) DeleteParents(ctx context.Context, collection string, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		delete(s.parents[collection], id)
+	}
+	return nil
+}
+
+func (s *InMemoryParentStore) DeleteCollection(ctx context.Context, collection string) error {
+	s.mu.Lock()
+	delete(s.parents, collection)
+	s.mu.Unlock()
+	return nil
+}
+
+// FileParentStore keeps each parent in its own JSON file, in a directory
+// per collection.
+type FileParentStore struct {
+	dir string
+}
+
+// NewFileParentStore creates dir if needed and returns a store backed by it.
+func NewFileParentStore(dir string) (*FileParentStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create parents directory: %w", err)
+	}
+	return &FileParentStore{dir: dir}, nil
+}
+
+func (s *FileParentStore) SaveParents(ctx context.Context, collection string, parents []Parent) error {
+	if err := local.ValidateCollectionName(collection); err != nil {
+		return err
+	}
+	dir := filepath.Join(s.dir, collection)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create parents directory: %w", err)
+	}
+	for _, p := range parents {
+		if !validParentID(p.ID) {
+			return fmt.Errorf("invalid parent ID %q", p.ID)
+		}
+		data, err := json.Marshal(p)
+		if err != nil {
+			return fmt.Errorf("failed to encode parent %s: %w", p.ID, err)
+		}
+		if err := writeFile(dir, p.ID, data); err != nil {
+			return fmt.Errorf("failed to save parent %s: %w", p.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *FileParentStore) LoadParents(ctx context.Context, collection string, ids []string) ([]Parent, error) {
+	if local.ValidateCollectionName(collection) != nil {
+		return nil, nil
+	}
+	var parents []Parent
+	for _, id := range ids {
+		if !validParentID(id) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, collection, id+".json"))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read parent %s: %w", id, err)
+		}
+		var p Parent
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, fmt.Errorf("failed to decode parent %s: %w", id, err)
+		}
+		parents = append(parents, p)
+	}
+	return parents, nil
+}
+
+func (s *FileParentStore) DeleteParents(ctx context.Context, collection string, ids []string) error {
+	if local.ValidateCollectionName(collection) != nil {
+		return nil
+	}
+	for _, id := range ids {
+		if !validParentID(id) {
+			continue
+		}
+		err := os.Remove(filepath.Join(s.dir, collection, id+".json"))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to delete parent %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func (s *FileParentStore) DeleteCollection(ctx context.Context, collection string) error {
+	if local.ValidateCollectionName(collection) != nil {
+		return nil
+	}
+	if err := os.RemoveAll(filepath.Join(s.dir, collection)); err != nil {
+		return fmt.Errorf("failed to delete parents of %s: %w", collection, err)
+	}
+	return nil
+}
+
+// writeFile replaces dir/id.json atomically via a temporary file.
+func writeFile(dir, id string, data []byte) error {
+	tmp, err := os.CreateTemp(dir, id+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, id+".json"))
+}
+
+// validParentID keeps ids read from payloads from naming paths outside the
+// store.
+func validParentID(id string) bool {
+	return id != "" && id != "." && id != ".." && filepath.Base(id) == id
+}
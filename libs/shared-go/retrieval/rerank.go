@@ -0,0 +1,75 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+// RerankingRetriever over-fetches candidates from another retriever and
+// reorders the best of them with a reranker, which weeds out the
+// near-duplicate and off-topic chunks that embedding similarity lets
+// through. Candidates beyond the rerank depth follow the reranked ones in
+// their retrieved order.
+type RerankingRetriever struct {
+	base     Retriever
+	reranker Reranker
+	cfg      RerankConfig
+	logger   *zap.Logger
+}
+
+// NewRerankingRetriever wraps base, reordering its results with reranker.
+func NewRerankingRetriever(base Retriever, reranker Reranker, cfg RerankConfig, logger *zap.Logger) *RerankingRetriever {
+	return &RerankingRetriever{base: base, reranker: reranker, cfg: cfg.withDefaults(), logger: logger}
+}
+
+// Retrieve returns the top chunks after reranking. Reranking is best effort:
+// if it fails the candidates are returned in their retrieved order.
+func (r *RerankingRetriever) Retrieve(ctx context.Context, req *Request) ([]Chunk, error) {
+	wide := *req
+	wide.TopK = r.cfg.OverFetch * topK(req)
+	candidates, err := r.base.Retrieve(ctx, &wide)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked, err := r.rerank(ctx, req.Query, candidates)
+	if err != nil {
+		r.logger.Warn("Reranking failed", zap.Error(err))
+		ranked = candidates
+	}
+	return ranked[:min(topK(req), len(ranked))], nil
+}
+
+func (r *RerankingRetriever) rerank(ctx context.Context, query string, candidates []Chunk) ([]Chunk, error) {
+	depth := min(r.cfg.Depth, len(candidates))
+	if depth == 0 {
+		return candidates, nil
+	}
+	passages := make([]string, depth)
+	for i, c := range candidates[:depth] {
+		passages[i] = c.Text
+	}
+	scores, err := r.reranker.Rerank(ctx, query, passages)
+	if err != nil {
+		return nil, err
+	}
+	if len(scores) != depth {
+		return nil, fmt.Errorf("got %d scores for %d passages", len(scores), depth)
+	}
+
+	ranked := make([]Chunk, 0, len(candidates))
+	for i, c := range candidates[:depth] {
+		if scores[i] < r.cfg.MinScore {
+			continue
+		}
+		c.RerankScore = &scores[i]
+		ranked = append(ranked, c)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return *ranked[i].RerankScore > *ranked[j].RerankScore
+	})
+	return append(ranked, candidates[depth:]...), nil
+}
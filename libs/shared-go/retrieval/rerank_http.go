@@ -0,0 +1,154 @@
+package retrieval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai/retry"
+	"go.uber.org/zap"
+)
+
+// Request formats of cross-encoder scoring endpoints.
+const (
+	// FormatTEI is Hugging Face Text Embeddings Inference's /rerank:
+	// {"query", "texts"} in, [{"index", "score"}] out.
+	FormatTEI = "tei"
+	// FormatCohere is Cohere's /v1/rerank, which Jina's and most hosted
+	// rerankers also speak: {"model", "query", "documents"} in,
+	// {"results": [{"index", "relevance_score"}]} out.
+	FormatCohere = "cohere"
+)
+
+// CrossEncoderConfig locates a cross-encoder scoring endpoint.
+type CrossEncoderConfig struct {
+	URL     string        // Full endpoint URL, e.g. http://localhost:8080/rerank
+	Format  string        // FormatTEI (default) or FormatCohere
+	Model   string        // Sent in the Cohere format; TEI serves one model
+	APIKey  string        // Sent as a bearer token when set
+	Timeout time.Duration // Per request; default 30s
+}
+
+// CrossEncoderReranker scores passages with a cross-encoder served over
+// HTTP, which reads the query and a passage together and so judges
+// relevance far better than comparing their embeddings.
+type CrossEncoderReranker struct {
+	cfg     CrossEncoderConfig
+	client  *http.Client
+	retrier *retry.Retrier
+}
+
+// NewCrossEncoderReranker returns a Reranker calling the endpoint of cfg.
+func NewCrossEncoderReranker(cfg CrossEncoderConfig, logger *zap.Logger) (*CrossEncoderReranker, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("cross-encoder reranker needs a URL")
+	}
+	switch cfg.Format {
+	case "":
+		cfg.Format = FormatTEI
+	case FormatTEI, FormatCohere:
+	default:
+		return nil, fmt.Errorf("unknown reranker format %q, expected %s or %s", cfg.Format, FormatTEI, FormatCohere)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	return &CrossEncoderReranker{
+		cfg:     cfg,
+		client:  &http.Client{Timeout: cfg.Timeout},
+		retrier: retry.New(nil, logger),
+	}, nil
+}
+
+type teiRequest struct {
+	Query    string   `json:"query"`
+	Texts    []string `json:"texts"`
+	Truncate bool     `json:"truncate"`
+}
+
+type teiScore struct {
+	Index int     `json:"index"`
+	Score float32 `json:"score"`
+}
+
+type cohereRequest struct {
+	Model     string   `json:"model,omitempty"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n"`
+}
+
+type cohereResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float32 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+func (r *CrossEncoderReranker) Rerank(ctx context.Context, query string, passages []string) ([]float32, error) {
+	var body any = teiRequest{Query: query, Texts: passages, Truncate: true}
+	if r.cfg.Format == FormatCohere {
+		body = cohereRequest{Model: r.cfg.Model, Query: query, Documents: passages, TopN: len(passages)}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rerank request: %w", err)
+	}
+
+	build := func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.cfg.URL, bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if r.cfg.APIKey != "" {
+			req.Header.Set("Authorization", "Bearer "+r.cfg.APIKey)
+		}
+		return req, nil
+	}
+	resp, err := r.retrier.Do(ctx, r.client, true, build)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send rerank request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("reranker returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	scores := make([]float32, len(passages))
+	scored := make([]bool, len(passages))
+	set := func(index int, score float32) {
+		if index >= 0 && index < len(scores) {
+			scores[index], scored[index] = score, true
+		}
+	}
+	if r.cfg.Format == FormatCohere {
+		var out cohereResponse
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal rerank response: %w", err)
+		}
+		for _, res := range out.Results {
+			set(res.Index, res.RelevanceScore)
+		}
+	} else {
+		var out []teiScore
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal rerank response: %w", err)
+		}
+		for _, res := range out {
+			set(res.Index, res.Score)
+		}
+	}
+	for i, ok := range scored {
+		if !ok {
+			return nil, fmt.Errorf("reranker returned no score for passage %d", i)
+		}
+	}
+	return scores, nil
+}
@@ -0,0 +1,68 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/prompts"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/tokenizer"
+)
+
+const (
+	rerankMaxTokens = 512
+
+	// rerankPassageTokens caps each passage in the prompt; the start of a
+	// passage is enough to judge it.
+	rerankPassageTokens = 300
+)
+
+// LLMReranker ranks passages listwise with a chat completion using the
+// rerank_passages prompt: the model sees every passage at once and lists
+// the useful ones, best first. A passage's score is 1 for the first listed,
+// falling by 1/n per place, and 0 when the model left it out.
+type LLMReranker struct {
+	provider ai.ChatProvider
+	model    string
+	prompts  *prompts.Registry
+}
+
+// NewLLMReranker returns a Reranker backed by provider. model may be empty
+// to use the provider's default.
+func NewLLMReranker(provider ai.ChatProvider, model string, registry *prompts.Registry) *LLMReranker {
+	return &LLMReranker{provider: provider, model: model, prompts: registry}
+}
+
+func (r *LLMReranker) Rerank(ctx context.Context, query string, passages []string) ([]float32, error) {
+	model := r.model
+	if model == "" {
+		model = r.provider.GetModel()
+	}
+	tok := tokenizer.ForModel(model)
+	numbered := make([]string, len(passages))
+	for i, p := range passages {
+		numbered[i] = fmt.Sprintf("[%d] %s", i+1, tok.Truncate(p, rerankPassageTokens))
+	}
+
+	var reply struct {
+		Ranking []int `json:"ranking"`
+	}
+	err := completeJSON(ctx, r.provider, r.prompts, prompts.RerankPassages, map[string]any{
+		"question": query,
+		"passages": numbered,
+	}, r.model, rerankMaxTokens, &reply)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rerank: %w", err)
+	}
+
+	scores := make([]float32, len(passages))
+	place := 0
+	for _, n := range reply.Ranking {
+		if n < 1 || n > len(passages) || scores[n-1] > 0 {
+			continue // not a passage, or listed twice
+		}
+		scores[n-1] = 1 - float32(place)/float32(len(passages))
+		place++
+	}
+	return scores, nil
+}
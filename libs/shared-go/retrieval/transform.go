@@ -0,0 +1,156 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/prompts"
+)
+
+const (
+	transformMaxTokens = 512
+
+	// fusionK damps the weight of the top ranks in reciprocal rank fusion;
+	// 60 is the usual value.
+	fusionK = 60
+)
+
+// QueryTransformer rewrites questions with a chat completion before
+// retrieval, which helps short or vague questions find their passages.
+type QueryTransformer struct {
+	provider ai.ChatProvider
+	prompts  *prompts.Registry
+	cfg      TransformConfig
+}
+
+// NewQueryTransformer returns a transformer backed by provider, using the
+// expand_query, hypothetical_answer and step_back_question prompts.
+func NewQueryTransformer(provider ai.ChatProvider, registry *prompts.Registry, cfg TransformConfig) *QueryTransformer {
+	return &QueryTransformer{provider: provider, prompts: registry, cfg: cfg.withDefaults()}
+}
+
+// ParseMode returns the mode named s, or ErrUnknownMode. Empty is allowed
+// and stands for the default.
+func ParseMode(s string) (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(s)); mode {
+	case "", ModeStandard, ModeMultiQuery, ModeHyDE, ModeStepBack:
+		return mode, nil
+	}
+	return "", ErrUnknownMode
+}
+
+// Mode returns the mode req asks for, or the default.
+func (t *QueryTransformer) Mode(req *Request) (string, error) {
+	mode, err := ParseMode(req.Mode)
+	if err != nil || mode != "" {
+		return mode, err
+	}
+	return t.cfg.Mode, nil
+}
+
+// Transform rewrites the query of req as its mode asks.
+func (t *QueryTransformer) Transform(ctx context.Context, req *Request) (*Transformation, error) {
+	mode, err := t.Mode(req)
+	if err != nil {
+		return nil, err
+	}
+	x := &Transformation{Mode: mode, Queries: []string{req.Query}}
+
+	switch mode {
+	case ModeMultiQuery:
+		var reply struct {
+			Queries []string `json:"queries"`
+		}
+		err := completeJSON(ctx, t.provider, t.prompts, prompts.ExpandQuery, map[string]any{
+			"question": req.Query,
+			"count":    t.cfg.Paraphrases,
+		}, t.cfg.Model, transformMaxTokens, &reply)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transform query: %w", err)
+		}
+		seen := map[string]bool{strings.ToLower(req.Query): true}
+		for _, q := range reply.Queries {
+			if q = strings.TrimSpace(q); q == "" || seen[strings.ToLower(q)] {
+				continue
+			}
+			seen[strings.ToLower(q)] = true
+			x.Queries = append(x.Queries, q)
+			if len(x.Queries) > t.cfg.Paraphrases {
+				break
+			}
+		}
+
+	case ModeHyDE:
+		reply, err := t.complete(ctx, prompts.HypotheticalAnswer, map[string]any{"question": req.Query})
+		if err != nil {
+			return nil, err
+		}
+		x.Hypothetical = strings.TrimSpace(reply)
+
+	case ModeStepBack:
+		reply, err := t.complete(ctx, prompts.StepBackQuestion, map[string]any{"question": req.Query})
+		if err != nil {
+			return nil, err
+		}
+		line, _, _ := strings.Cut(strings.TrimSpace(reply), "\n")
+		if q := strings.Trim(strings.TrimSpace(line), `"`); q != "" && !strings.EqualFold(q, req.Query) {
+			x.Queries = append(x.Queries, q)
+		}
+	}
+	return x, nil
+}
+
+func (t *QueryTransformer) complete(ctx context.Context, name string, vars map[string]any) (string, error) {
+	reply, err := complete(ctx, t.provider, t.prompts, name, vars, t.cfg.Model, transformMaxTokens)
+	if err != nil {
+		return "", fmt.Errorf("failed to transform query: %w", err)
+	}
+	return reply, nil
+}
+
+// RetrieveTransformed retrieves with r once for the queries of x: the
+// vector stage searches each of them, embedding the hypothetical answer in
+// their place if any, and fuses the results with fuse; graph expansion,
+// reranking and parent lookup then run once against the original question.
+func RetrieveTransformed(ctx context.Context, r Retriever, req *Request, x *Transformation) ([]Chunk, error) {
+	sub := *req
+	sub.Queries = x.Queries
+	sub.Embed = x.Hypothetical
+	return r.Retrieve(ctx, &sub)
+}
+
+// fuse merges result lists by reciprocal rank and keeps the best k: a chunk
+// ranks by the sum of 1/(60+rank) over the lists it is in, so chunks found
+// by several queries rise. A chunk keeps the copy with its highest score.
+func fuse(lists [][]Chunk, k int) []Chunk {
+	if len(lists) == 1 {
+		return lists[0][:min(k, len(lists[0]))]
+	}
+
+	fused := make(map[string]float64)
+	best := make(map[string]Chunk)
+	var order []string
+	for _, list := range lists {
+		for rank, c := range list {
+			if prev, ok := best[c.ID]; !ok {
+				order = append(order, c.ID)
+				best[c.ID] = c
+			} else if c.Score > prev.Score {
+				best[c.ID] = c
+			}
+			fused[c.ID] += 1 / float64(fusionK+rank+1)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return fused[order[i]] > fused[order[j]]
+	})
+
+	chunks := make([]Chunk, 0, min(k, len(order)))
+	for _, id := range order[:min(k, len(order))] {
+		chunks = append(chunks, best[id])
+	}
+	return chunks
+}
@@ -0,0 +1,105 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/embedding"
+)
+
+// VectorRetriever embeds the query and returns the nearest chunks. Given
+// several queries it searches each and fuses the results.
+type VectorRetriever struct {
+	vectors    VectorIndex
+	embedder   embedding.Provider
+	collection string
+}
+
+// NewVectorRetriever searches vectors, using collection when a request
+// doesn't name one. embedder may be nil, in which case Retrieve fails with
+// ErrNoEmbedder.
+func NewVectorRetriever(vectors VectorIndex, embedder embedding.Provider, collection string) *VectorRetriever {
+	return &VectorRetriever{vectors: vectors, embedder: embedder, collection: collection}
+}
+
+func (r *VectorRetriever) Retrieve(ctx context.Context, req *Request) ([]Chunk, error) {
+	if strings.TrimSpace(req.Query) == "" {
+		return nil, ErrEmptyQuery
+	}
+	if r.embedder == nil {
+		return nil, ErrNoEmbedder
+	}
+
+	queries := req.Queries
+	if len(queries) == 0 {
+		queries = []string{req.Query}
+	}
+	lists := make([][]Chunk, len(queries))
+	errs := make([]error, len(queries))
+	var wg sync.WaitGroup
+	for i, q := range queries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lists[i], errs[i] = r.search(ctx, req, q)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return fuse(lists, topK(req)), nil
+}
+
+// search returns the chunks nearest to query, or to req.Embed if set.
+func (r *VectorRetriever) search(ctx context.Context, req *Request, query string) ([]Chunk, error) {
+	text := query
+	if req.Embed != "" {
+		text = req.Embed
+	}
+	vector, err := r.embedder.CreateEmbedding(ctx, text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	hits, err := r.vectors.Search(ctx, r.Collection(req), vector, topK(req))
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make([]Chunk, len(hits))
+	for i, res := range hits {
+		chunks[i] = newChunk(res.ID, res.Payload, res.Score, ViaVector)
+	}
+	return chunks, nil
+}
+
+// Collection returns the collection req targets.
+func (r *VectorRetriever) Collection(req *Request) string {
+	if req.Collection != "" {
+		return req.Collection
+	}
+	return r.collection
+}
+
+func topK(req *Request) int {
+	if req.TopK > 0 {
+		return req.TopK
+	}
+	return defaultTopK
+}
+
+func newChunk(id string, payload map[string]any, score float32, via string) Chunk {
+	text, _ := payload[PayloadText].(string)
+	meta := make(map[string]any, len(payload))
+	for k, v := range payload {
+		if k != PayloadText {
+			meta[k] = v
+		}
+	}
+	return Chunk{ID: id, Text: text, Score: score, Payload: meta, Via: via}
+}
@@ -0,0 +1,184 @@
+package tokenizer
+
+import (
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// BPE is a byte pair encoding tokenizer using tiktoken rank files. Special
+// tokens in the input are encoded as ordinary text, which is what counting
+// user-supplied content needs.
+type BPE struct {
+	name     string
+	ranks    map[string]int
+	decoder  map[int]string
+	splitter *splitter
+}
+
+func newBPE(spec encodingSpec) (*BPE, error) {
+	ranks, err := loadRanks(vocabFiles, spec.name)
+	if err != nil {
+		return nil, err
+	}
+	return fromRanks(spec, ranks)
+}
+
+// fromRanks builds the tokenizer of spec from its parsed rank file.
+func fromRanks(spec encodingSpec, ranks map[string]int) (*BPE, error) {
+	// Every byte must be a token on its own or merge could produce pieces
+	// with no rank.
+	for b := 0; b < 256; b++ {
+		if _, ok := ranks[string([]byte{byte(b)})]; !ok {
+			return nil, fmt.Errorf("%s vocab has no token for byte %#x", spec.name, b)
+		}
+	}
+
+	decoder := make(map[int]string, len(ranks)+len(spec.special))
+	for token, rank := range ranks {
+		decoder[rank] = token
+	}
+	for token, rank := range spec.special {
+		decoder[rank] = token
+	}
+
+	return &BPE{
+		name:     spec.name,
+		ranks:    ranks,
+		decoder:  decoder,
+		splitter: newSplitter(spec.pattern),
+	}, nil
+}
+
+func (e *BPE) Name() string {
+	return e.name
+}
+
+func (e *BPE) Exact() bool {
+	return true
+}
+
+// Encode returns the tokens of text.
+func (e *BPE) Encode(text string) []int {
+	tokens := make([]int, 0, len(text)/3+1)
+	e.splitter.split(text, func(piece string) bool {
+		tokens = e.appendPiece(tokens, piece)
+		return true
+	})
+	return tokens
+}
+
+// Decode returns the text of tokens. Unknown tokens are skipped.
+func (e *BPE) Decode(tokens []int) string {
+	var buf []byte
+	for _, t := range tokens {
+		buf = append(buf, e.decoder[t]...)
+	}
+	return string(buf)
+}
+
+func (e *BPE) Count(text string) int {
+	count := 0
+	e.splitter.split(text, func(piece string) bool {
+		if _, ok := e.ranks[piece]; ok {
+			count++
+		} else {
+			count += len(e.merge(piece)) - 1
+		}
+		return true
+	})
+	return count
+}
+
+func (e *BPE) Truncate(text string, maxTokens int) string {
+	if maxTokens <= 0 {
+		return ""
+	}
+
+	count, end := 0, 0
+	e.splitter.split(text, func(piece string) bool {
+		tokens := e.appendPiece(nil, piece)
+		if count+len(tokens) <= maxTokens {
+			count += len(tokens)
+			end += len(piece)
+			return true
+		}
+
+		// Keep the tokens of this piece that still fit. Token boundaries
+		// can fall inside a multi-byte character, so back off to the last
+		// complete one.
+		partial := len(e.Decode(tokens[:maxTokens-count]))
+		for partial > 0 && !utf8.ValidString(piece[:partial]) {
+			partial--
+		}
+		end += partial
+		return false
+	})
+	return text[:end]
+}
+
+// appendPiece appends the tokens of one pre-token to tokens.
+func (e *BPE) appendPiece(tokens []int, piece string) []int {
+	if rank, ok := e.ranks[piece]; ok {
+		return append(tokens, rank)
+	}
+	bounds := e.merge(piece)
+	for i := 0; i < len(bounds)-1; i++ {
+		tokens = append(tokens, e.ranks[piece[bounds[i]:bounds[i+1]]])
+	}
+	return tokens
+}
+
+// merge applies the BPE merges to piece and returns the token boundaries,
+// including 0 and len(piece). It is the algorithm of tiktoken's
+// byte_pair_merge: repeatedly join the adjacent pair with the lowest rank.
+func (e *BPE) merge(piece string) []int {
+	type part struct {
+		start int
+		rank  int
+	}
+
+	parts := make([]part, len(piece)+1)
+	for i := range parts {
+		parts[i] = part{start: i, rank: math.MaxInt}
+	}
+	for i := 0; i < len(parts)-2; i++ {
+		if r, ok := e.ranks[piece[parts[i].start:parts[i+2].start]]; ok {
+			parts[i].rank = r
+		}
+	}
+
+	// rankAt is the rank of the pair starting at i once parts[i+1] is removed.
+	rankAt := func(i int) int {
+		if i+3 < len(parts) {
+			if r, ok := e.ranks[piece[parts[i].start:parts[i+3].start]]; ok {
+				return r
+			}
+		}
+		return math.MaxInt
+	}
+
+	for len(parts) > 1 {
+		minRank, minIdx := math.MaxInt, -1
+		for i := 0; i < len(parts)-1; i++ {
+			if parts[i].rank < minRank {
+				minRank, minIdx = parts[i].rank, i
+			}
+		}
+		if minIdx < 0 {
+			break
+		}
+
+		if minIdx > 0 {
+			parts[minIdx-1].rank = rankAt(minIdx - 1)
+		}
+		parts[minIdx].rank = rankAt(minIdx)
+		parts = append(parts[:minIdx+1], parts[minIdx+2:]...)
+	}
+
+	bounds := make([]int, len(parts))
+	for i, p := range parts {
+		bounds[i] = p.start
+	}
+	return bounds
+}
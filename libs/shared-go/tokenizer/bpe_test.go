@@ -0,0 +1,201 @@
+package tokenizer
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// toyBPE has every byte as a token, ranked by its value, plus the merges
+// given in order from rank 256.
+func toyBPE(t *testing.T, merges ...string) *BPE {
+	t.Helper()
+	ranks := make(map[string]int, 256+len(merges))
+	for b := 0; b < 256; b++ {
+		ranks[string([]byte{byte(b)})] = b
+	}
+	for i, m := range merges {
+		ranks[m] = 256 + i
+	}
+	e, err := fromRanks(encodingSpec{name: "toy", pattern: cl100kPattern}, ranks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestMergeLowestRankFirst(t *testing.T) {
+	tests := []struct {
+		merges []string
+		text   string
+		want   []int
+	}{
+		{[]string{"ab", "bc", "abc"}, "abcd", []int{258, 'd'}},
+		{[]string{"bc", "ab"}, "abc", []int{'a', 256}},
+		{[]string{"ab", "bc"}, "abc", []int{256, 'c'}},
+		{[]string{"aa", "aaaa"}, "aaaaa", []int{257, 'a'}},
+		{nil, "ab", []int{'a', 'b'}},
+		{[]string{"ab"}, "ab ab", []int{256, ' ', 256}},
+	}
+	for _, tt := range tests {
+		e := toyBPE(t, tt.merges...)
+		got := e.Encode(tt.text)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("merges %q: Encode(%q) = %v, want %v", tt.merges, tt.text, got, tt.want)
+		}
+		if n := e.Count(tt.text); n != len(tt.want) {
+			t.Errorf("merges %q: Count(%q) = %d, want %d", tt.merges, tt.text, n, len(tt.want))
+		}
+		if d := e.Decode(got); d != tt.text {
+			t.Errorf("merges %q: Decode(Encode(%q)) = %q", tt.merges, tt.text, d)
+		}
+	}
+}
+
+func TestFromRanksNeedsEveryByte(t *testing.T) {
+	_, err := fromRanks(encodingSpec{name: "toy", pattern: cl100kPattern}, map[string]int{"a": 0})
+	if err == nil {
+		t.Fatal("expected an error for a vocab missing byte tokens")
+	}
+}
+
+func TestTruncateToy(t *testing.T) {
+	e := toyBPE(t, "ab", "cd")
+	tests := []struct {
+		text string
+		max  int
+		want string
+	}{
+		{"abcd ef", 0, ""},
+		{"abcd ef", 1, "ab"},
+		{"abcd ef", 2, "abcd"},
+		{"abcd ef", 4, "abcd e"},
+		{"abcd ef", 10, "abcd ef"},
+		// "é" is two byte tokens; half of it is dropped.
+		{"é", 1, ""},
+		{"aé", 2, "a"},
+	}
+	for _, tt := range tests {
+		if got := e.Truncate(tt.text, tt.max); got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.text, tt.max, got, tt.want)
+		}
+	}
+}
+
+// testdata holds a small vocab in the rank file format, plain as
+// toy.tiktoken and gzipped as toy_gz.tiktoken.gz: every byte ranked by its
+// value, then the merges he, ll, hell, hello, " w", or, " wor", ld and
+// " world" from rank 256.
+func TestLoadRanksFromFiles(t *testing.T) {
+	tests := []struct {
+		text string
+		want []int
+	}{
+		{"hello world", []int{259, 264}},
+		{"hello worlds", []int{259, 264, 's'}},
+		{"shell", []int{'s', 258}},
+		{"old", []int{'o', 263}},
+	}
+	for _, name := range []string{"toy", "toy_gz"} {
+		ranks, err := loadRanks(os.DirFS("testdata"), name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ranks) != 265 {
+			t.Fatalf("%s: loaded %d ranks, want 265", name, len(ranks))
+		}
+		e, err := fromRanks(encodingSpec{name: name, pattern: cl100kPattern}, ranks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tt := range tests {
+			got := e.Encode(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s: Encode(%q) = %v, want %v", name, tt.text, got, tt.want)
+			}
+			if n := e.Count(tt.text); n != len(tt.want) {
+				t.Errorf("%s: Count(%q) = %d, want %d", name, tt.text, n, len(tt.want))
+			}
+		}
+		if got := e.Truncate("hello worlds", 2); got != "hello world" {
+			t.Errorf("%s: Truncate(2) = %q", name, got)
+		}
+	}
+
+	if _, err := loadRanks(os.DirFS("testdata"), "missing"); !errors.Is(err, ErrVocabMissing) {
+		t.Errorf("loadRanks(missing) = %v, want ErrVocabMissing", err)
+	}
+}
+
+// The expected tokens are the output of tiktoken for the same text.
+func TestEncodeKnownOutputs(t *testing.T) {
+	tests := []struct {
+		encoding string
+		text     string
+		want     []int
+	}{
+		{CL100KBase, "hello world", []int{15339, 1917}},
+		{CL100KBase, "Hello, world!", []int{9906, 11, 1917, 0}},
+		{CL100KBase, "tiktoken is great!", []int{83, 1609, 5963, 374, 2294, 0}},
+		{O200KBase, "Hello, world!", []int{13225, 11, 2375, 0}},
+		{O200KBase, "tiktoken is great!", []int{83, 8251, 2488, 382, 2212, 0}},
+	}
+	for _, tt := range tests {
+		e := encoding(t, tt.encoding)
+		got := e.Encode(tt.text)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Encode(%q) = %v, want %v", tt.encoding, tt.text, got, tt.want)
+		}
+		if n := e.Count(tt.text); n != len(tt.want) {
+			t.Errorf("%s: Count(%q) = %d, want %d", tt.encoding, tt.text, n, len(tt.want))
+		}
+		if d := e.Decode(got); d != tt.text {
+			t.Errorf("%s: Decode(Encode(%q)) = %q", tt.encoding, tt.text, d)
+		}
+	}
+}
+
+func TestTruncateKnownOutputs(t *testing.T) {
+	e := encoding(t, CL100KBase)
+	// "tiktoken is great!" is t|ik|token| is| great|!
+	tests := []struct {
+		max  int
+		want string
+	}{
+		{1, "t"},
+		{3, "tiktoken"},
+		{4, "tiktoken is"},
+		{6, "tiktoken is great!"},
+	}
+	for _, tt := range tests {
+		if got := e.Truncate("tiktoken is great!", tt.max); got != tt.want {
+			t.Errorf("Truncate(%d) = %q, want %q", tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestForModel(t *testing.T) {
+	if got := EncodingForModel("gpt-4o-mini"); got != O200KBase {
+		t.Errorf("EncodingForModel(gpt-4o-mini) = %q", got)
+	}
+	if got := EncodingForModel("ft:gpt-3.5-turbo:acme::abc"); got != CL100KBase {
+		t.Errorf("EncodingForModel(ft:gpt-3.5-turbo) = %q", got)
+	}
+	if got := ForModel("llama3.1").Name(); got != Heuristic {
+		t.Errorf("ForModel(llama3.1) = %q", got)
+	}
+}
+
+// encoding loads a real vocab, skipping the test when it isn't embedded.
+func encoding(t *testing.T, name string) *BPE {
+	t.Helper()
+	tok, err := Get(name)
+	if errors.Is(err, ErrVocabMissing) {
+		t.Skipf("%s vocab not embedded", name)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tok.(*BPE)
+}
@@ -0,0 +1,48 @@
+package tokenizer
+
+import (
+	"math"
+	"unicode/utf8"
+)
+
+// heuristic estimates tokens for models whose vocabulary we don't have (most
+// local models). English text averages about four bytes per token across
+// common vocabularies; other scripts average close to one token per character.
+type heuristic struct{}
+
+func (heuristic) Name() string {
+	return Heuristic
+}
+
+func (heuristic) Exact() bool {
+	return false
+}
+
+func (heuristic) Count(text string) int {
+	cost := 0.0
+	for _, r := range text {
+		cost += runeCost(r)
+	}
+	return int(math.Ceil(cost))
+}
+
+func (heuristic) Truncate(text string, maxTokens int) string {
+	if maxTokens <= 0 {
+		return ""
+	}
+	cost := 0.0
+	for i, r := range text {
+		cost += runeCost(r)
+		if math.Ceil(cost) > float64(maxTokens) {
+			return text[:i]
+		}
+	}
+	return text
+}
+
+func runeCost(r rune) float64 {
+	if r < utf8.RuneSelf {
+		return 0.25
+	}
+	return 1
+}
@@ -0,0 +1,17 @@
+package tokenizer
+
+// Tokenizer counts and truncates text in the tokens of one encoding.
+type Tokenizer interface {
+	// Name returns the encoding name, e.g. "cl100k_base".
+	Name() string
+
+	// Count returns the number of tokens in text.
+	Count(text string) int
+
+	// Truncate returns the longest prefix of text that fits in maxTokens,
+	// never splitting a UTF-8 character.
+	Truncate(text string, maxTokens int) string
+
+	// Exact reports whether counts are exact rather than estimated.
+	Exact() bool
+}
@@ -0,0 +1,44 @@
+package tokenizer
+
+import "errors"
+
+// Encoding names.
+const (
+	CL100KBase = "cl100k_base" // gpt-4, gpt-3.5-turbo, text-embedding-3-*
+	O200KBase  = "o200k_base"  // gpt-4o, gpt-4.1, o1, o3, o4
+	Heuristic  = "heuristic"   // Estimate for models without a known encoding
+)
+
+// ErrVocabMissing is returned when an encoding's vocab file was not embedded
+// at build time (see vocab/README.md).
+var ErrVocabMissing = errors.New("tokenizer vocab not embedded")
+
+// encodingSpec describes a BPE encoding: its pre-tokenizer pattern and its
+// special tokens.
+type encodingSpec struct {
+	name    string
+	pattern string
+	special map[string]int
+}
+
+var specs = map[string]encodingSpec{
+	CL100KBase: {
+		name:    CL100KBase,
+		pattern: cl100kPattern,
+		special: map[string]int{
+			"<|endoftext|>":   100257,
+			"<|fim_prefix|>":  100258,
+			"<|fim_middle|>":  100259,
+			"<|fim_suffix|>":  100260,
+			"<|endofprompt|>": 100276,
+		},
+	},
+	O200KBase: {
+		name:    O200KBase,
+		pattern: o200kPattern,
+		special: map[string]int{
+			"<|endoftext|>":   199999,
+			"<|endofprompt|>": 200018,
+		},
+	},
+}
@@ -0,0 +1,84 @@
+package tokenizer
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The pre-tokenizer patterns of tiktoken, adapted to RE2. \s is spelled out
+// as Unicode White_Space (RE2's \s is ASCII only), and the "\s+(?!\S)"
+// lookahead alternative is emulated in splitter.split.
+const (
+	ws = `\t\n\v\f\r\x{85}\p{Z}`
+
+	contractions = `(?i:'s|'t|'re|'ve|'m|'ll|'d)`
+
+	cl100kPattern = contractions +
+		`|[^\r\n\p{L}\p{N}]?\p{L}+` +
+		`|\p{N}{1,3}` +
+		`| ?[^` + ws + `\p{L}\p{N}]+[\r\n]*` +
+		`|[` + ws + `]*[\r\n]+` +
+		`|[` + ws + `]+`
+
+	o200kPattern = `[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+` + contractions + `?` +
+		`|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*` + contractions + `?` +
+		`|\p{N}{1,3}` +
+		`| ?[^` + ws + `\p{L}\p{N}]+[\r\n/]*` +
+		`|[` + ws + `]*[\r\n]+` +
+		`|[` + ws + `]+`
+)
+
+type splitter struct {
+	re *regexp.Regexp
+}
+
+func newSplitter(pattern string) *splitter {
+	return &splitter{re: regexp.MustCompile(pattern)}
+}
+
+// split calls fn with each pre-token of text in order, stopping if fn
+// returns false.
+func (s *splitter) split(text string, fn func(piece string) bool) {
+	for pos := 0; pos < len(text); {
+		loc := s.re.FindStringIndex(text[pos:])
+		if loc == nil || loc[1] == 0 {
+			fn(text[pos:])
+			return
+		}
+		if loc[0] > 0 {
+			// Every character is matched by some alternative, so this only
+			// guards against a pattern change.
+			if !fn(text[pos : pos+loc[0]]) {
+				return
+			}
+		}
+
+		start, end := pos+loc[0], pos+loc[1]
+		end = trimTrailingSpace(text, start, end)
+		if !fn(text[start:end]) {
+			return
+		}
+		pos = end
+	}
+}
+
+// trimTrailingSpace emulates "\s+(?!\S)": a run of whitespace followed by a
+// non-space character leaves its last character to start the next token, so
+// " x" stays together. Runs ending in a newline come from "\s*[\r\n]+" and are
+// kept whole.
+func trimTrailingSpace(text string, start, end int) int {
+	if end >= len(text) {
+		return end
+	}
+	piece := text[start:end]
+	if strings.IndexFunc(piece, func(r rune) bool { return !unicode.IsSpace(r) }) >= 0 {
+		return end
+	}
+	last, size := utf8.DecodeLastRuneInString(piece)
+	if last == '\r' || last == '\n' || size == len(piece) {
+		return end
+	}
+	return end - size
+}
@@ -0,0 +1,73 @@
+package tokenizer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func pieces(pattern, text string) []string {
+	var out []string
+	newSplitter(pattern).split(text, func(piece string) bool {
+		out = append(out, piece)
+		return true
+	})
+	return out
+}
+
+// The expected splits are those of tiktoken's own regexes.
+func TestSplitCL100K(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Hello, world!", []string{"Hello", ",", " world", "!"}},
+		{"I'm here, they'LL see", []string{"I", "'m", " here", ",", " they", "'LL", " see"}},
+		{"don't", []string{"don", "'t"}},
+		{"HelloWorld", []string{"HelloWorld"}},
+		{"12345 apples", []string{"123", "45", " apples"}},
+		{"a  b", []string{"a", " ", " b"}},
+		{"a   ", []string{"a", "   "}},
+		{"line1\n\nline2", []string{"line", "1", "\n\n", "line", "2"}},
+		{"foo   \nbar", []string{"foo", "   \n", "bar"}},
+		{"$$$abc", []string{"$$$", "abc"}},
+		{"x := y\n", []string{"x", " :=", " y", "\n"}},
+		{"naïve café", []string{"naïve", " café"}},
+		{"a  b", []string{"a", " ", " b"}},
+	}
+	for _, tt := range tests {
+		if got := pieces(cl100kPattern, tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("split(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSplitO200K(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Hello, world!", []string{"Hello", ",", " world", "!"}},
+		{"HelloWorld", []string{"Hello", "World"}},
+		{"don't", []string{"don't"}},
+		{"HTTPServer", []string{"HTTPServer"}},
+		{"12345", []string{"123", "45"}},
+		{"a//b\n", []string{"a", "//", "b", "\n"}},
+		{"foo   \nbar", []string{"foo", "   \n", "bar"}},
+	}
+	for _, tt := range tests {
+		if got := pieces(o200kPattern, tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("split(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSplitStops(t *testing.T) {
+	var got []string
+	newSplitter(cl100kPattern).split("one two three", func(piece string) bool {
+		got = append(got, piece)
+		return len(got) < 2
+	})
+	if want := []string{"one", " two"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("split = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,98 @@
+package tokenizer
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+)
+
+// modelEncodings maps model name patterns (path.Match globs, tried in order)
+// to encodings. Models not listed use the heuristic.
+var modelEncodings = []struct {
+	pattern  string
+	encoding string
+}{
+	{"gpt-4o*", O200KBase},
+	{"chatgpt-4o*", O200KBase},
+	{"gpt-4.1*", O200KBase},
+	{"gpt-4.5*", O200KBase},
+	{"gpt-5*", O200KBase},
+	{"o1*", O200KBase},
+	{"o3*", O200KBase},
+	{"o4*", O200KBase},
+	{"gpt-4*", CL100KBase},
+	{"gpt-3.5*", CL100KBase},
+	{"text-embedding-3-*", CL100KBase},
+	{"text-embedding-ada-002*", CL100KBase},
+}
+
+var (
+	mu       sync.Mutex
+	encoders = make(map[string]Tokenizer)
+	failures = make(map[string]error) // Load errors are remembered so they aren't retried per call
+)
+
+// EncodingForModel returns the encoding name used by model, or Heuristic.
+func EncodingForModel(model string) string {
+	model = strings.ToLower(model)
+	// Fine-tuned models are named "ft:<base>:<org>...".
+	if base, found := strings.CutPrefix(model, "ft:"); found {
+		model, _, _ = strings.Cut(base, ":")
+	}
+	for _, m := range modelEncodings {
+		if ok, _ := path.Match(m.pattern, model); ok {
+			return m.encoding
+		}
+	}
+	return Heuristic
+}
+
+// Get returns the tokenizer for an encoding name. Encodings are loaded on
+// first use.
+func Get(encoding string) (Tokenizer, error) {
+	if encoding == Heuristic {
+		return heuristic{}, nil
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if t, ok := encoders[encoding]; ok {
+		return t, nil
+	}
+	if err, ok := failures[encoding]; ok {
+		return nil, err
+	}
+
+	spec, ok := specs[encoding]
+	if !ok {
+		return nil, fmt.Errorf("unknown tokenizer encoding %q", encoding)
+	}
+	t, err := newBPE(spec)
+	if err != nil {
+		failures[encoding] = err
+		return nil, err
+	}
+	encoders[encoding] = t
+	return t, nil
+}
+
+// ForModel returns the tokenizer for model, falling back to the heuristic
+// when the model's encoding is unknown or its vocab isn't available.
+func ForModel(model string) Tokenizer {
+	t, err := Get(EncodingForModel(model))
+	if err != nil {
+		return heuristic{}
+	}
+	return t
+}
+
+// Count returns the number of tokens in text for model.
+func Count(model, text string) int {
+	return ForModel(model).Count(text)
+}
+
+// Truncate returns the longest prefix of text that fits in maxTokens for model.
+func Truncate(model, text string, maxTokens int) string {
+	return ForModel(model).Truncate(text, maxTokens)
+}
@@ -0,0 +1,80 @@
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+)
+
+// vocab holds the tiktoken rank files, committed gzipped as
+// <encoding>.tiktoken.gz and compiled into the binary so token counting works
+// offline. An uncompressed <encoding>.tiktoken is read too.
+//
+//go:embed vocab
+var vocab embed.FS
+
+// vocabFiles is the vocab directory itself.
+var vocabFiles, _ = fs.Sub(vocab, "vocab")
+
+// readVocab returns the contents of an encoding's rank file in fsys.
+func readVocab(fsys fs.FS, name string) ([]byte, error) {
+	compressed, err := fs.ReadFile(fsys, name+".tiktoken.gz")
+	if errors.Is(err, fs.ErrNotExist) {
+		data, err := fs.ReadFile(fsys, name+".tiktoken")
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrVocabMissing, name)
+		}
+		return data, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// loadRanks parses a tiktoken rank file of fsys: one "<base64 token> <rank>"
+// per line.
+func loadRanks(fsys fs.FS, name string) (map[string]int, error) {
+	data, err := readVocab(fsys, name)
+	if errors.Is(err, ErrVocabMissing) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s vocab: %w", name, err)
+	}
+
+	ranks := make(map[string]int, bytes.Count(data, []byte("\n"))+1)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		token, rank, found := bytes.Cut(scanner.Bytes(), []byte(" "))
+		if !found {
+			return nil, fmt.Errorf("invalid %s vocab line %d", name, line)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(string(token))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s vocab token on line %d: %w", name, line, err)
+		}
+		r, err := strconv.Atoi(string(rank))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s vocab rank on line %d: %w", name, line, err)
+		}
+		ranks[string(decoded)] = r
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s vocab: %w", name, err)
+	}
+	return ranks, nil
+}
@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/prompts"
+)
+
+const (
+	extractMaxTokens = 512
+	maxFactLength    = 300
+)
+
+// LLMExtractor extracts facts with a chat completion using the extract_facts
+// prompt.
+type LLMExtractor struct {
+	provider ai.ChatProvider
+	model    string
+	prompts  *prompts.Registry
+}
+
+// NewLLMExtractor returns a FactExtractor backed by provider. model may be
+// empty to use the provider's default.
+func NewLLMExtractor(provider ai.ChatProvider, model string, registry *prompts.Registry) *LLMExtractor {
+	return &LLMExtractor{provider: provider, model: model, prompts: registry}
+}
+
+func (e *LLMExtractor) Extract(ctx context.Context, known []string, messages []ai.Message) ([]string, error) {
+	var transcript strings.Builder
+	for _, m := range messages {
+		if m.Role == ai.RoleSystem {
+			continue
+		}
+		fmt.Fprintf(&transcript, "%s: %s\n\n", m.Role, m.Text())
+	}
+
+	prompt, _, err := e.prompts.Render(prompts.ExtractFacts, map[string]any{
+		"known":      known,
+		"transcript": strings.TrimSpace(transcript.String()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	temperature := 0.0
+	resp, err := e.provider.Completion(ctx, []ai.Message{
+		{Role: ai.RoleUser, Content: prompt},
+	}, &ai.ChatOptions{
+		Model:       e.model,
+		Temperature: &temperature,
+		MaxTokens:   extractMaxTokens,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract facts: %w", err)
+	}
+	return parseFacts(resp.Content)
+}
+
+// parseFacts reads the JSON array of facts from a model reply, tolerating
+// code fences or prose around it.
+func parseFacts(reply string) ([]string, error) {
+	start, end := strings.Index(reply, "["), strings.LastIndex(reply, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("failed to extract facts: no JSON array in reply")
+	}
+
+	var raw []string
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &raw); err != nil {
+		return nil, fmt.Errorf("failed to extract facts: %w", err)
+	}
+
+	facts := make([]string, 0, len(raw))
+	for _, fact := range raw {
+		fact = strings.TrimSpace(fact)
+		if fact == "" || len(fact) > maxFactLength {
+			continue
+		}
+		facts = append(facts, fact)
+	}
+	return facts, nil
+}
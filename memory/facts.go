@@ -0,0 +1,277 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/embedding"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/db/vector/local"
+	"github.com/google/uuid"
+)
+
+// maxKnownFacts caps how many remembered facts are shown to the extractor.
+const maxKnownFacts = 50
+
+// Facts is the long-term memory: facts about each user, extracted from their
+// conversations and kept in a vector collection per user so the relevant ones
+// can be recalled on later turns. It is safe for concurrent use; learning for
+// the same user is serialized.
+type Facts struct {
+	vectors   local.Service
+	embedder  embedding.Provider
+	extractor FactExtractor
+	cfg       FactsConfig
+	locks     keyLocks // by user ID
+}
+
+// NewFacts creates a long-term memory storing facts in vectors.
+func NewFacts(vectors local.Service, embedder embedding.Provider, extractor FactExtractor, cfg FactsConfig) *Facts {
+	return &Facts{
+		vectors:   vectors,
+		embedder:  embedder,
+		extractor: extractor,
+		cfg:       cfg.withDefaults(),
+	}
+}
+
+// Learn extracts facts from messages and stores them for the user. A fact
+// close enough to one already known replaces it, so restated or updated facts
+// don't pile up. It returns the facts that were added or replaced.
+func (f *Facts) Learn(ctx context.Context, userID, conversationID string, messages []ai.Message) ([]Fact, error) {
+	if err := ValidateUserID(userID); err != nil {
+		return nil, err
+	}
+	unlock := f.locks.lock(userID)
+	defer unlock()
+
+	known, err := f.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(known, func(i, j int) bool { return known[i].UpdatedAt.After(known[j].UpdatedAt) })
+	if len(known) > maxKnownFacts {
+		known = known[:maxKnownFacts]
+	}
+	knownTexts := make([]string, len(known))
+	for i, fact := range known {
+		knownTexts[i] = fact.Text
+	}
+
+	candidates, err := f.extractor.Extract(ctx, knownTexts, messages)
+	if err != nil || len(candidates) == 0 {
+		return nil, err
+	}
+	vectors, err := f.embedder.CreateEmbeddings(ctx, candidates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed facts: %w", err)
+	}
+	if err := f.vectors.CreateCollection(ctx, &local.CreateCollectionRequest{
+		CollectionName: collectionName(userID),
+		VectorSize:     uint64(len(vectors[0])),
+		Distance:       "cosine",
+	}); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	var (
+		learned []Fact
+		points  []local.Point
+	)
+	for i, text := range candidates {
+		// A candidate restating one learned earlier in this batch is dropped.
+		if containsClose(points, vectors[i], f.cfg.DedupeThreshold) {
+			continue
+		}
+
+		fact := Fact{ID: uuid.NewString(), Text: text, Source: conversationID, CreatedAt: now, UpdatedAt: now}
+		match, err := f.closest(ctx, userID, vectors[i])
+		if err != nil {
+			return nil, err
+		}
+		if match != nil && match.Score >= f.cfg.DedupeThreshold {
+			fact.ID, fact.CreatedAt = match.ID, match.CreatedAt
+		}
+
+		learned = append(learned, fact)
+		points = append(points, local.Point{ID: fact.ID, Vector: vectors[i], Payload: factPayload(fact)})
+	}
+	if len(points) == 0 {
+		return nil, nil
+	}
+
+	if err := f.vectors.UpsertPoints(ctx, &local.UpsertPointsRequest{
+		CollectionName: collectionName(userID),
+		Points:         points,
+	}); err != nil {
+		return nil, err
+	}
+	return learned, nil
+}
+
+// Recall returns the user's facts most relevant to query, best first.
+func (f *Facts) Recall(ctx context.Context, userID, query string) ([]Fact, error) {
+	if err := ValidateUserID(userID); err != nil {
+		return nil, err
+	}
+	vector, err := f.embedder.CreateEmbedding(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	resp, err := f.vectors.Search(ctx, &local.SearchRequest{
+		CollectionName: collectionName(userID),
+		Vector:         vector,
+		Limit:          uint64(f.cfg.RecallLimit),
+		ScoreThreshold: f.cfg.RecallThreshold,
+		WithPayload:    true,
+	})
+	if errors.Is(err, local.ErrCollectionNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	facts := make([]Fact, len(resp.Results))
+	for i, r := range resp.Results {
+		facts[i] = factFromPayload(r.ID, r.Payload)
+		facts[i].Score = r.Score
+	}
+	return facts, nil
+}
+
+// List returns every fact remembered about the user, oldest first.
+func (f *Facts) List(ctx context.Context, userID string) ([]Fact, error) {
+	if err := ValidateUserID(userID); err != nil {
+		return nil, err
+	}
+	resp, err := f.vectors.ListPoints(ctx, &local.ListPointsRequest{CollectionName: collectionName(userID)})
+	if errors.Is(err, local.ErrCollectionNotFound) {
+		return []Fact{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	facts := make([]Fact, len(resp.Points))
+	for i, p := range resp.Points {
+		facts[i] = factFromPayload(p.ID, p.Payload)
+	}
+	sort.SliceStable(facts, func(i, j int) bool { return facts[i].CreatedAt.Before(facts[j].CreatedAt) })
+	return facts, nil
+}
+
+// Forget deletes one fact, or returns ErrFactNotFound.
+func (f *Facts) Forget(ctx context.Context, userID, factID string) error {
+	if err := ValidateUserID(userID); err != nil {
+		return err
+	}
+	unlock := f.locks.lock(userID)
+	defer unlock()
+
+	resp, err := f.vectors.GetPointsByIDs(ctx, &local.GetPointsByIDsRequest{
+		CollectionName: collectionName(userID),
+		PointIDs:       []string{factID},
+	})
+	if errors.Is(err, local.ErrCollectionNotFound) {
+		return ErrFactNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if len(resp.Points) == 0 {
+		return ErrFactNotFound
+	}
+
+	return f.vectors.DeletePoints(ctx, &local.DeletePointsRequest{
+		CollectionName: collectionName(userID),
+		PointIDs:       []string{factID},
+	})
+}
+
+// ForgetAll deletes everything remembered about the user.
+func (f *Facts) ForgetAll(ctx context.Context, userID string) error {
+	if err := ValidateUserID(userID); err != nil {
+		return err
+	}
+	unlock := f.locks.lock(userID)
+	defer unlock()
+
+	return f.vectors.DeleteCollection(ctx, collectionName(userID))
+}
+
+// closest returns the known fact nearest to vector, or nil if there is none.
+func (f *Facts) closest(ctx context.Context, userID string, vector []float32) (*Fact, error) {
+	resp, err := f.vectors.Search(ctx, &local.SearchRequest{
+		CollectionName: collectionName(userID),
+		Vector:         vector,
+		Limit:          1,
+		WithPayload:    true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Results) == 0 {
+		return nil, nil
+	}
+	fact := factFromPayload(resp.Results[0].ID, resp.Results[0].Payload)
+	fact.Score = resp.Results[0].Score
+	return &fact, nil
+}
+
+// collectionName returns the vector collection holding a user's facts.
+func collectionName(userID string) string {
+	return "facts_" + userID
+}
+
+func factPayload(f Fact) local.Payload {
+	return local.Payload{
+		"text":       f.Text,
+		"source":     f.Source,
+		"created_at": f.CreatedAt.Format(time.RFC3339Nano),
+		"updated_at": f.UpdatedAt.Format(time.RFC3339Nano),
+	}
+}
+
+func factFromPayload(id string, p local.Payload) Fact {
+	fact := Fact{ID: id}
+	fact.Text, _ = p["text"].(string)
+	fact.Source, _ = p["source"].(string)
+	if v, ok := p["created_at"].(string); ok {
+		fact.CreatedAt, _ = time.Parse(time.RFC3339Nano, v)
+	}
+	if v, ok := p["updated_at"].(string); ok {
+		fact.UpdatedAt, _ = time.Parse(time.RFC3339Nano, v)
+	}
+	return fact
+}
+
+// containsClose reports whether any of points has a vector whose cosine
+// similarity to v is at least threshold.
+func containsClose(points []local.Point, v []float32, threshold float32) bool {
+	for _, p := range points {
+		if cosine(p.Vector, v) >= threshold {
+			return true
+		}
+	}
+	return false
+}
+
+func cosine(a, b []float32) float32 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(normA) * math.Sqrt(normB)))
+}
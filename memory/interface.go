@@ -0,0 +1,32 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+)
+
+// Store persists conversations by ID.
+type Store interface {
+	// Load returns the conversation, or ErrNotFound.
+	Load(ctx context.Context, id string) (*Conversation, error)
+	Save(ctx context.Context, conv *Conversation) error
+	Delete(ctx context.Context, id string) error
+}
+
+// Strategy decides which turns of a conversation are kept. Compact is called
+// after new messages are appended and may evict turns from conv.Messages.
+type Strategy interface {
+	Compact(ctx context.Context, conv *Conversation) error
+}
+
+// Summarizer folds evicted turns into a running synopsis.
+type Summarizer interface {
+	Summarize(ctx context.Context, summary string, turns []ai.Message) (string, error)
+}
+
+// FactExtractor picks out durable facts about the user from a conversation.
+// known holds facts already remembered so they aren't extracted again.
+type FactExtractor interface {
+	Extract(ctx context.Context, known []string, messages []ai.Message) ([]string, error)
+}
@@ -1 +1,166 @@
 package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai/window"
+)
+
+var validID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
+// Memory is the conversation memory: a Store of conversations compacted by
+// a Strategy. It is safe for concurrent use; calls for the same conversation
+// are serialized.
+type Memory struct {
+	store    Store
+	strategy Strategy
+	locks    keyLocks // by conversation ID
+}
+
+// ValidateID reports whether id can name a conversation. IDs double as
+// file names in the FileStore, so only letters, digits, '-' and '_' are
+// allowed.
+func ValidateID(id string) error {
+	if !validID.MatchString(id) {
+		return ErrInvalidID
+	}
+	return nil
+}
+
+// ValidateUserID is ValidateID for the user IDs facts are kept under.
+func ValidateUserID(id string) error {
+	if !validID.MatchString(id) {
+		return ErrInvalidUserID
+	}
+	return nil
+}
+
+// New creates a Memory.
+func New(store Store, strategy Strategy) *Memory {
+	return &Memory{store: store, strategy: strategy}
+}
+
+// History returns the messages to replay before the next turn: the summary of
+// evicted turns, if any, as a system message, then the buffered turns. owner
+// is the user continuing the conversation, empty if anonymous; it must match
+// the conversation's owner, or ErrNotOwner is returned.
+func (m *Memory) History(ctx context.Context, id, owner string) ([]ai.Message, error) {
+	conv, err := m.Get(ctx, id, owner)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]ai.Message, 0, len(conv.Messages)+1)
+	if conv.Summary != "" {
+		history = append(history, ai.Message{Role: ai.RoleSystem, Content: window.SummaryPrefix + conv.Summary})
+	}
+	return append(history, conv.Messages...), nil
+}
+
+// Get returns the stored conversation, or ErrNotOwner if it isn't owner's.
+func (m *Memory) Get(ctx context.Context, id, owner string) (*Conversation, error) {
+	if err := ValidateID(id); err != nil {
+		return nil, err
+	}
+	conv, err := m.store.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !conv.accessibleBy(owner) {
+		return nil, ErrNotOwner
+	}
+	return conv, nil
+}
+
+// Append adds messages to the conversation and compacts it. Images are
+// remembered as ImagePlaceholder. A new conversation is owned by owner; an
+// existing one must be owner's, or ErrNotOwner is returned. The messages are
+// saved even if compaction fails; the error is returned after saving.
+func (m *Memory) Append(ctx context.Context, id, owner string, messages ...ai.Message) error {
+	if err := ValidateID(id); err != nil {
+		return err
+	}
+
+	unlock := m.locks.lock(id)
+	defer unlock()
+
+	conv, err := m.store.Load(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		conv, err = &Conversation{ID: id}, nil
+		if owner != AnyOwner {
+			conv.Owner = owner
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if !conv.accessibleBy(owner) {
+		return ErrNotOwner
+	}
+
+	conv.Messages = append(conv.Messages, withoutImages(messages)...)
+	compactErr := m.strategy.Compact(ctx, conv)
+	conv.UpdatedAt = time.Now()
+
+	if err := m.store.Save(ctx, conv); err != nil {
+		return err
+	}
+	if compactErr != nil {
+		return fmt.Errorf("failed to compact conversation %s: %w", id, compactErr)
+	}
+	return nil
+}
+
+// Clear forgets the conversation, if it is owner's.
+func (m *Memory) Clear(ctx context.Context, id, owner string) error {
+	if err := ValidateID(id); err != nil {
+		return err
+	}
+
+	unlock := m.locks.lock(id)
+	defer unlock()
+
+	conv, err := m.store.Load(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !conv.accessibleBy(owner) {
+		return ErrNotOwner
+	}
+	return m.store.Delete(ctx, id)
+}
+
+// ImagePlaceholder stands in for an image in remembered messages; the image
+// itself isn't kept.
+const ImagePlaceholder = "[image]"
+
+// withoutImages returns messages with their image parts replaced by
+// ImagePlaceholder, so conversations don't persist image bytes.
+func withoutImages(messages []ai.Message) []ai.Message {
+	out := make([]ai.Message, len(messages))
+	for i, m := range messages {
+		out[i] = m
+		if len(m.Images()) == 0 {
+			continue
+		}
+		out[i].Parts = make([]ai.ContentPart, len(m.Parts))
+		for j, p := range m.Parts {
+			if p.IsImage() {
+				p = ai.TextPart(ImagePlaceholder)
+			}
+			out[i].Parts[j] = p
+		}
+	}
+	return out
+}
@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+)
+
+var (
+	ErrNotFound  = errors.New("conversation not found")
+	ErrInvalidID = errors.New("invalid conversation id")
+	ErrNotOwner  = errors.New("conversation belongs to another user")
+
+	ErrFactNotFound  = errors.New("fact not found")
+	ErrInvalidUserID = errors.New("invalid user id")
+)
+
+// Conversation is the remembered state of one conversation.
+type Conversation struct {
+	ID        string       `json:"id"`
+	Owner     string       `json:"owner,omitempty"`   // User who started the conversation; empty if anonymous
+	Messages  []ai.Message `json:"messages"`          // Turns still in the buffer, oldest first
+	Summary   string       `json:"summary,omitempty"` // Synopsis of evicted turns (rolling summary only)
+	Evicted   int          `json:"evicted"`           // Messages evicted so far
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// AnyOwner passed as the owner to Memory skips the ownership check, for the
+// operator. It can't be a user ID.
+const AnyOwner = "*"
+
+// accessibleBy reports whether owner may read and continue the conversation:
+// anonymous conversations are open to everyone, the rest only to their owner.
+func (c *Conversation) accessibleBy(owner string) bool {
+	return c.Owner == "" || c.Owner == owner || owner == AnyOwner
+}
+
+// Turns groups the buffered messages into turns, each starting at a user
+// message.
+func (c *Conversation) Turns() [][]ai.Message {
+	var turns [][]ai.Message
+	for _, m := range c.Messages {
+		if m.Role == ai.RoleUser || len(turns) == 0 {
+			turns = append(turns, nil)
+		}
+		turns[len(turns)-1] = append(turns[len(turns)-1], m)
+	}
+	return turns
+}
+
+// evict drops the oldest n turns from the buffer and returns their messages.
+func (c *Conversation) evict(n int) []ai.Message {
+	turns := c.Turns()
+	if n > len(turns) {
+		n = len(turns)
+	}
+	var evicted []ai.Message
+	for _, turn := range turns[:n] {
+		evicted = append(evicted, turn...)
+	}
+	c.Messages = c.Messages[len(evicted):]
+	c.Evicted += len(evicted)
+	return evicted
+}
+
+// Fact is a durable statement about a user, remembered across conversations.
+type Fact struct {
+	ID        string    `json:"id"`
+	Text      string    `json:"text"`
+	Source    string    `json:"source,omitempty"` // Conversation the fact was last stated in
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Score     float32   `json:"score,omitempty"` // Similarity to the query, set by Recall
+}
+
+// FactsConfig tunes long-term memory. Zero values use the defaults.
+type FactsConfig struct {
+	DedupeThreshold float32 // Similarity above which a new fact replaces a known one; default 0.9
+	RecallLimit     int     // Facts recalled per turn; default 5
+	RecallThreshold float32 // Minimum similarity for a fact to be recalled; default 0.3
+}
+
+func (c FactsConfig) withDefaults() FactsConfig {
+	if c.DedupeThreshold <= 0 {
+		c.DedupeThreshold = 0.9
+	}
+	if c.RecallLimit <= 0 {
+		c.RecallLimit = 5
+	}
+	if c.RecallThreshold <= 0 {
+		c.RecallThreshold = 0.3
+	}
+	return c
+}
@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+)
+
+// InMemoryStore keeps conversations in process memory.
+type InMemoryStore struct {
+	mu            sync.RWMutex
+	conversations map[string]*Conversation
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{conversations: make(map[string]*Conversation)}
+}
+
+func (s *InMemoryStore) Load(ctx context.Context, id string) (*Conversation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	conv, ok := s.conversations[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	// Hand out a copy so callers can't mutate the stored conversation.
+	c := *conv
+	c.Messages = append([]ai.Message(nil), conv.Messages...)
+	return &c, nil
+}
+
+func (s *InMemoryStore) Save(ctx context.Context, conv *Conversation) error {
+	c := *conv
+	c.Messages = append([]ai.Message(nil), conv.Messages...)
+	s.mu.Lock()
+	s.conversations[conv.ID] = &c
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *InMemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	delete(s.conversations, id)
+	s.mu.Unlock()
+	return nil
+}
+
+// FileStore keeps each conversation as a JSON file in a directory, so
+// conversations survive restarts.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates dir if needed and returns a store backed by it.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create memory directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) Load(ctx context.Context, id string) (*Conversation, error) {
+	data, err := os.ReadFile(s.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read conversation %s: %w", id, err)
+	}
+
+	var conv Conversation
+	if err := json.Unmarshal(data, &conv); err != nil {
+		return nil, fmt.Errorf("failed to decode conversation %s: %w", id, err)
+	}
+	return &conv, nil
+}
+
+// Save writes the conversation atomically via a temporary file.
+func (s *FileStore) Save(ctx context.Context, conv *Conversation) error {
+	data, err := json.Marshal(conv)
+	if err != nil {
+		return fmt.Errorf("failed to encode conversation %s: %w", conv.ID, err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, conv.ID+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save conversation %s: %w", conv.ID, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save conversation %s: %w", conv.ID, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save conversation %s: %w", conv.ID, err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(conv.ID)); err != nil {
+		return fmt.Errorf("failed to save conversation %s: %w", conv.ID, err)
+	}
+	return nil
+}
+
+func (s *FileStore) Delete(ctx context.Context, id string) error {
+	if err := os.Remove(s.path(id)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete conversation %s: %w", id, err)
+	}
+	return nil
+}
+
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/tokenizer"
+)
+
+// SlidingWindow keeps the most recent MaxTurns turns.
+type SlidingWindow struct {
+	MaxTurns int
+}
+
+func (s *SlidingWindow) Compact(ctx context.Context, conv *Conversation) error {
+	if excess := len(conv.Turns()) - s.MaxTurns; excess > 0 {
+		conv.evict(excess)
+	}
+	return nil
+}
+
+// TokenBuffer keeps the most recent turns that fit in MaxTokens, counted with
+// the tokenizer of Model. The latest turn is always kept.
+type TokenBuffer struct {
+	MaxTokens int
+	Model     string
+}
+
+func (s *TokenBuffer) Compact(ctx context.Context, conv *Conversation) error {
+	conv.evict(overBudget(conv, s.MaxTokens, s.Model))
+	return nil
+}
+
+// RollingSummary keeps recent turns like TokenBuffer, but folds evicted turns
+// into conv.Summary with the Summarizer instead of forgetting them.
+type RollingSummary struct {
+	MaxTokens  int
+	Model      string
+	Summarizer Summarizer
+}
+
+// Compact summarizes before evicting: if the summarizer fails, nothing is
+// evicted and the turns are retried on the next append.
+func (s *RollingSummary) Compact(ctx context.Context, conv *Conversation) error {
+	n := overBudget(conv, s.MaxTokens, s.Model)
+	if n == 0 {
+		return nil
+	}
+
+	var turns []ai.Message
+	for _, turn := range conv.Turns()[:n] {
+		turns = append(turns, turn...)
+	}
+	summary, err := s.Summarizer.Summarize(ctx, conv.Summary, turns)
+	if err != nil {
+		return err
+	}
+
+	conv.evict(n)
+	conv.Summary = summary
+	return nil
+}
+
+// overBudget returns how many of the oldest turns must go for the rest to fit
+// in maxTokens, never counting the latest turn.
+func overBudget(conv *Conversation, maxTokens int, model string) int {
+	tok := tokenizer.ForModel(model)
+	turns := conv.Turns()
+
+	used, keep := 0, 0
+	for i := len(turns) - 1; i >= 0; i-- {
+		cost := 0
+		for _, m := range turns[i] {
+			cost += 4 + tok.Count(m.Text()) + ai.ImageTokens*len(m.Images())
+		}
+		if keep > 0 && used+cost > maxTokens {
+			break
+		}
+		used += cost
+		keep++
+	}
+	return len(turns) - keep
+}
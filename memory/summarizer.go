@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Joepolymath/DaVinci/libs/shared-go/infra/ai"
+	"github.com/Joepolymath/DaVinci/libs/shared-go/prompts"
+)
+
+const summaryMaxTokens = 512
+
+// LLMSummarizer maintains the rolling summary with a chat completion using
+// the rolling_summary prompt.
+type LLMSummarizer struct {
+	provider ai.ChatProvider
+	model    string
+	prompts  *prompts.Registry
+}
+
+// NewLLMSummarizer returns a Summarizer backed by provider. model may be
+// empty to use the provider's default.
+func NewLLMSummarizer(provider ai.ChatProvider, model string, registry *prompts.Registry) *LLMSummarizer {
+	return &LLMSummarizer{provider: provider, model: model, prompts: registry}
+}
+
+func (s *LLMSummarizer) Summarize(ctx context.Context, summary string, turns []ai.Message) (string, error) {
+	var transcript strings.Builder
+	for _, m := range turns {
+		fmt.Fprintf(&transcript, "%s: %s\n\n", m.Role, m.Text())
+	}
+
+	prompt, _, err := s.prompts.Render(prompts.RollingSummary, map[string]any{
+		"summary":    summary,
+		"transcript": strings.TrimSpace(transcript.String()),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	temperature := 0.0
+	resp, err := s.provider.Completion(ctx, []ai.Message{
+		{Role: ai.RoleUser, Content: prompt},
+	}, &ai.ChatOptions{
+		Model:       s.model,
+		Temperature: &temperature,
+		MaxTokens:   summaryMaxTokens,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize conversation: %w", err)
+	}
+	return strings.TrimSpace(resp.Content), nil
+}